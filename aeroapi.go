@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// --- FlightAware AeroAPI enrichment ---
+// adsbdb knows the airframe but rarely the flight. With an AeroAPI key, alerts
+// for aircraft broadcasting a callsign also get the scheduled route and
+// departure/arrival times. It's the first provider in the route chain
+// (routes.go), whose cache keeps the per-query bill down.
+type AeroAPIConfig struct {
+	APIKey string `json:"api_key"`
+}
+
+const aeroAPIURL = "https://aeroapi.flightaware.com/aeroapi/flights/"
+
+// FlightRoute is what we know about the flight an aircraft is operating.
+type FlightRoute struct {
+	Ident           string
+	Origin          string // ICAO airport code
+	OriginName      string
+	Destination     string
+	DestinationName string
+	ScheduledOut    time.Time
+	ActualOut       time.Time
+	ScheduledIn     time.Time
+	EstimatedIn     time.Time
+	Registration    string
+	AircraftType    string
+	Source          string
+}
+
+func (r FlightRoute) String() string {
+	if r.Origin == "" && r.Destination == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s → %s", orUnknown(r.Origin), orUnknown(r.Destination))
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "?"
+	}
+	return s
+}
+
+type aeroAirport struct {
+	CodeICAO string `json:"code_icao"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	City     string `json:"city"`
+}
+
+func (a *aeroAirport) code() string {
+	if a == nil {
+		return ""
+	}
+	if a.CodeICAO != "" {
+		return a.CodeICAO
+	}
+	return a.Code
+}
+
+func (a *aeroAirport) label() string {
+	if a == nil {
+		return ""
+	}
+	if a.City != "" && a.City != a.Name {
+		return fmt.Sprintf("%s (%s)", a.Name, a.City)
+	}
+	return a.Name
+}
+
+type aeroFlight struct {
+	Ident        string       `json:"ident"`
+	Registration string       `json:"registration"`
+	AircraftType string       `json:"aircraft_type"`
+	Origin       *aeroAirport `json:"origin"`
+	Destination  *aeroAirport `json:"destination"`
+	ScheduledOut *time.Time   `json:"scheduled_out"`
+	ActualOut    *time.Time   `json:"actual_out"`
+	ActualOff    *time.Time   `json:"actual_off"`
+	ActualOn     *time.Time   `json:"actual_on"`
+	ScheduledIn  *time.Time   `json:"scheduled_in"`
+	EstimatedIn  *time.Time   `json:"estimated_in"`
+}
+
+// fetchAeroAPI returns nil without an error when AeroAPI has no airborne
+// flight for the callsign.
+func fetchAeroAPI(callsign string) (*FlightRoute, error) {
+	key := cfg().AeroAPI.APIKey
+	if key == "" {
+		return nil, nil
+	}
+	debugf("[EN] API FETCH: Fetching route for %s from AeroAPI\n", callsign)
+	req, _ := http.NewRequest("GET", aeroAPIURL+url.PathEscape(callsign), nil)
+	req.Header.Set("x-apikey", key)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("AeroAPI fetch error for %s: %v", callsign, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AeroAPI returned non-200 status: %s", resp.Status)
+	}
+
+	var body struct {
+		Flights []aeroFlight `json:"flights"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("AeroAPI JSON decode error for %s: %v", callsign, err)
+	}
+
+	// The ident covers a few days of flights; we want the one in the air now.
+	for _, f := range body.Flights {
+		if f.ActualOff == nil || f.ActualOn != nil {
+			continue
+		}
+		route := &FlightRoute{
+			Ident:           f.Ident,
+			Origin:          f.Origin.code(),
+			OriginName:      f.Origin.label(),
+			Destination:     f.Destination.code(),
+			DestinationName: f.Destination.label(),
+			Registration:    f.Registration,
+			AircraftType:    f.AircraftType,
+			Source:          "aeroapi",
+		}
+		for dst, src := range map[*time.Time]*time.Time{
+			&route.ScheduledOut: f.ScheduledOut,
+			&route.ActualOut:    f.ActualOut,
+			&route.ScheduledIn:  f.ScheduledIn,
+			&route.EstimatedIn:  f.EstimatedIn,
+		} {
+			if src != nil {
+				*dst = *src
+			}
+		}
+		return route, nil
+	}
+	return nil, nil
+}
+
+// routeFields renders a route as embed fields. Names are translated by the caller.
+func routeFields(r *FlightRoute) []Field {
+	if r == nil || r.String() == "" {
+		return nil
+	}
+	value := r.String()
+	if r.OriginName != "" || r.DestinationName != "" {
+		value = fmt.Sprintf("%s\n%s → %s", value, orUnknown(r.OriginName), orUnknown(r.DestinationName))
+	}
+	fields := []Field{{Name: "Route", Value: value, Inline: false}}
+	if !r.ActualOut.IsZero() {
+		fields = append(fields, Field{Name: "Departed", Value: fmt.Sprintf("<t:%d:t>", r.ActualOut.Unix()), Inline: true})
+	} else if !r.ScheduledOut.IsZero() {
+		fields = append(fields, Field{Name: "Scheduled Departure", Value: fmt.Sprintf("<t:%d:t>", r.ScheduledOut.Unix()), Inline: true})
+	}
+	if !r.EstimatedIn.IsZero() {
+		fields = append(fields, Field{Name: "ETA", Value: fmt.Sprintf("<t:%d:t>", r.EstimatedIn.Unix()), Inline: true})
+	} else if !r.ScheduledIn.IsZero() {
+		fields = append(fields, Field{Name: "Scheduled Arrival", Value: fmt.Sprintf("<t:%d:t>", r.ScheduledIn.Unix()), Inline: true})
+	}
+	return fields
+}
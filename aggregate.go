@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"main.go/geo"
+)
+
+// --- Aggregate-count rules ---
+// Evaluated over the whole poll snapshot rather than single aircraft, e.g.
+// "4+ military aircraft within 50nm" or "20+ aircraft below 5,000 ft".
+// Fires once per episode; the episode ends when the count drops below MinCount.
+// Muted aircraft don't count towards it.
+type AggregateRule struct {
+	Name     string     `json:"name"`
+	Classes  []string   `json:"classes"`
+	RadiusNM float64    `json:"radius_nm"`  // 0 = the whole poll radius
+	MinAltFT float64    `json:"min_alt_ft"` // 0 = no lower limit
+	MaxAltFT float64    `json:"max_alt_ft"` // 0 = no upper limit
+	MinCount int        `json:"min_count"`
+	Channel  string     `json:"channel"`
+	Schedule []Schedule `json:"schedule"`
+	Tags     []string   `json:"tags"`
+}
+
+var globalAggregateEpisodes = make(map[string]bool)
+
+func (r AggregateRule) matches(site sourceCenter, ac Aircraft) bool {
+	if !matchesClass(ac, r.Classes) {
+		return false
+	}
+	if r.RadiusNM > 0 {
+		lat, lon, hasCoords := getActualCoords(ac)
+		if !hasCoords || geo.DistanceNM(site.Lat, site.Lon, lat, lon) > r.RadiusNM {
+			return false
+		}
+	}
+	if r.MinAltFT > 0 || r.MaxAltFT > 0 {
+		altitudeFT, ok := altitudeFeet(ac)
+		if !ok || (r.MinAltFT > 0 && altitudeFT < r.MinAltFT) || (r.MaxAltFT > 0 && altitudeFT > r.MaxAltFT) {
+			return false
+		}
+	}
+	return true
+}
+
+// minCount is the rule's threshold, never below one so an unset min_count
+// doesn't fire on an empty poll.
+func (r AggregateRule) minCount() int {
+	return max(r.MinCount, 1)
+}
+
+func processAggregateAlerts(site sourceCenter, snapshot []Aircraft) {
+	rules := cfg().AggregateRules
+	for name := range globalAggregateEpisodes {
+		if !hasAggregateRule(rules, name) {
+			delete(globalAggregateEpisodes, name) // rule removed by a config reload
+		}
+	}
+
+	for _, rule := range rules {
+		var members []Aircraft
+		for _, ac := range snapshot {
+			if _, muted := isMuted(ac); muted {
+				continue
+			}
+			if rule.matches(site, ac) {
+				members = append(members, ac)
+			}
+		}
+
+		if len(members) < rule.minCount() {
+			if globalAggregateEpisodes[rule.Name] {
+				fmt.Printf("[Radius] Aggregate rule '%s' episode over (%d aircraft).\n", rule.Name, len(members))
+			}
+			delete(globalAggregateEpisodes, rule.Name)
+			continue
+		}
+		if globalAggregateEpisodes[rule.Name] || !ruleActive(rule.Name, rule.Schedule, clock.Now()) {
+			continue
+		}
+
+		fmt.Printf("[Radius] !!! AGGREGATE DETECTED: rule '%s' matched %d aircraft\n", rule.Name, len(members))
+		sendAggregateAlert(resolveChannel(rule.Channel), site, rule, members)
+		globalAggregateEpisodes[rule.Name] = true
+	}
+}
+
+func hasAggregateRule(rules []AggregateRule, name string) bool {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sendAggregateAlert(webhookURL string, site sourceCenter, rule AggregateRule, members []Aircraft) {
+	if webhookURL == "" {
+		fmt.Printf("[Discord] Webhook for aggregate rule '%s' is not set. Skipping.\n", rule.Name)
+		return
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Hex < members[j].Hex })
+	var lines []string
+	for _, ac := range members {
+		line := fmt.Sprintf("`%s` %s %s — %s", ac.Hex, strings.TrimSpace(ac.Flight), ac.Type, fmtAltBaro(ac.AltBaro))
+		if lat, lon, ok := getActualCoords(ac); ok {
+			line += ", " + fmtDistance(geo.DistanceNM(site.Lat, site.Lon, lat, lon))
+		}
+		lines = append(lines, line)
+	}
+	list := strings.Join(lines, "\n")
+	if len(list) > 3800 {
+		list = list[:3800] + "\n…"
+	}
+
+	embed := Embed{
+		Title:       fmt.Sprintf("Aggregate Alert: %s", rule.Name),
+		Description: fmt.Sprintf("**%d aircraft** currently match this rule:\n%s", len(members), list),
+		Color:       10181046, // Violet
+		Fields:      []Field{},
+		Footer:      Footer{Text: footerText(alertTags("aggregate", rule.Tags)) + " · " + formatTime(clock.Now())},
+	}
+	rec := AlertRecord{Time: clock.Now(), Type: "aggregate", Rule: rule.Name, Tags: alertTags("aggregate", rule.Tags), Outcome: "sent"}
+	for _, ac := range members {
+		rec.Members = append(rec.Members, ac.Hex)
+	}
+	defer func() { recordAlert(rec) }()
+
+	if outcome := quietHold(webhookURL, "aggregate", heldAlert{Title: embed.Title}); outcome != "" {
+		rec.Outcome = outcome
+		return
+	}
+	if err := postDiscordWebhook(webhookURL, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[Discord] Error sending aggregate alert: %v\n", err)
+		rec.Outcome = "error"
+	} else {
+		fmt.Printf("[Discord] Successfully sent aggregate alert for rule '%s'\n", rule.Name)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// --- Airlines ---
+// Callsigns come off the feed space-padded ("DAL1234 ") and are normalised
+// when aircraft are decoded. An airline callsign is the three letter ICAO
+// designator followed by the flight number; the designator is looked up in
+// the bundled airlines.csv, which config "airlines.extra" can add to or
+// override.
+//
+//go:embed airlines.csv
+var airlinesCSV string
+
+type Airline struct {
+	ICAO    string `json:"icao"`
+	IATA    string `json:"iata"`
+	Name    string `json:"name"`
+	Country string `json:"country"`
+}
+
+type AirlinesConfig struct {
+	LogoURL string    `json:"logo_url"` // template with {icao} or {iata}, empty for no logos
+	Extra   []Airline `json:"extra"`
+}
+
+var (
+	bundledAirlines     map[string]Airline
+	bundledAirlinesOnce sync.Once
+)
+
+func loadBundledAirlines() {
+	bundledAirlines = make(map[string]Airline)
+	rows, err := csv.NewReader(strings.NewReader(airlinesCSV)).ReadAll()
+	if err != nil {
+		fmt.Printf("[EN] Error reading bundled airlines: %v\n", err)
+		return
+	}
+	for _, row := range rows[1:] {
+		if len(row) < 4 {
+			continue
+		}
+		bundledAirlines[row[0]] = Airline{ICAO: row[0], IATA: row[1], Name: row[2], Country: row[3]}
+	}
+}
+
+// normalizeCallsign trims the padding and any stray inner spaces.
+func normalizeCallsign(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// splitCallsign separates "DAL1234" into "DAL" and "1234". Registrations
+// used as callsigns (N123AB, GABCD) don't split.
+func splitCallsign(flight string) (string, string, bool) {
+	flight = normalizeCallsign(flight)
+	if len(flight) < 4 || len(flight) > 8 {
+		return "", "", false
+	}
+	for i := range 3 {
+		if flight[i] < 'A' || flight[i] > 'Z' {
+			return "", "", false
+		}
+	}
+	if flight[3] < '0' || flight[3] > '9' {
+		return "", "", false
+	}
+	return flight[:3], flight[3:], true
+}
+
+func lookupAirline(designator string) (Airline, bool) {
+	for _, a := range cfg().Airlines.Extra {
+		if strings.EqualFold(a.ICAO, designator) {
+			return a, true
+		}
+	}
+	bundledAirlinesOnce.Do(loadBundledAirlines)
+	a, ok := bundledAirlines[strings.ToUpper(designator)]
+	return a, ok
+}
+
+// airlineFor resolves a callsign's operator. Military callsigns are left
+// to milcallsign.go even when the designator collides.
+func airlineFor(flight string) (Airline, string, bool) {
+	designator, number, ok := splitCallsign(flight)
+	if !ok {
+		return Airline{}, "", false
+	}
+	if _, mil := militaryCallsign(flight); mil {
+		return Airline{}, "", false
+	}
+	a, ok := lookupAirline(designator)
+	return a, number, ok
+}
+
+// callsignLabel is "Delta Air Lines 1234 (`DAL1234`)", or just the
+// callsign when the airline isn't known.
+func callsignLabel(flight string) string {
+	flight = normalizeCallsign(flight)
+	if a, number, ok := airlineFor(flight); ok {
+		return fmt.Sprintf("%s %s (`%s`)", a.Name, number, flight)
+	}
+	return fmt.Sprintf("`%s`", flight)
+}
+
+func airlineLogo(a Airline) string {
+	tmpl := cfg().Airlines.LogoURL
+	if tmpl == "" || (a.IATA == "" && strings.Contains(tmpl, "{iata}")) {
+		return ""
+	}
+	return strings.NewReplacer("{icao}", a.ICAO, "{iata}", a.IATA).Replace(tmpl)
+}
+
+// UnmarshalJSON normalises the callsign wherever aircraft are decoded.
+func (ac *Aircraft) UnmarshalJSON(b []byte) error {
+	type plain Aircraft
+	if err := json.Unmarshal(b, (*plain)(ac)); err != nil {
+		return err
+	}
+	ac.Flight = normalizeCallsign(ac.Flight)
+	return nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Airport board ---
+// Detects takeoffs and landings at one nearby airport from the radius feed
+// and keeps a rolling arrivals/departures board, with the other end of each
+// flight filled in from the route providers. Served at GET /airport (HTML)
+// and GET /airport.json, and summarised in the daily digest.
+//
+// A movement is an aircraft within radius_nm of the field, below max_agl_ft
+// above it, and either on the ground, climbing (departure) or descending
+// (arrival).
+type AirportBoardConfig struct {
+	ICAO        string   `json:"icao"` // empty disables the board
+	Name        string   `json:"name"`
+	Lat         float64  `json:"lat"`
+	Lon         float64  `json:"lon"`
+	ElevationFT *float64 `json:"elevation_ft"` // default: looked up from the elevation source
+	RadiusNM    float64  `json:"radius_nm"`    // default 4
+	MaxAGLFT    float64  `json:"max_agl_ft"`   // default 1500
+	Keep        Duration `json:"keep"`         // default 24h
+}
+
+const (
+	movementRateFPM  = 300              // climb/descent that counts as departing/arriving
+	movementCooldown = 45 * time.Minute // one arrival and one departure per visit
+)
+
+type Movement struct {
+	Time         time.Time `json:"time"`
+	Kind         string    `json:"kind"` // arrival, departure
+	Hex          string    `json:"hex"`
+	Flight       string    `json:"flight,omitempty"`
+	Registration string    `json:"registration,omitempty"`
+	AircraftType string    `json:"aircraft_type,omitempty"`
+	Airport      string    `json:"airport,omitempty"` // origin for arrivals, destination for departures
+}
+
+type airportTrack struct {
+	OnGround bool
+	Last     map[string]time.Time // kind -> last movement
+}
+
+var (
+	movements      []Movement
+	airportTracks  = make(map[string]*airportTrack)
+	movementsMutex = &sync.Mutex{}
+)
+
+func (ac AirportBoardConfig) fieldElevation() float64 {
+	if ac.ElevationFT != nil {
+		return *ac.ElevationFT
+	}
+	elev, _ := groundElevationFT(ac.Lat, ac.Lon)
+	return elev
+}
+
+func processAirportMovements(aircraft []Aircraft) {
+	bc := cfg().AirportBoard
+	if bc.ICAO == "" {
+		return
+	}
+	radius, maxAGL := bc.RadiusNM, bc.MaxAGLFT
+	if radius <= 0 {
+		radius = 4
+	}
+	if maxAGL <= 0 {
+		maxAGL = 1500
+	}
+	field := bc.fieldElevation()
+	now := clock.Now()
+
+	for _, ac := range aircraft {
+		lat, lon, ok := getActualCoords(ac)
+		if !ok || geo.DistanceNM(bc.Lat, bc.Lon, lat, lon) > radius {
+			continue
+		}
+		grounded := onGround(ac)
+		alt, hasAlt := altitudeFeet(ac)
+		if !grounded && (!hasAlt || alt-field > maxAGL) {
+			continue
+		}
+		var rate float64
+		if ac.BaroRate != nil {
+			rate = *ac.BaroRate
+		}
+
+		movementsMutex.Lock()
+		t, seen := airportTracks[ac.Hex]
+		if !seen {
+			t = &airportTrack{Last: make(map[string]time.Time)}
+			airportTracks[ac.Hex] = t
+		}
+		kind := ""
+		switch {
+		case grounded && seen && !t.OnGround:
+			kind = "arrival"
+		case !grounded && rate <= -movementRateFPM:
+			kind = "arrival"
+		case !grounded && (rate >= movementRateFPM || seen && t.OnGround):
+			kind = "departure"
+		}
+		t.OnGround = grounded
+		if kind != "" && now.Sub(t.Last[kind]) < movementCooldown {
+			kind = ""
+		}
+		if kind != "" {
+			t.Last[kind] = now
+		}
+		movementsMutex.Unlock()
+
+		if kind != "" {
+			go recordMovement(ac, kind, now)
+		}
+	}
+	pruneMovements(bc, now)
+}
+
+// recordMovement runs off the poll loop; the route lookup may be slow.
+func recordMovement(ac Aircraft, kind string, at time.Time) {
+	m := Movement{
+		Time:         at,
+		Kind:         kind,
+		Hex:          ac.Hex,
+		Flight:       strings.TrimSpace(ac.Flight),
+		Registration: ac.NNumber,
+		AircraftType: ac.Type,
+	}
+	if route, _ := lookupRoute(ac.Flight); route != nil {
+		if kind == "arrival" {
+			m.Airport = route.Origin
+		} else {
+			m.Airport = route.Destination
+		}
+	}
+	fmt.Printf("[AP] %s: %s %s\n", kind, ac.Hex, m.Flight)
+	movementsMutex.Lock()
+	movements = append(movements, m)
+	movementsMutex.Unlock()
+}
+
+func pruneMovements(bc AirportBoardConfig, now time.Time) {
+	keep := bc.Keep.Duration
+	if keep <= 0 {
+		keep = 24 * time.Hour
+	}
+	movementsMutex.Lock()
+	defer movementsMutex.Unlock()
+	i := 0
+	for i < len(movements) && now.Sub(movements[i].Time) > keep {
+		i++
+	}
+	movements = movements[i:]
+	for hex, t := range airportTracks {
+		if now.Sub(t.Last["arrival"]) > keep && now.Sub(t.Last["departure"]) > keep {
+			if _, live := globalRadiusState[hex]; !live {
+				delete(airportTracks, hex)
+			}
+		}
+	}
+}
+
+// boardMovements returns the board, newest first.
+func boardMovements() []Movement {
+	movementsMutex.Lock()
+	defer movementsMutex.Unlock()
+	out := make([]Movement, len(movements))
+	for i, m := range movements {
+		out[len(movements)-1-i] = m
+	}
+	return out
+}
+
+func handleAirportJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"airport":   cfg().AirportBoard.ICAO,
+		"movements": boardMovements(),
+	})
+}
+
+var airportPageTemplate = template.Must(template.New("airport").Funcs(template.FuncMap{
+	"fmtTime": formatTime,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+.boards { display: flex; gap: 2em; flex-wrap: wrap; }
+table { border-collapse: collapse; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; font-size: 14px; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p><a href="/history">History</a> · <a href="/airport.json">JSON</a></p>
+<div class="boards">
+{{range .Boards}}
+<div>
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Time</th><th>Flight</th><th>Aircraft</th><th>{{.Other}}</th></tr>
+{{range .Rows}}
+<tr>
+  <td>{{fmtTime .Time}}</td>
+  <td>{{if .Flight}}{{.Flight}}{{else}}<span class="muted">—</span>{{end}}</td>
+  <td><a href="https://globe.adsb.lol/?icao={{.Hex}}">{{if .Registration}}{{.Registration}}{{else}}{{.Hex}}{{end}}</a> {{.AircraftType}}</td>
+  <td>{{.Airport}}</td>
+</tr>
+{{else}}
+<tr><td colspan="4" class="muted">None yet</td></tr>
+{{end}}
+</table>
+</div>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+func handleAirportPage(w http.ResponseWriter, r *http.Request) {
+	bc := cfg().AirportBoard
+	type board struct {
+		Name, Other string
+		Rows        []Movement
+	}
+	arrivals := board{Name: "Arrivals", Other: "From"}
+	departures := board{Name: "Departures", Other: "To"}
+	for _, m := range boardMovements() {
+		if m.Kind == "arrival" {
+			arrivals.Rows = append(arrivals.Rows, m)
+		} else {
+			departures.Rows = append(departures.Rows, m)
+		}
+	}
+	title := strings.TrimSpace(bc.ICAO + " " + bc.Name)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := airportPageTemplate.Execute(w, map[string]any{"Title": title, "Boards": []board{arrivals, departures}}); err != nil {
+		fmt.Printf("[API] Error rendering airport board: %v\n", err)
+	}
+}
+
+// airportDigest summarises the last day's movements for the daily digest.
+func airportDigest() (Field, bool) {
+	bc := cfg().AirportBoard
+	if bc.ICAO == "" {
+		return Field{}, false
+	}
+	since := clock.Now().Add(-24 * time.Hour)
+	var arrivals, departures int
+	hours := make(map[int]int)
+	for _, m := range boardMovements() {
+		if m.Time.Before(since) {
+			continue
+		}
+		if m.Kind == "arrival" {
+			arrivals++
+		} else {
+			departures++
+		}
+		hours[m.Time.In(displayLocation()).Hour()]++
+	}
+	value := fmt.Sprintf("%d arrivals, %d departures", arrivals, departures)
+	busiest, count := 0, 0
+	for h, n := range hours {
+		if n > count || n == count && h < busiest {
+			busiest, count = h, n
+		}
+	}
+	if count > 0 {
+		value += fmt.Sprintf(" · busiest hour %02d:00 (%d)", busiest, count)
+	}
+	return Field{Name: "✈️ " + bc.ICAO, Value: value}, true
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Spoken announcements ---
+// For a shack or garage setup: alerts of the opted-in types play a
+// sound and/or speak a short announcement ("Military aircraft, 5 miles
+// north, 3000 feet") through a local command (espeak, say, piper) or a
+// network TTS endpoint whose audio is played locally.
+type AnnounceConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Types    []string `json:"types"`     // as for mastodon.types
+	Template string   `json:"template"`  // text/template over AnnouncementData
+	Speak    []string `json:"speak"`     // TTS command, "{text}" is replaced or the text appended; default ["espeak"]
+	TTSURL   string   `json:"tts_url"`   // POSTs the text as text/plain, plays the returned audio instead of Speak
+	Sound    string   `json:"sound"`     // audio file played before the announcement
+	Player   []string `json:"player"`    // plays a file, "{file}" is replaced or the path appended; default ["aplay", "-q"]
+	MaxQueue int      `json:"max_queue"` // announcements waiting beyond this are dropped, default 5
+}
+
+const defaultAnnounceTemplate = `{{.Kind}}{{with .Type}}, {{.}}{{end}}{{if .Distance}}, {{.Distance}} {{.DistanceUnit}} {{.Direction}}{{end}}{{if .Altitude}}, {{.Altitude}} {{.AltitudeUnit}}{{end}}`
+
+// AnnouncementData is what the template sees.
+type AnnouncementData struct {
+	Kind      string // "Military aircraft", "Emergency", ...
+	Rule      string
+	Flight    string
+	Type      string
+	Owner     string
+	Distance  string // whole display units, "" when unknown
+	Direction string // "north", "south west", ...
+	Altitude  string // rounded to 100 ft (or 50 m), "" when unknown or on the ground
+
+	SelectedAltitude string // autopilot selected altitude, "" unless the receiver decodes Comm-B
+	IAS              string // indicated airspeed, as above
+
+	DistanceUnit string // spoken: "miles", "kilometres", "nautical miles"
+	AltitudeUnit string // spoken: "feet", "metres"
+}
+
+var announceKinds = map[string]string{
+	"watchlist":            "Watchlist aircraft",
+	"emergency":            "Emergency",
+	"emergency_escalation": "Emergency escalation",
+	"military":             "Military aircraft",
+	"special_military":     "Military aircraft",
+	"proximity":            "Low aircraft overhead",
+	"dwell":                "Aircraft circling",
+	"operator":             "Operator alert",
+	"aggregate":            "Group of aircraft",
+	"transit":              "Transit coming up",
+	"overhead":             "Aircraft overhead soon",
+	"global_watch":         "Watched aircraft is up",
+	"descent":              "Aircraft descending in",
+	"squawk":               "Watched squawk in",
+}
+
+var (
+	announceQueue chan string
+	announceOnce  sync.Once
+)
+
+// announceAlert renders the announcement now, with distance and direction
+// from the alert's site, and queues it for the announcer.
+func announceAlert(rec AlertRecord, site sourceCenter) {
+	ac := cfg().Announce
+	text, err := announcementText(ac.Template, rec, site)
+	if err != nil {
+		fmt.Printf("[TTS] Template error: %v\n", err)
+		return
+	}
+	announceOnce.Do(func() {
+		size := ac.MaxQueue
+		if size <= 0 {
+			size = 5
+		}
+		announceQueue = make(chan string, size)
+		go runAnnouncer()
+	})
+	select {
+	case announceQueue <- text:
+	default:
+		fmt.Printf("[TTS] Queue full, dropping: %s\n", text)
+	}
+}
+
+// runAnnouncer plays one announcement at a time so they never talk over each other.
+func runAnnouncer() {
+	for text := range announceQueue {
+		ac := cfg().Announce
+		if ac.Sound != "" {
+			if err := playAudio(ac.Player, ac.Sound); err != nil {
+				fmt.Printf("[TTS] Error playing %s: %v\n", ac.Sound, err)
+			}
+		}
+		if err := speak(ac, text); err != nil {
+			fmt.Printf("[TTS] Error announcing: %v\n", err)
+		}
+	}
+}
+
+func announcementText(tmpl string, rec AlertRecord, site sourceCenter) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultAnnounceTemplate
+	}
+	t, err := template.New("announce").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	data := AnnouncementData{
+		Kind:   announceKinds[rec.Type],
+		Rule:   rec.Rule,
+		Flight: rec.Flight,
+		Type:   rec.AircraftType,
+		Owner:  rec.Owner,
+
+		DistanceUnit: spokenDistanceUnit(),
+		AltitudeUnit: spokenAltitudeUnit(),
+	}
+	if data.Kind == "" {
+		data.Kind = "Aircraft alert"
+	}
+	if rec.Lat != nil && rec.Lon != nil {
+		dist, _ := convertDistance(geo.DistanceNM(site.Lat, site.Lon, *rec.Lat, *rec.Lon))
+		data.Distance = strconv.Itoa(int(dist + 0.5))
+		data.Direction = compassWords(geo.InitialBearing(site.Lat, site.Lon, *rec.Lat, *rec.Lon))
+	}
+	if alt, err := strconv.ParseFloat(rec.AltBaro, 64); err == nil && alt > 0 {
+		if strings.EqualFold(units().Altitude, "m") {
+			data.Altitude = strconv.Itoa(int(alt*metersPerFoot/50+0.5) * 50)
+		} else {
+			data.Altitude = strconv.Itoa(int(alt/100+0.5) * 100)
+		}
+	}
+	if d := rec.ModeS; d != nil {
+		if d.SelectedAltFT != nil {
+			data.SelectedAltitude = fmtAltitude(*d.SelectedAltFT)
+		}
+		if d.IAS != nil {
+			data.IAS = fmtSpeed(*d.IAS)
+		}
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func compassWords(bearing float64) string {
+	points := []string{"north", "north east", "east", "south east", "south", "south west", "west", "north west"}
+	return points[int((bearing+22.5)/45)%8]
+}
+
+func speak(ac AnnounceConfig, text string) error {
+	if ac.TTSURL == "" {
+		cmd := ac.Speak
+		if len(cmd) == 0 {
+			cmd = []string{"espeak"}
+		}
+		return runWithArg(cmd, "{text}", text)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Post(ac.TTSURL, "text/plain; charset=utf-8", strings.NewReader(text))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TTS endpoint returned non-200 status: %s", resp.Status)
+	}
+	f, err := os.CreateTemp("", "announce-*.wav")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	return playAudio(ac.Player, f.Name())
+}
+
+func playAudio(player []string, file string) error {
+	if len(player) == 0 {
+		player = []string{"aplay", "-q"}
+	}
+	return runWithArg(player, "{file}", file)
+}
+
+// runWithArg runs cmd with placeholder replaced by arg, or arg appended when
+// no argument contains the placeholder.
+func runWithArg(cmd []string, placeholder, arg string) error {
+	args := make([]string, 0, len(cmd)+1)
+	replaced := false
+	for _, a := range cmd[1:] {
+		if strings.Contains(a, placeholder) {
+			a, replaced = strings.ReplaceAll(a, placeholder, arg), true
+		}
+		args = append(args, a)
+	}
+	if !replaced {
+		args = append(args, arg)
+	}
+	out, err := exec.Command(cmd[0], args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", cmd[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
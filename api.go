@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// --- HTTP admin API ---
+func startAPIServer() {
+	if cfg().HTTPListen == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /mutes", handleListMutes)
+	mux.HandleFunc("POST /mutes", requireToken(handleAddMute))
+	mux.HandleFunc("DELETE /mutes", requireToken(handleRemoveMute))
+	mux.HandleFunc("GET /tracks/{hex}", handleGetTrack)
+	mux.HandleFunc("GET /leaderboards", handleLeaderboards)
+	mux.HandleFunc("GET /search", handleSearch)
+	mux.HandleFunc("POST /discord/interactions", handleDiscordInteraction)
+	mux.HandleFunc("GET /feed.rss", handleRSSFeed)
+	mux.HandleFunc("GET /feed.atom", handleAtomFeed)
+	mux.HandleFunc("GET /calendar.ics", handleCalendar)
+	mux.HandleFunc("GET /history", handleHistoryPage)
+	mux.HandleFunc("GET /history/{id}/embed", handleHistoryEmbed)
+	mux.Handle("GET /track-files/", trackFilesHandler())
+	mux.HandleFunc("GET /heatmap.geojson", handleHeatmapGeoJSON)
+	mux.HandleFunc("GET /heatmap.png", handleHeatmapPNG)
+	mux.HandleFunc("GET /map.png", handleMapPNG)
+	mux.HandleFunc("GET /coverage", handleCoverage)
+	mux.HandleFunc("GET /coverage.svg", handleCoverageSVG)
+	mux.HandleFunc("GET /status", handleStatus)
+	mux.HandleFunc("GET /metrics", handleMetrics)
+	mux.HandleFunc("POST /reload", requireToken(handleReload))
+	mux.HandleFunc("GET /conflicts", handleConflicts)
+	mux.HandleFunc("POST /ingest", handlePush)
+	mux.HandleFunc("GET /airport", handleAirportPage)
+	mux.HandleFunc("GET /airport.json", handleAirportJSON)
+	if dir := cfg().PhotoArchive.Dir; dir != "" {
+		mux.Handle("GET /photos/", http.StripPrefix("/photos/", http.FileServer(http.Dir(dir))))
+	}
+
+	fmt.Printf("[API] Listening on %s\n", cfg().HTTPListen)
+	if err := http.ListenAndServe(cfg().HTTPListen, mux); err != nil {
+		fmt.Printf("[API] Server stopped: %v\n", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// requireToken guards a route that changes state: it wants
+// "Authorization: Bearer <http_token>", and stays closed while http_token is
+// empty. /ingest and /discord/interactions authenticate themselves.
+func requireToken(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		want := cfg().HTTPToken
+		if want == "" {
+			writeError(w, http.StatusForbidden, fmt.Errorf("set http_token to use %s %s", r.Method, r.URL.Path))
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// POST /reload re-reads config.json, as SIGHUP does.
+func handleReload(w http.ResponseWriter, r *http.Request) {
+	c, err := reloadConfig()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"reloaded":       configFile,
+		"dwell_rules":    len(c.DwellRules),
+		"operator_rules": len(c.OperatorRules),
+		"special_types":  len(c.SpecialTypes),
+	})
+}
+
+func handleListMutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, listMutes())
+}
+
+// POST /mutes {"match":"hex","value":"a1b2c3","duration":"3h","reason":"medevac laps"}
+func handleAddMute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mute
+		Duration Duration `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	m := req.Mute
+	if req.Duration.Duration > 0 {
+		until := clock.Now().Add(req.Duration.Duration)
+		m.Until = &until
+	}
+	if err := addMute(m); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	saveMutes()
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// DELETE /mutes?match=hex&value=a1b2c3
+func handleRemoveMute(w http.ResponseWriter, r *http.Request) {
+	match, value := r.URL.Query().Get("match"), r.URL.Query().Get("value")
+	if !removeMute(match, value) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no mute for %s=%s", match, value))
+		return
+	}
+	saveMutes()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /tracks/a1b2c3?format=kml (default gpx)
+func handleGetTrack(w http.ResponseWriter, r *http.Request) {
+	session, ok := currentTrack(r.PathValue("hex"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no active track for %s", r.PathValue("hex")))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "gpx"
+	}
+	data, err := encodeTrack(session, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	contentType := "application/gpx+xml"
+	if format == "kml" {
+		contentType = "application/vnd.google-earth.kml+xml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, session.fileName(format)))
+	w.Write(data)
+}
+
+func handleLeaderboards(w http.ResponseWriter, r *http.Request) {
+	lb, err := computeLeaderboards()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lb)
+}
+
+// GET /search?q=duke+university&limit=25
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	results, err := searchHistory(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
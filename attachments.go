@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"strings"
+)
+
+// --- Discord attachments ---
+// Embeds normally point at third-party image URLs, which Discord fetches
+// when the message is first shown. Those break when the map API runs out of
+// quota or the photo host stops allowing hotlinks. With attachments on, the
+// images are fetched here and uploaded with the webhook message instead, so
+// the alert keeps its images for good. Anything that fails to download
+// falls back to the plain URL.
+type AttachmentConfig struct {
+	Map       bool `json:"map"`
+	Thumbnail bool `json:"thumbnail"`
+}
+
+type discordFile struct {
+	Name string
+	Data []byte
+}
+
+// attachAlertImages downloads the embed's images and points it at the
+// uploaded copies.
+func attachAlertImages(embed *Embed, lat, lon float64, hasCoords bool) []discordFile {
+	ac := cfg().Attachments
+	var files []discordFile
+
+	if ac.Map && hasCoords {
+		if data, err := mapImage(lat, lon); err != nil {
+			fmt.Printf("[Discord] Map attachment failed, keeping the URL: %v\n", err)
+		} else {
+			files = append(files, discordFile{Name: "map.png", Data: data})
+			embed.Image.URL = "attachment://map.png"
+		}
+	}
+
+	if ac.Thumbnail && embed.Thumbnail.URL != "" {
+		if img, err := downloadImage(embed.Thumbnail.URL); err != nil {
+			fmt.Printf("[Discord] Thumbnail attachment failed, keeping the URL: %v\n", err)
+		} else {
+			name := "thumbnail" + imageExt(img.ContentType)
+			files = append(files, discordFile{Name: name, Data: img.Data})
+			embed.Thumbnail.URL = "attachment://" + name
+		}
+	}
+	return files
+}
+
+func imageExt(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "png"):
+		return ".png"
+	case strings.Contains(contentType, "gif"):
+		return ".gif"
+	case strings.Contains(contentType, "webp"):
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// postDiscordFiles sends a webhook message with file attachments.
+func postDiscordFiles(webhookURL string, msg DiscordWebhook, files []discordFile) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	payload, _ := json.Marshal(msg)
+	mw.WriteField("payload_json", string(payload))
+	for i, f := range files {
+		fw, err := mw.CreateFormFile(fmt.Sprintf("files[%d]", i), f.Name)
+		if err != nil {
+			return err
+		}
+		fw.Write(f.Data)
+	}
+	mw.Close()
+
+	resp, err := httpClient.Post(webhookURL, mw.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("API returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// --- Beast binary stream ---
+// readsb and dump1090 serve raw Mode S frames in the Beast format on port
+// 30005, so the ingestor can run straight off a receiver with no JSON
+// service in between. Each frame is
+//
+//	0x1a, type, 6 byte MLAT timestamp, 1 byte signal, message
+//
+// with any 0x1a in the rest doubled. Type '2' is a 7 byte Mode S short
+// message and '3' a 14 byte long one; Mode A/C ('1') and status ('4') frames
+// are skipped. From the messages themselves, DF17 extended squitters give
+// identity and category, airborne position, velocity and the emergency
+// squawk, and DF5/DF21 replies give the squawk of aircraft already known.
+const defaultBeastAddress = "localhost:30005"
+
+const (
+	beastEscape    = 0x1a
+	cprPairMaxAge  = 10 * time.Second // even/odd frames further apart than this aren't paired
+	cprLocalMaxAge = 30 * time.Second // last position a single frame may be decoded against
+	cprStatePrune  = time.Minute
+)
+
+func readBeast(r io.Reader, t *liveTable) error {
+	br := bufio.NewReader(r)
+	d := &beastDecoder{table: t, cpr: make(map[string]*cprState)}
+	for {
+		msg, err := readBeastFrame(br)
+		if err != nil {
+			return err
+		}
+		if msg != nil {
+			d.message(msg)
+		}
+	}
+}
+
+// readBeastFrame returns the Mode S message of the next frame, or nil for a
+// frame type that isn't one.
+func readBeastFrame(br *bufio.Reader) ([]byte, error) {
+	// Resync on the next frame start
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == beastEscape {
+			break
+		}
+	}
+	typ, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch typ {
+	case '1':
+		n = 2
+	case '2':
+		n = 7
+	case '3':
+		n = 14
+	default:
+		// Status frames, or a doubled 0x1a we landed in the middle of
+		return nil, nil
+	}
+	frame := make([]byte, 7+n)
+	for i := range frame {
+		p, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if p[0] == beastEscape {
+			if p, err = br.Peek(2); err != nil {
+				return nil, err
+			}
+			if p[1] != beastEscape {
+				// A new frame started mid-message; drop this one
+				return nil, nil
+			}
+			br.Discard(1)
+		}
+		frame[i], _ = br.ReadByte()
+	}
+	if typ == '1' {
+		return nil, nil
+	}
+	return frame[7:], nil
+}
+
+type beastDecoder struct {
+	table  *liveTable
+	cpr    map[string]*cprState
+	pruned time.Time
+}
+
+// cprState holds the last even and odd position frames of one aircraft, and
+// the last position decoded from them.
+type cprState struct {
+	frames    [2]cprFrame
+	lat, lon  float64
+	posTime   time.Time
+	heardTime time.Time
+}
+
+type cprFrame struct {
+	lat, lon float64 // 17 bit CPR values scaled to [0, 1)
+	at       time.Time
+}
+
+func (d *beastDecoder) message(msg []byte) {
+	switch df := msg[0] >> 3; {
+	case df == 17 && len(msg) == 14:
+		if modesCRC(msg) != 0 {
+			return
+		}
+		d.extendedSquitter(msg)
+	case (df == 5 && len(msg) == 7) || (df == 21 && len(msg) == 14):
+		// The address is overlaid on the parity, so it's only trusted for an
+		// aircraft already heard in a DF17
+		hex := fmt.Sprintf("%06x", modesCRC(msg))
+		if !d.table.has(hex) {
+			return
+		}
+		squawk := decodeID13(uint32(msg[2]&0x1f)<<8 | uint32(msg[3]))
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.Squawk = squawk
+			return false
+		})
+	}
+}
+
+func (d *beastDecoder) extendedSquitter(msg []byte) {
+	hex := fmt.Sprintf("%02x%02x%02x", msg[1], msg[2], msg[3])
+	me := msg[4:11]
+	tc := me[0] >> 3
+	now := clock.Now()
+
+	switch {
+	case tc >= 1 && tc <= 4:
+		callsign := decodeCallsign(me)
+		category := ""
+		if ca := me[0] & 7; ca != 0 {
+			category = fmt.Sprintf("%c%d", 'A'+4-tc, ca)
+		}
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.MsgType = "adsb_icao"
+			if callsign != "" {
+				ac.Flight = callsign
+			}
+			if category != "" {
+				ac.Category = category
+			}
+			return false
+		})
+
+	case tc >= 5 && tc <= 8:
+		// Surface position; only the on-ground state is used
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.MsgType = "adsb_icao"
+			ac.AltBaro = "ground"
+			return false
+		})
+
+	case tc >= 9 && tc <= 18:
+		alt, altOK := decodeAC12(uint32(me[1])<<4 | uint32(me[2])>>4)
+		odd := int(me[2]>>2) & 1
+		frame := cprFrame{
+			lat: float64(uint32(me[2]&3)<<15|uint32(me[3])<<7|uint32(me[4])>>1) / 131072,
+			lon: float64(uint32(me[4]&1)<<16|uint32(me[5])<<8|uint32(me[6])) / 131072,
+			at:  now,
+		}
+		lat, lon, posOK := d.position(hex, odd, frame, now)
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.MsgType = "adsb_icao"
+			if altOK {
+				ac.AltBaro = float64(alt)
+			}
+			if posOK {
+				ac.Lat, ac.Lon = someFloat(lat), someFloat(lon)
+			}
+			return posOK
+		})
+
+	case tc == 19:
+		st := me[0] & 7
+		if st != 1 && st != 2 {
+			// Airspeed and heading subtypes; ground speed is what's used
+			return
+		}
+		vew, vns := int(me[1]&3)<<8|int(me[2]), int(me[3]&0x7f)<<3|int(me[4]>>5)
+		if vew == 0 || vns == 0 {
+			return
+		}
+		scale := 1.0
+		if st == 2 {
+			scale = 4 // supersonic
+		}
+		ew, ns := float64(vew-1)*scale, float64(vns-1)*scale
+		if me[1]&4 != 0 {
+			ew = -ew
+		}
+		if me[3]&0x80 != 0 {
+			ns = -ns
+		}
+		gs := math.Hypot(ew, ns)
+		track := math.Mod(math.Atan2(ew, ns)*180/math.Pi+360, 360)
+		var rate *float64
+		if vr := int(me[4]&7)<<6 | int(me[5]>>2); vr != 0 {
+			fpm := float64(vr-1) * 64
+			if me[4]&8 != 0 {
+				fpm = -fpm
+			}
+			rate = &fpm
+		}
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.GS = someFloat(math.Round(gs*10) / 10)
+			ac.Track = &track
+			if rate != nil {
+				ac.BaroRate = rate
+			}
+			return false
+		})
+
+	case tc == 28 && me[0]&7 == 1:
+		// Emergency/priority status carries the Mode A code
+		squawk := decodeID13(uint32(me[1]&0x1f)<<8 | uint32(me[2]))
+		d.table.update(hex, func(ac *Aircraft) bool {
+			ac.Squawk = squawk
+			return false
+		})
+	}
+}
+
+// position decodes an airborne CPR frame: globally from an even/odd pair
+// heard within cprPairMaxAge, otherwise locally against the aircraft's last
+// decoded position.
+func (d *beastDecoder) position(hex string, odd int, f cprFrame, now time.Time) (float64, float64, bool) {
+	if now.Sub(d.pruned) > cprStatePrune {
+		for h, s := range d.cpr {
+			if now.Sub(s.heardTime) > streamAircraftTTL {
+				delete(d.cpr, h)
+			}
+		}
+		d.pruned = now
+	}
+	s, ok := d.cpr[hex]
+	if !ok {
+		s = &cprState{}
+		d.cpr[hex] = s
+	}
+	s.heardTime = now
+	s.frames[odd] = f
+
+	var lat, lon float64
+	switch {
+	case !s.posTime.IsZero() && now.Sub(s.posTime) < cprLocalMaxAge:
+		lat, lon = cprLocal(f, odd, s.lat, s.lon)
+	case !s.frames[1-odd].at.IsZero() && now.Sub(s.frames[1-odd].at) < cprPairMaxAge:
+		var ok bool
+		if lat, lon, ok = cprGlobal(s.frames[0], s.frames[1], odd); !ok {
+			return 0, 0, false
+		}
+	default:
+		return 0, 0, false
+	}
+	if math.Abs(lat) > 90 {
+		return 0, 0, false
+	}
+	s.lat, s.lon, s.posTime = lat, lon, now
+	return lat, lon, true
+}
+
+// cprGlobal decodes an even/odd pair; the position is that of the most recent
+// frame, which has parity odd.
+func cprGlobal(even, oddF cprFrame, odd int) (float64, float64, bool) {
+	const dLatEven, dLatOdd = 360.0 / 60, 360.0 / 59
+	j := math.Floor(59*even.lat - 60*oddF.lat + 0.5)
+	latEven := dLatEven * (posMod(j, 60) + even.lat)
+	latOdd := dLatOdd * (posMod(j, 59) + oddF.lat)
+	if latEven >= 270 {
+		latEven -= 360
+	}
+	if latOdd >= 270 {
+		latOdd -= 360
+	}
+	if cprNL(latEven) != cprNL(latOdd) {
+		// The pair straddles a longitude zone boundary; wait for the next one
+		return 0, 0, false
+	}
+	lat, cprLon := latEven, even.lon
+	if odd == 1 {
+		lat, cprLon = latOdd, oddF.lon
+	}
+	nl := cprNL(lat)
+	ni := max(nl-odd, 1)
+	m := math.Floor(even.lon*float64(nl-1) - oddF.lon*float64(nl) + 0.5)
+	lon := 360 / float64(ni) * (posMod(m, float64(ni)) + cprLon)
+	if lon >= 180 {
+		lon -= 360
+	}
+	return lat, lon, true
+}
+
+// cprLocal decodes one frame against a reference position within 180 NM.
+func cprLocal(f cprFrame, odd int, refLat, refLon float64) (float64, float64) {
+	dLat := 360 / float64(60-odd)
+	j := math.Floor(refLat/dLat) + math.Floor(0.5+posMod(refLat, dLat)/dLat-f.lat)
+	lat := dLat * (j + f.lat)
+	dLon := 360 / float64(max(cprNL(lat)-odd, 1))
+	m := math.Floor(refLon/dLon) + math.Floor(0.5+posMod(refLon, dLon)/dLon-f.lon)
+	lon := dLon * (m + f.lon)
+	return lat, lon
+}
+
+// cprNL is the number of longitude zones at a latitude.
+func cprNL(lat float64) int {
+	lat = math.Abs(lat)
+	switch {
+	case lat == 0:
+		return 59
+	case lat == 87:
+		return 2
+	case lat > 87:
+		return 1
+	}
+	const nz = 15
+	a := 1 - math.Cos(math.Pi/(2*nz))
+	b := math.Pow(math.Cos(math.Pi/180*lat), 2)
+	return int(math.Floor(2 * math.Pi / math.Acos(1-a/b)))
+}
+
+func posMod(a, b float64) float64 {
+	r := math.Mod(a, b)
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+// modesCRC is the CRC-24 remainder of a message including its parity field:
+// zero for a clean DF17, the ICAO address for DF5/DF21.
+func modesCRC(msg []byte) uint32 {
+	var crc uint32
+	n := len(msg) - 3
+	for _, b := range msg[:n] {
+		crc ^= uint32(b) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1fff409
+			}
+		}
+	}
+	parity := uint32(msg[n])<<16 | uint32(msg[n+1])<<8 | uint32(msg[n+2])
+	return (crc ^ parity) & 0xffffff
+}
+
+const callsignChars = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ##### ###############0123456789######"
+
+func decodeCallsign(me []byte) string {
+	bits := uint64(0)
+	for _, b := range me[1:7] {
+		bits = bits<<8 | uint64(b)
+	}
+	var sb strings.Builder
+	for i := 7; i >= 0; i-- {
+		sb.WriteByte(callsignChars[bits>>(uint(i)*6)&0x3f])
+	}
+	return strings.TrimSpace(strings.ReplaceAll(sb.String(), "#", ""))
+}
+
+// decodeAC12 reads the 12 bit altitude field of an airborne position. Only
+// the 25 ft encoding is handled; the old Gillham 100 ft code is rare on
+// ADS-B transponders.
+func decodeAC12(ac uint32) (int, bool) {
+	if ac == 0 || ac&0x10 == 0 {
+		return 0, false
+	}
+	n := (ac&0xfe0)>>1 | ac&0xf
+	return int(n)*25 - 1000, true
+}
+
+// decodeID13 turns the interleaved 13 bit identity field (C1 A1 C2 A2 C4 A4
+// X B1 D1 B2 D2 B4 D4) into a four digit squawk.
+func decodeID13(id uint32) string {
+	var v uint32
+	for _, m := range [][2]uint32{
+		{0x1000, 0x0010}, {0x0800, 0x1000}, {0x0400, 0x0020}, {0x0200, 0x2000},
+		{0x0100, 0x0040}, {0x0080, 0x4000}, {0x0020, 0x0100}, {0x0010, 0x0001},
+		{0x0008, 0x0200}, {0x0004, 0x0002}, {0x0002, 0x0400}, {0x0001, 0x0004},
+	} {
+		if id&m[0] != 0 {
+			v |= m[1]
+		}
+	}
+	return fmt.Sprintf("%04x", v)
+}
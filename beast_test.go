@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// DF17 vectors are from "The 1090 Megahertz Riddle" (Junzi Sun).
+const (
+	esIdentKLM    = "8D4840D6202CC371C32CE0576098" // KLM1023
+	esPosEven     = "8D40621D58C382D690C8AC2863A7" // 38000 ft, even
+	esPosOdd      = "8D40621D58C386435CC412692AD6" // 38000 ft, odd
+	esVelGround   = "8D485020994409940838175B284F" // 159 kt, 182.88°, -832 fpm
+	esVelAirspeed = "8DA05F219B06B6AF189400CBC33F" // subtype 3, not used
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// beastFrame wraps a message the way readsb sends it, with a timestamp
+// that needs escaping.
+func beastFrame(msg []byte) []byte {
+	typ := byte('3')
+	if len(msg) == 7 {
+		typ = '2'
+	}
+	body := append([]byte{0x00, 0x1a, 0x02, 0x03, 0x04, 0x05, 0x80}, msg...)
+	frame := []byte{beastEscape, typ}
+	for _, b := range body {
+		frame = append(frame, b)
+		if b == beastEscape {
+			frame = append(frame, b)
+		}
+	}
+	return frame
+}
+
+func decodeBeast(t *testing.T, msgs ...string) *liveTable {
+	t.Helper()
+	var stream []byte
+	for _, m := range msgs {
+		stream = append(stream, beastFrame(mustHex(t, m))...)
+	}
+	table := newLiveTable()
+	if err := readBeast(bytes.NewReader(stream), table); err != io.EOF {
+		t.Fatalf("readBeast: %v", err)
+	}
+	return table
+}
+
+func tableAircraft(t *testing.T, table *liveTable, hex string) (Aircraft, bool) {
+	t.Helper()
+	for _, ac := range table.snapshot() {
+		if ac.Hex == hex {
+			return ac, true
+		}
+	}
+	return Aircraft{}, false
+}
+
+func near(a, b, tol float64) bool { return math.Abs(a-b) <= tol }
+
+func TestModesCRC(t *testing.T) {
+	for _, m := range []string{esIdentKLM, esPosEven, esPosOdd, esVelGround, esVelAirspeed} {
+		if crc := modesCRC(mustHex(t, m)); crc != 0 {
+			t.Errorf("%s: remainder %06x, want 0", m, crc)
+		}
+	}
+	bad := mustHex(t, esIdentKLM)
+	bad[5] ^= 0x10
+	if modesCRC(bad) == 0 {
+		t.Error("flipped bit still passes the CRC")
+	}
+
+	// DF5: the remainder is the address
+	df5 := []byte{0x28, 0x00, 0x0a, 0xaa, 0, 0, 0}
+	crc := modesCRC(df5) ^ 0x4840d6
+	df5[4], df5[5], df5[6] = byte(crc>>16), byte(crc>>8), byte(crc)
+	if got := modesCRC(df5); got != 0x4840d6 {
+		t.Errorf("DF5 address %06x, want 4840d6", got)
+	}
+}
+
+func TestCPRNL(t *testing.T) {
+	cases := []struct {
+		lat  float64
+		want int
+	}{
+		{0, 59},
+		{10.47, 59},
+		{10.48, 58},
+		{-10.48, 58},
+		{51.89, 37},
+		{51.90, 36},
+		{52.2572, 36},
+		{86.53, 3},
+		{86.54, 2},
+		{87, 2},
+		{87.1, 1},
+		{-90, 1},
+	}
+	for _, tc := range cases {
+		if got := cprNL(tc.lat); got != tc.want {
+			t.Errorf("cprNL(%g) = %d, want %d", tc.lat, got, tc.want)
+		}
+	}
+}
+
+// cprEncode is the airborne CPR encoding, for building frames at chosen
+// positions.
+func cprEncode(lat, lon float64, odd int) cprFrame {
+	dLat := 360 / float64(60-odd)
+	yz := math.Floor(131072*posMod(lat, dLat)/dLat + 0.5)
+	rLat := dLat * (yz/131072 + math.Floor(lat/dLat))
+	dLon := 360 / float64(max(cprNL(rLat)-odd, 1))
+	xz := math.Floor(131072*posMod(lon, dLon)/dLon + 0.5)
+	return cprFrame{lat: posMod(yz, 131072) / 131072, lon: posMod(xz, 131072) / 131072}
+}
+
+func TestCPRGlobal(t *testing.T) {
+	riddleEven := cprFrame{lat: 93000.0 / 131072, lon: 51372.0 / 131072}
+	riddleOdd := cprFrame{lat: 74158.0 / 131072, lon: 50194.0 / 131072}
+
+	cases := []struct {
+		name     string
+		even     cprFrame
+		odd      cprFrame
+		latest   int
+		ok       bool
+		lat, lon float64
+	}{
+		{"riddle, even latest", riddleEven, riddleOdd, 0, true, 52.2572, 3.91937},
+		{"riddle, odd latest", riddleEven, riddleOdd, 1, true, 52.2658, 3.93891},
+		{"southern and western", cprEncode(-33.9, -70.7, 0), cprEncode(-33.9, -70.7, 1), 0, true, -33.9, -70.7},
+		{"near the antimeridian", cprEncode(21.3, 179.9, 0), cprEncode(21.3, 179.9, 1), 1, true, 21.3, 179.9},
+		{"pair within one latitude zone", cprEncode(51.880, 0.5, 0), cprEncode(51.885, 0.5, 1), 1, true, 51.885, 0.5},
+		{"pair straddling a latitude zone", cprEncode(51.890, 0.5, 0), cprEncode(51.897, 0.5, 1), 1, false, 0, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lat, lon, ok := cprGlobal(tc.even, tc.odd, tc.latest)
+			if ok != tc.ok {
+				t.Fatalf("ok = %v, want %v", ok, tc.ok)
+			}
+			if ok && (!near(lat, tc.lat, 1e-3) || !near(lon, tc.lon, 1e-3)) {
+				t.Errorf("got %.5f, %.5f, want %.5f, %.5f", lat, lon, tc.lat, tc.lon)
+			}
+		})
+	}
+}
+
+func TestCPRLocal(t *testing.T) {
+	cases := []struct {
+		name           string
+		f              cprFrame
+		odd            int
+		refLat, refLon float64
+		lat, lon       float64
+	}{
+		{"riddle", cprFrame{lat: 93000.0 / 131072, lon: 51372.0 / 131072}, 0, 52.258, 3.918, 52.2572, 3.91937},
+		{"odd frame", cprEncode(40.1, -74.2, 1), 1, 40.5, -74.6, 40.1, -74.2},
+		{"reference across a zone edge", cprEncode(-12.01, 130.9, 0), 0, -11.5, 131.3, -12.01, 130.9},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lat, lon := cprLocal(tc.f, tc.odd, tc.refLat, tc.refLon)
+			if !near(lat, tc.lat, 1e-3) || !near(lon, tc.lon, 1e-3) {
+				t.Errorf("got %.5f, %.5f, want %.5f, %.5f", lat, lon, tc.lat, tc.lon)
+			}
+		})
+	}
+}
+
+func TestDecodeAC12(t *testing.T) {
+	cases := []struct {
+		ac   uint32
+		alt  int
+		want bool
+	}{
+		{0xc38, 38000, true}, // riddle position frames
+		{0x010, -1000, true},
+		{0x011, -975, true},
+		{0xc28, 0, false}, // Q bit clear: Gillham code
+		{0x000, 0, false},
+	}
+	for _, tc := range cases {
+		alt, ok := decodeAC12(tc.ac)
+		if ok != tc.want || alt != tc.alt {
+			t.Errorf("decodeAC12(%#x) = %d, %v, want %d, %v", tc.ac, alt, ok, tc.alt, tc.want)
+		}
+	}
+}
+
+func TestDecodeID13(t *testing.T) {
+	cases := []struct {
+		id   uint32
+		want string
+	}{
+		{0x0000, "0000"},
+		{0x0aaa, "7700"},
+		{0x0aa2, "7500"},
+		{0x0a8a, "7600"},
+		{0x0808, "1200"},
+		{0x1fbf, "7777"},
+		{0x0040, "0000"}, // X bit only
+	}
+	for _, tc := range cases {
+		if got := decodeID13(tc.id); got != tc.want {
+			t.Errorf("decodeID13(%#04x) = %s, want %s", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestBeastDecode(t *testing.T) {
+	withTestEnv(t)
+
+	t.Run("identification", func(t *testing.T) {
+		ac, ok := tableAircraft(t, decodeBeast(t, esIdentKLM), "4840d6")
+		if !ok {
+			t.Fatal("aircraft not in the table")
+		}
+		if ac.Flight != "KLM1023" || ac.Category != "" || ac.MsgType != "adsb_icao" {
+			t.Errorf("got flight %q, category %q, type %q", ac.Flight, ac.Category, ac.MsgType)
+		}
+	})
+
+	t.Run("bad CRC", func(t *testing.T) {
+		msg := mustHex(t, esIdentKLM)
+		msg[6] ^= 0x01
+		if _, ok := tableAircraft(t, decodeBeast(t, hex.EncodeToString(msg)), "4840d6"); ok {
+			t.Error("a frame failing the CRC made it into the table")
+		}
+	})
+
+	t.Run("position pair", func(t *testing.T) {
+		table := decodeBeast(t, esPosOdd, esPosEven)
+		ac, ok := tableAircraft(t, table, "40621d")
+		if !ok {
+			t.Fatal("aircraft not in the table")
+		}
+		lat, lon, hasCoords := getActualCoords(ac)
+		if !hasCoords || !near(lat, 52.2572, 1e-3) || !near(lon, 3.91937, 1e-3) {
+			t.Errorf("got %.5f, %.5f (%v), want 52.2572, 3.91937", lat, lon, hasCoords)
+		}
+		if ac.AltBaro != 38000.0 {
+			t.Errorf("altitude %v, want 38000", ac.AltBaro)
+		}
+	})
+
+	t.Run("single frame has no position", func(t *testing.T) {
+		ac, _ := tableAircraft(t, decodeBeast(t, esPosEven), "40621d")
+		if _, _, hasCoords := getActualCoords(ac); hasCoords {
+			t.Error("one frame with nothing to pair or reference decoded a position")
+		}
+	})
+
+	t.Run("velocity", func(t *testing.T) {
+		ac, _ := tableAircraft(t, decodeBeast(t, esVelGround), "485020")
+		if !ac.GS.Valid || !near(ac.GS.V, 159.2, 0.05) {
+			t.Errorf("ground speed %+v, want 159.2", ac.GS)
+		}
+		if ac.Track == nil || !near(*ac.Track, 182.88, 0.01) {
+			t.Errorf("track %v, want 182.88", ac.Track)
+		}
+		if ac.BaroRate == nil || *ac.BaroRate != -832 {
+			t.Errorf("vertical rate %v, want -832", ac.BaroRate)
+		}
+
+		ac, _ = tableAircraft(t, decodeBeast(t, esVelAirspeed), "a05f21")
+		if ac.GS.Valid || ac.Track != nil {
+			t.Errorf("airspeed subtype set ground speed %+v, track %v", ac.GS, ac.Track)
+		}
+	})
+
+	t.Run("DF5 squawk for a known aircraft only", func(t *testing.T) {
+		df5 := []byte{0x28, 0x00, 0x0a, 0xaa, 0, 0, 0}
+		crc := modesCRC(df5) ^ 0x4840d6
+		df5[4], df5[5], df5[6] = byte(crc>>16), byte(crc>>8), byte(crc)
+
+		table := decodeBeast(t, hex.EncodeToString(df5))
+		if _, ok := tableAircraft(t, table, "4840d6"); ok {
+			t.Error("DF5 from an unheard address added an aircraft")
+		}
+		table = decodeBeast(t, esIdentKLM, hex.EncodeToString(df5))
+		if ac, _ := tableAircraft(t, table, "4840d6"); ac.Squawk != "7700" {
+			t.Errorf("squawk %q, want 7700", ac.Squawk)
+		}
+	})
+}
+
+// A pair is decoded globally once; later single frames decode locally
+// against that position until it's cprLocalMaxAge old.
+func TestBeastLocalDecode(t *testing.T) {
+	withTestEnv(t)
+	table := newLiveTable()
+	d := &beastDecoder{table: table, cpr: make(map[string]*cprState)}
+	start := clock.Now()
+
+	d.message(mustHex(t, esPosOdd))
+	d.message(mustHex(t, esPosEven))
+	clock = fakeClock{start.Add(20 * time.Second)}
+	d.message(mustHex(t, esPosOdd))
+	ac, _ := tableAircraft(t, table, "40621d")
+	if lat, lon, _ := getActualCoords(ac); !near(lat, 52.2658, 1e-3) || !near(lon, 3.93891, 1e-3) {
+		t.Errorf("local decode got %.5f, %.5f, want 52.2658, 3.93891", lat, lon)
+	}
+
+	// Too old to reference and no fresh partner: no position
+	clock = fakeClock{start.Add(55 * time.Second)}
+	d.message(mustHex(t, esPosEven))
+	if got := d.cpr["40621d"].posTime; !got.Equal(start.Add(20 * time.Second)) {
+		t.Errorf("position decoded at %v against a stale reference", got.Sub(start))
+	}
+}
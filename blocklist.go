@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// --- Permanent blocklist ---
+// Aircraft matching the blocklist are dropped before any rule is evaluated
+// (e.g. the local flight school's fleet). Unlike mutes these never expire.
+type Blocklist struct {
+	Hexes            []string `json:"hexes"`
+	Registrations    []string `json:"registrations"`
+	CallsignPatterns []string `json:"callsign_patterns"` // regular expressions, e.g. "^N[0-9]+SF$"
+	Types            []string `json:"types"`
+
+	callsignRes []*regexp.Regexp
+}
+
+func (b *Blocklist) compile() {
+	b.callsignRes = nil
+	for _, pattern := range b.CallsignPatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			fmt.Printf("[CF] Ignoring invalid callsign pattern %q: %v\n", pattern, err)
+			continue
+		}
+		b.callsignRes = append(b.callsignRes, re)
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlocked(ac Aircraft) bool {
+	b := &cfg().Blocklist
+	if containsFold(b.Hexes, ac.Hex) || containsFold(b.Registrations, ac.NNumber) || containsFold(b.Types, ac.Type) {
+		return true
+	}
+	callsign := strings.TrimSpace(ac.Flight)
+	if callsign == "" {
+		return false
+	}
+	for _, re := range b.callsignRes {
+		if re.MatchString(callsign) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Bluesky notifier ---
+// Posts opted-in alerts through the AT Protocol XRPC API. By default the post
+// carries a link card to globe.adsb.lol using the aircraft photo (or map) as
+// its thumbnail; with link_card off the images are attached instead.
+type BlueskyConfig struct {
+	Handle      string   `json:"handle"`       // e.g. planes.bsky.social; empty disables
+	AppPassword string   `json:"app_password"` // an app password, not the account password
+	PDS         string   `json:"pds"`          // default https://bsky.social
+	Types       []string `json:"types"`        // alert types (or "rule:<name>"), default watchlist + special_military
+	Hashtags    []string `json:"hashtags"`
+	LinkCard    *bool    `json:"link_card"` // default true
+}
+
+const (
+	blueskyMaxChars   = 300
+	blueskyMaxBlob    = 1000000
+	blueskySessionTTL = time.Hour
+)
+
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+	created   time.Time
+}
+
+var (
+	bskySession *blueskySession
+	bskyMutex   = &sync.Mutex{}
+)
+
+func (bc BlueskyConfig) pds() string {
+	if bc.PDS != "" {
+		return strings.TrimSuffix(bc.PDS, "/")
+	}
+	return "https://bsky.social"
+}
+
+func blueskyXRPC(bc BlueskyConfig, method, token, contentType string, body []byte, out any) error {
+	req, err := http.NewRequest(http.MethodPost, bc.pds()+"/xrpc/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := socialHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var xerr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&xerr)
+		return fmt.Errorf("%s: %s %s %s", method, resp.Status, xerr.Error, xerr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// blueskyLogin returns a cached session, creating one when missing or old.
+func blueskyLogin(bc BlueskyConfig, force bool) (*blueskySession, error) {
+	bskyMutex.Lock()
+	defer bskyMutex.Unlock()
+	if bskySession != nil && !force && time.Since(bskySession.created) < blueskySessionTTL {
+		return bskySession, nil
+	}
+	body, _ := json.Marshal(map[string]string{"identifier": bc.Handle, "password": bc.AppPassword})
+	var s blueskySession
+	if err := blueskyXRPC(bc, "com.atproto.server.createSession", "", "application/json", body, &s); err != nil {
+		return nil, err
+	}
+	s.created = time.Now()
+	bskySession = &s
+	return bskySession, nil
+}
+
+func postBluesky(rec AlertRecord, images func() []socialImage) {
+	bc := cfg().Bluesky
+	if bc.Handle == "" || bc.AppPassword == "" {
+		return
+	}
+	session, err := blueskyLogin(bc, false)
+	if err != nil {
+		fmt.Printf("[BSK] Error logging in: %v\n", err)
+		return
+	}
+
+	linkCard := bc.LinkCard == nil || *bc.LinkCard
+	var blobs []json.RawMessage
+	var alts []string
+	for _, img := range images() {
+		if len(img.Data) > blueskyMaxBlob {
+			continue
+		}
+		var uploaded struct {
+			Blob json.RawMessage `json:"blob"`
+		}
+		if err := blueskyXRPC(bc, "com.atproto.repo.uploadBlob", session.AccessJwt, img.ContentType, img.Data, &uploaded); err != nil {
+			fmt.Printf("[BSK] Error uploading %s: %v\n", img.Name, err)
+			continue
+		}
+		blobs = append(blobs, uploaded.Blob)
+		alts = append(alts, img.Alt)
+	}
+
+	text := socialText(rec, bc.Hashtags)
+	var embed map[string]any
+	if linkCard {
+		// The link lives in the card, keep the text for the description
+		text = strings.Replace(text, alertLink(rec)+"\n", "", 1)
+		external := map[string]any{"uri": alertLink(rec), "title": alertTitle(rec), "description": alertSummary(rec)}
+		if len(blobs) > 0 {
+			external["thumb"] = blobs[len(blobs)-1] // the photo when there is one, else the map
+		}
+		embed = map[string]any{"$type": "app.bsky.embed.external", "external": external}
+	} else if len(blobs) > 0 {
+		var images []map[string]any
+		for i, b := range blobs {
+			images = append(images, map[string]any{"alt": alts[i], "image": b})
+		}
+		embed = map[string]any{"$type": "app.bsky.embed.images", "images": images}
+	}
+
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      truncateRunes(text, blueskyMaxChars),
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"langs":     []string{"en"},
+	}
+	if embed != nil {
+		record["embed"] = embed
+	}
+	body, _ := json.Marshal(map[string]any{"repo": session.DID, "collection": "app.bsky.feed.post", "record": record})
+	if err := blueskyXRPC(bc, "com.atproto.repo.createRecord", session.AccessJwt, "application/json", body, nil); err != nil {
+		// Expired tokens get one retry with a fresh session
+		if session, err = blueskyLogin(bc, true); err == nil {
+			err = blueskyXRPC(bc, "com.atproto.repo.createRecord", session.AccessJwt, "application/json", body, nil)
+		}
+		if err != nil {
+			fmt.Printf("[BSK] Error posting: %v\n", err)
+			return
+		}
+	}
+	fmt.Printf("[BSK] Posted %s alert for %s\n", rec.Type, rec.Hex)
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// --- iCalendar feed of notable sightings ---
+// GET /calendar.ics turns watchlist and special military alerts into events
+// with the alert position as GEO, so a calendar app shows when interesting
+// aircraft were overhead. Uses the history DB when available (events then
+// last until the flight left), otherwise the in-memory recent alerts.
+type CalendarConfig struct {
+	Types    []string `json:"types"`    // alert types that become events, default watchlist + special_military
+	Days     int      `json:"days"`     // how far back the feed goes with the history DB, default 90
+	Duration Duration `json:"duration"` // event length when the flight end isn't known, default 15m
+}
+
+type calendarEvent struct {
+	rec AlertRecord
+	end time.Time
+}
+
+func calendarTypes() []string {
+	if types := cfg().Calendar.Types; len(types) > 0 {
+		return types
+	}
+	return []string{"watchlist", "special_military"}
+}
+
+func calendarEvents(types []string) ([]calendarEvent, error) {
+	cc := cfg().Calendar
+	eventLength := cc.Duration.Duration
+	if eventLength <= 0 {
+		eventLength = 15 * time.Minute
+	}
+
+	if historyDB == nil {
+		var events []calendarEvent
+		for _, rec := range recentAlertsOfType(types) {
+			events = append(events, calendarEvent{rec: rec, end: rec.Time.Add(eventLength)})
+		}
+		return events, nil
+	}
+
+	days := cc.Days
+	if days <= 0 {
+		days = 90
+	}
+	lowered := make([]string, len(types))
+	for i, t := range types {
+		lowered[i] = strings.ToLower(t)
+	}
+	rows, err := historyDB.Query(`SELECT a.alerted_at, a.type, coalesce(a.rule, ''), coalesce(a.hex, ''), coalesce(a.flight, ''),
+			coalesce(a.registration, ''), coalesce(a.aircraft_type, ''), coalesce(a.owner, ''), coalesce(a.squawk, ''),
+			coalesce(a.alt_baro, ''), a.lat, a.lon, coalesce(a.note, ''), f.last_seen
+		FROM alerts a LEFT JOIN flights f ON f.id = a.flight_id
+		WHERE a.outcome = 'sent' AND a.type = ANY($1) AND a.alerted_at >= $2
+		ORDER BY a.alerted_at DESC`, pq.Array(lowered), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []calendarEvent
+	for rows.Next() {
+		var ev calendarEvent
+		var lastSeen *time.Time
+		r := &ev.rec
+		if err := rows.Scan(&r.Time, &r.Type, &r.Rule, &r.Hex, &r.Flight, &r.Registration, &r.AircraftType,
+			&r.Owner, &r.Squawk, &r.AltBaro, &r.Lat, &r.Lon, &r.Note, &lastSeen); err != nil {
+			return nil, err
+		}
+		ev.end = r.Time.Add(eventLength)
+		if lastSeen != nil && lastSeen.After(r.Time) {
+			ev.end = *lastSeen
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// GET /calendar.ics?type=watchlist
+func handleCalendar(w http.ResponseWriter, r *http.Request) {
+	types := alertTypesParam(r)
+	if len(types) == 0 {
+		types = calendarTypes()
+	}
+	events, err := calendarEvents(types)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var b strings.Builder
+	line := func(s string) { b.WriteString(foldICSLine(s)) }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//flight-ingestor//alerts//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + icsEscape(feedTitle()))
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, ev := range events {
+		rec := ev.rec
+		line("BEGIN:VEVENT")
+		line("UID:" + alertGUID(rec) + "@flight-ingestor")
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + rec.Time.UTC().Format("20060102T150405Z"))
+		line("DTEND:" + ev.end.UTC().Format("20060102T150405Z"))
+		line("SUMMARY:" + icsEscape(alertTitle(rec)))
+		line("DESCRIPTION:" + icsEscape(alertSummary(rec)))
+		line("URL:" + alertLink(rec))
+		line("CATEGORIES:" + icsEscape(rec.Type))
+		if rec.Lat != nil && rec.Lon != nil {
+			line(fmt.Sprintf("GEO:%.6f;%.6f", *rec.Lat, *rec.Lon))
+			line(fmt.Sprintf("LOCATION:%.4f\\, %.4f", *rec.Lat, *rec.Lon))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// foldICSLine wraps a content line at 75 octets (RFC 5545) and adds the CRLF.
+func foldICSLine(s string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// --- Command line ---
+//
+//	flight-ingestor [--config FILE] [--log-level LEVEL] [COMMAND [ARGS]]
+//
+// Global flags come before the command; each command parses its own. With
+// no command (or "run") the daemon starts, which is what the service units
+// and profiles run.
+
+// version is stamped at build time:
+//
+//	go build -ldflags "-X main.version=v1.4.0"
+var version = "dev"
+
+type cliCommand struct {
+	Name  string
+	Usage string
+	Help  string
+	Run   func(args []string) int
+}
+
+// cliCommands is the table runCommand dispatches on, in the order "help"
+// lists them. Two-word commands ("rules test") match on both words.
+var cliCommands []cliCommand
+
+func init() {
+	cliCommands = []cliCommand{
+		{"run", "run", "start the daemon (the default)", runDaemon},
+		{"validate-config", "validate-config [--online [--post]] [FILE]", "check a config file and exit (alias: validate)", runValidate},
+		{"validate", "", "", runValidate},
+		{"migrate", "migrate [FILE]", "upgrade a config file to the current schema", runMigrate},
+		{"test-alert", "test-alert [--type TYPE] [--channel NAME] [--hex HEX]", "send one alert to check a webhook and its formatting", runTestAlert},
+		{"rules test", "rules test [--hex HEX | FILE]", "explain which rules sample aircraft would match", runRulesTest},
+		{"once", "once [--nationwide] [--dry-run] [--json]", "run one poll cycle and exit", runOnce},
+		{"import globe-history", "import globe-history [--radius-nm NM] [--sightings] DIR", "backfill history from tar1090 globe_history", runGlobeImport},
+		{"tui", "tui", "run the daemon with a live terminal view", runTUI},
+		{"service", "service unit|install|uninstall|start|stop", "manage the system service", runService},
+		{"profiles", "profiles [FILE]", "run several profiles from one process", runProfiles},
+		{"version", "version", "print the version and exit", runVersion},
+		{"help", "help", "show this list", runHelp},
+	}
+}
+
+// parseGlobalFlags handles the flags that apply to every command and returns
+// the command line that's left.
+func parseGlobalFlags(args []string) ([]string, bool) {
+	fs := flag.NewFlagSet("flight-ingestor", flag.ContinueOnError)
+	fs.Usage = func() { printUsage(os.Stderr) }
+	fs.StringVar(&configFile, "config", configFile, "config file")
+	level := fs.String("log-level", "info", "error, warn, info or debug")
+	showVersion := fs.Bool("version", false, "print the version and exit")
+	if err := fs.Parse(args); err != nil {
+		return nil, false
+	}
+	l, ok := parseLogLevel(*level)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown log level %q (want error, warn, info or debug)\n", *level)
+		return nil, false
+	}
+	activeLogLevel = l
+	if *showVersion {
+		return []string{"version"}, true
+	}
+	return fs.Args(), true
+}
+
+// needsConfig reports whether the command reads config.json, so "version"
+// and "help" don't log a config load.
+func needsConfig(args []string) bool {
+	return len(args) == 0 || (args[0] != "version" && args[0] != "help")
+}
+
+func runCommand(args []string) int {
+	for _, c := range cliCommands {
+		words := strings.Fields(c.Name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == c.Name {
+			return c.Run(args[len(words):])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n", strings.Join(args, " "))
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s [--config FILE] [--log-level LEVEL] [COMMAND]\n\ncommands:\n", os.Args[0])
+	for _, c := range cliCommands {
+		if c.Usage != "" {
+			fmt.Fprintf(w, "  %-58s %s\n", c.Usage, c.Help)
+		}
+	}
+}
+
+func runHelp(args []string) int {
+	printUsage(os.Stdout)
+	return 0
+}
+
+// runDaemon never returns.
+func runDaemon(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: run")
+		return 2
+	}
+	startDaemon()
+	select {}
+}
+
+// --- CLI: version ---
+func runVersion(args []string) int {
+	fmt.Println(versionString())
+	return 0
+}
+
+// versionString adds the commit a plain "go build" records, so dev builds
+// can still be told apart.
+func versionString() string {
+	s := "flight-ingestor " + version
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return s
+	}
+	settings := make(map[string]string)
+	for _, kv := range info.Settings {
+		settings[kv.Key] = kv.Value
+	}
+	if rev := settings["vcs.revision"]; rev != "" {
+		s += " (" + rev[:min(12, len(rev))]
+		if settings["vcs.modified"] == "true" {
+			s += ", modified"
+		}
+		s += ")"
+	}
+	return s + " " + info.GoVersion
+}
+
+// --- Log level ---
+// Log lines are plain "[XX] ..." Printfs, so the level is read off the text:
+// lines mentioning an error or failure are errors, warnings are warnings and
+// everything else is info. debugf lines only print at debug. Output that
+// isn't a log line (command results, panics) always passes.
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var logLevelNames = []string{"error", "warn", "info", "debug"}
+
+var activeLogLevel = levelInfo
+
+func parseLogLevel(s string) (logLevel, bool) {
+	for i, name := range logLevelNames {
+		if strings.EqualFold(s, name) || (name == "warn" && strings.EqualFold(s, "warning")) {
+			return logLevel(i), true
+		}
+	}
+	return levelInfo, false
+}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+func debugf(format string, args ...any) {
+	if activeLogLevel >= levelDebug {
+		fmt.Printf(format, args...)
+	}
+}
+
+func lineLevel(line string) logLevel {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "error") || strings.Contains(lower, "failed") || strings.Contains(lower, "panic"):
+		return levelError
+	case strings.Contains(lower, "warn"):
+		return levelWarn
+	}
+	return levelInfo
+}
+
+// filterLogs drops stdout log lines below the active level. Nothing is
+// filtered at info or debug.
+func filterLogs() {
+	if activeLogLevel >= levelInfo {
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: log filter: %v\n", err)
+		return
+	}
+	out := os.Stdout
+	os.Stdout = w
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			line := sc.Text()
+			if !strings.HasPrefix(line, "[") || lineLevel(line) <= activeLogLevel {
+				fmt.Fprintln(out, line)
+			}
+		}
+	}()
+}
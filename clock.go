@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// --- Clock and HTTP transport ---
+// Alert state (cooldowns, dwell timers, escalations, re-alert windows) reads
+// the time through clock, and every outbound request goes through
+// httpTransport, so a fake clock and canned responses can drive the
+// alerting logic end to end.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+var clock Clock = systemClock{}
+
+// since is time.Since on clock.
+func since(t time.Time) time.Duration {
+	return clock.Now().Sub(t)
+}
+
+var httpTransport http.RoundTripper = http.DefaultTransport
+
+// injectedTransport looks httpTransport up per request, so clients built at
+// init still pick up a replacement.
+type injectedTransport struct{}
+
+func (injectedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return httpTransport.RoundTrip(r)
+}
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: injectedTransport{}}
+}
+
+// httpClient is for requests that had no client of their own.
+var httpClient = newHTTPClient(0)
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const configWatchInterval = 10 * time.Second
+
+// --- Runtime configuration (config.json) ---
+// Everything in here is optional. Missing keys keep the defaults below, and a
+// missing file just means "run with the built-in behaviour".
+type Config struct {
+	Version int `json:"version"` // schema version, see migrate.go
+
+	// Named alert destinations. Rules refer to these by name, but a raw
+	// webhook URL is accepted anywhere a channel name is.
+	Channels map[string]string `json:"channels"`
+
+	DwellRules     []DwellRule      `json:"dwell_rules"`
+	OperatorRules  []OperatorRule   `json:"operator_rules"`
+	AggregateRules []AggregateRule  `json:"aggregate_rules"`
+	DescentRules   []DescentRule    `json:"descent_rules"`
+	SquawkRules    []SquawkRule     `json:"squawk_rules"`
+	Watchlist      WatchlistConfig  `json:"watchlist"`
+	Nationwide     NationwideConfig `json:"nationwide"`
+	SpecialTypes   []SpecialType    `json:"special_types"`
+	// Extra military callsign prefixes, merged over the built-in table.
+	MilitaryCallsigns []MilCallsign      `json:"military_callsigns"`
+	Regions           map[string]Polygon `json:"regions"`
+	Proximity         ProximityConfig    `json:"proximity"`
+	Emergency         EmergencyConfig    `json:"emergency"`
+	Resolutions       ResolutionConfig   `json:"resolutions"`
+	Mutes             []Mute             `json:"mutes"`
+	Blocklist         Blocklist          `json:"blocklist"`
+
+	// Active windows for the built-in triggers, keyed by trigger name.
+	// Triggers without an entry are always on.
+	Schedules map[string][]Schedule `json:"schedules"`
+
+	// Tags added to every alert of a built-in trigger type, keyed by alert type.
+	TriggerTags map[string][]string `json:"trigger_tags"`
+
+	SightingLog  SightingLogConfig  `json:"sighting_log"`
+	CSVExport    CSVExportConfig    `json:"csv_export"`
+	Parquet      ParquetConfig      `json:"parquet"`
+	Influx       InfluxConfig       `json:"influx"`
+	RemoteWrite  RemoteWriteConfig  `json:"remote_write"`
+	Tracks       TrackConfig        `json:"tracks"`
+	PhotoArchive PhotoArchiveConfig `json:"photo_archive"`
+	History      HistoryConfig      `json:"history"`
+	Leaderboards LeaderboardConfig  `json:"leaderboards"`
+	Retention    RetentionConfig    `json:"retention"`
+	S3           S3Config           `json:"s3"`
+	DiscordBot   DiscordBotConfig   `json:"discord_bot"`
+	Feed         FeedConfig         `json:"feed"`
+	Calendar     CalendarConfig     `json:"calendar"`
+	State        StateConfig        `json:"state"`
+	Heatmap      HeatmapConfig      `json:"heatmap"`
+	Elevation    ElevationConfig    `json:"elevation"`
+	Coverage     CoverageConfig     `json:"coverage"`
+	Ops          OpsConfig          `json:"ops"`
+	Conflicts    ConflictConfig     `json:"conflicts"`
+	Mastodon     MastodonConfig     `json:"mastodon"`
+	Bluesky      BlueskyConfig      `json:"bluesky"`
+	MQTT         MQTTConfig         `json:"mqtt"`
+	Push         PushConfig         `json:"push"`
+	AeroAPI      AeroAPIConfig      `json:"aeroapi"`
+	Routes       RouteConfig        `json:"routes"`
+	Sigmet       SigmetConfig       `json:"sigmet"`
+	PhotoMode    PhotoModeConfig    `json:"photo_mode"`
+	Transit      TransitConfig      `json:"transit"`
+	Announce     AnnounceConfig     `json:"announce"`
+	Desktop      DesktopConfig      `json:"desktop"`
+	Telegram     TelegramConfig     `json:"telegram"`
+	Display      DisplayConfig      `json:"display"`
+	Feeder       FeederConfig       `json:"feeder"`
+	FeedStatus   FeedStatusConfig   `json:"feed_status"`
+	Digest       DigestConfig       `json:"digest"`
+	GlobalWatch  GlobalWatchConfig  `json:"global_watch"`
+	AirportBoard AirportBoardConfig `json:"airport_board"`
+	UAT          UATConfig          `json:"uat"`
+	Map          MapConfig          `json:"map"`
+	Offline      OfflineConfig      `json:"offline"`
+	Attachments  AttachmentConfig   `json:"attachments"`
+	Adsbdb       AdsbdbConfig       `json:"adsbdb"`
+	Airlines     AirlinesConfig     `json:"airlines"`
+	Observer     ObserverConfig     `json:"observer"`
+	Overhead     OverheadConfig     `json:"overhead"`
+	Locations    []Location         `json:"locations"`
+	// Do-not-disturb windows, keyed by channel name. See quiet.go.
+	QuietHours map[string]QuietHours `json:"quiet_hours"`
+	// Where the radius loop gets aircraft, in priority order. See source.go.
+	Sources []SourceConfig `json:"sources"`
+
+	// Address for the HTTP admin API, e.g. ":8080". Empty disables it.
+	HTTPListen string `json:"http_listen"`
+	// Bearer token the API's write routes (/mutes, /reload) require.
+	// Empty refuses them.
+	HTTPToken string `json:"http_token"`
+}
+
+// Duration lets config values be written as "90s", "20m", "2h".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		// Plain numbers are taken as seconds
+		var secs float64
+		if err := json.Unmarshal(b, &secs); err != nil {
+			return fmt.Errorf("invalid duration %s", string(b))
+		}
+		d.Duration = time.Duration(secs * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// The active config is swapped atomically on reload, so always go through cfg().
+var activeConfig atomic.Pointer[Config]
+
+func cfg() *Config {
+	if c := activeConfig.Load(); c != nil {
+		return c
+	}
+	return defaultConfig()
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Channels: map[string]string{
+			"watchlist":        discordHookWatchlist,
+			"proximity":        discordHookProximity,
+			"special_military": discordHookSpecialMil,
+		},
+		Proximity: ProximityConfig{
+			RadiusNM:          proximityRadiusNM,
+			MaxAltFT:          proximityAltitudeFT,
+			MaxTrackOffsetDeg: 45,
+			MinDescentFPM:     200,
+		},
+		Resolutions: ResolutionConfig{
+			DepartedAfter: Duration{10 * time.Minute},
+		},
+	}
+}
+
+func readConfig(path string) (*Config, error) {
+	c := defaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range unknownConfigFields(data) {
+		fmt.Printf("[CF] Warning: %s:%d: %s: %s\n", path, p.Line, p.Path, p.Message)
+	}
+	data, notes, err := migrateConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		fmt.Printf("[CF] %s: %s\n", path, note)
+	}
+	if len(notes) > 0 {
+		fmt.Printf("[CF] Run '%s migrate' to save the upgraded %s.\n", os.Args[0], path)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	c.Blocklist.compile()
+	c.Proximity.applyUnits()
+	return c, nil
+}
+
+func loadConfig() *Config {
+	c, err := readConfig(configFile)
+	if os.IsNotExist(err) {
+		fmt.Printf("[CF] Warning: Could not read %s. Using defaults.\n", configFile)
+		return defaultConfig()
+	}
+	if err != nil {
+		fmt.Printf("[CF] Error parsing %s: %v. Using defaults. Run '%s validate' for details.\n", configFile, err, os.Args[0])
+		return defaultConfig()
+	}
+	fmt.Printf("[CF] Loaded %s (%d dwell rules, %d operator rules).\n", configFile, len(c.DwellRules), len(c.OperatorRules))
+	return c
+}
+
+// watchConfig polls config.json and swaps in the new rules when it changes,
+// and reloads on SIGHUP (or POST /reload) whether it changed or not. A file
+// that fails to parse is reported and the running config is kept. Alert
+// state (globalRadiusState and the rule state) is never touched; rule state
+// is keyed by rule name, so cooldowns and dwell timers survive a reload for
+// every rule whose name didn't change.
+func watchConfig() {
+	var lastMod time.Time
+	if info, err := os.Stat(configFile); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hup:
+			fmt.Println("[CF] SIGHUP received, reloading config.")
+		case <-ticker.C:
+			info, err := os.Stat(configFile)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+		}
+		if info, err := os.Stat(configFile); err == nil {
+			lastMod = info.ModTime()
+		}
+		reloadConfig()
+	}
+}
+
+// reloadConfig swaps in config.json. The swap happens between radius polls
+// so no poll sees half of each config.
+func reloadConfig() (*Config, error) {
+	c, err := readConfig(configFile)
+	if err != nil {
+		fmt.Printf("[CF] Error reloading %s, keeping current config: %v\n", configFile, err)
+		return nil, err
+	}
+	old := cfg()
+	radiusMutex.Lock()
+	activeConfig.Store(c)
+	// Saved before a poll can reload the old list from Redis
+	replaceConfigMutes(c.Mutes)
+	saveMutes()
+	radiusMutex.Unlock()
+	if !reflect.DeepEqual(old.Observer.Lat, c.Observer.Lat) || !reflect.DeepEqual(old.Observer.Lon, c.Observer.Lon) || old.Observer.RadiusNM != c.Observer.RadiusNM {
+		fmt.Println("[CF] The observer location only changes on restart.")
+	}
+	fmt.Printf("[CF] Reloaded %s (%d dwell rules, %d operator rules).\n", configFile, len(c.DwellRules), len(c.OperatorRules))
+	return c, nil
+}
+
+// resolveChannel turns a channel name into a webhook URL.
+func resolveChannel(name string) string {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return name
+	}
+	return cfg().Channels[name]
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Cross-source conflict detection ---
+// Each data source reports the positions it sees. When two sources report
+// the same hex within the same minute with positions that can't both be
+// right (further apart than the aircraft could have flown) or with different
+// callsigns, the conflict is logged along with the source we trust more.
+// Aircraft that keep conflicting are flagged to the ops channel for review.
+type ConflictConfig struct {
+	Enabled       bool           `json:"enabled"`
+	SourceQuality map[string]int `json:"source_quality"`  // higher wins, default radius 2, nationwide 1
+	MaxDistanceNM float64        `json:"max_distance_nm"` // slack on top of the distance flown, default 5
+	FlagAfter     int            `json:"flag_after"`      // conflicts within FlagWindow before flagging, default 3
+	FlagWindow    Duration       `json:"flag_window"`     // default 1h
+}
+
+const conflictPairWindow = time.Minute
+
+type sourceReport struct {
+	Source   string
+	Time     time.Time
+	Lat, Lon float64
+	HasPos   bool
+	Callsign string
+	GS       float64
+}
+
+type SourceConflict struct {
+	Time       time.Time `json:"time"`
+	Hex        string    `json:"hex"`
+	Sources    [2]string `json:"sources"`
+	Preferred  string    `json:"preferred"`
+	DistanceNM float64   `json:"distance_nm,omitempty"`
+	Callsigns  [2]string `json:"callsigns,omitempty"`
+	Reason     string    `json:"reason"`
+}
+
+var (
+	sourceReports    = make(map[string]map[string]sourceReport) // hex -> source -> last report
+	recentConflicts  []SourceConflict
+	flaggedConflicts = make(map[string]time.Time)
+	conflictMutex    = &sync.Mutex{}
+)
+
+func sourceQuality(source string) int {
+	if q, ok := cfg().Conflicts.SourceQuality[source]; ok {
+		return q
+	}
+	switch source {
+	case "radius":
+		return 2
+	case "nationwide":
+		return 1
+	}
+	return 0
+}
+
+// reportSource records what one source saw for an aircraft and checks it
+// against the other sources' reports from the same minute.
+func reportSource(source string, ac Aircraft) {
+	cc := cfg().Conflicts
+	if !cc.Enabled {
+		return
+	}
+	lat, lon, hasPos := getActualCoords(ac)
+	report := sourceReport{Source: source, Time: clock.Now(), Lat: lat, Lon: lon, HasPos: hasPos, Callsign: strings.TrimSpace(ac.Flight), GS: ac.GS.Or(0)}
+
+	conflictMutex.Lock()
+	defer conflictMutex.Unlock()
+
+	reports := sourceReports[ac.Hex]
+	if reports == nil {
+		reports = make(map[string]sourceReport)
+		sourceReports[ac.Hex] = reports
+	}
+	for other, prev := range reports {
+		if other == source || report.Time.Sub(prev.Time) > conflictPairWindow {
+			continue
+		}
+		if c, ok := compareReports(ac.Hex, prev, report, cc); ok {
+			recordConflictLocked(c, cc)
+		}
+	}
+	reports[source] = report
+}
+
+func compareReports(hex string, a, b sourceReport, cc ConflictConfig) (SourceConflict, bool) {
+	c := SourceConflict{Time: b.Time, Hex: hex, Sources: [2]string{a.Source, b.Source}, Preferred: a.Source}
+	if sourceQuality(b.Source) > sourceQuality(a.Source) {
+		c.Preferred = b.Source
+	}
+
+	var reasons []string
+	if a.HasPos && b.HasPos {
+		slack := cc.MaxDistanceNM
+		if slack <= 0 {
+			slack = 5
+		}
+		// Allow for the distance the aircraft could cover between the two reports
+		flown := max(a.GS, b.GS) * b.Time.Sub(a.Time).Hours()
+		c.DistanceNM = geo.DistanceNM(a.Lat, a.Lon, b.Lat, b.Lon)
+		if c.DistanceNM > flown+slack {
+			reasons = append(reasons, fmt.Sprintf("positions %s apart", fmtDistance(c.DistanceNM)))
+		}
+	}
+	if a.Callsign != "" && b.Callsign != "" && !strings.EqualFold(a.Callsign, b.Callsign) {
+		c.Callsigns = [2]string{a.Callsign, b.Callsign}
+		reasons = append(reasons, fmt.Sprintf("callsign %s vs %s", a.Callsign, b.Callsign))
+	}
+	if len(reasons) == 0 {
+		return c, false
+	}
+	c.Reason = strings.Join(reasons, ", ")
+	return c, true
+}
+
+func recordConflictLocked(c SourceConflict, cc ConflictConfig) {
+	fmt.Printf("[CON] %s: %s (%s vs %s, preferring %s)\n", c.Hex, c.Reason, c.Sources[0], c.Sources[1], c.Preferred)
+	recentConflicts = append(recentConflicts, c)
+	if len(recentConflicts) > 500 {
+		recentConflicts = recentConflicts[len(recentConflicts)-500:]
+	}
+
+	window := cc.FlagWindow.Duration
+	if window <= 0 {
+		window = time.Hour
+	}
+	flagAfter := cc.FlagAfter
+	if flagAfter <= 0 {
+		flagAfter = 3
+	}
+	count := 0
+	for _, prev := range recentConflicts {
+		if prev.Hex == c.Hex && c.Time.Sub(prev.Time) <= window {
+			count++
+		}
+	}
+	if count < flagAfter || c.Time.Sub(flaggedConflicts[c.Hex]) < window {
+		return
+	}
+	flaggedConflicts[c.Hex] = c.Time
+	go notifyOps("🔀 Persistent Source Conflict",
+		fmt.Sprintf("`%s` has conflicted %d times in the last %v.\nLatest: %s (%s vs %s, preferring %s)",
+			c.Hex, count, window, c.Reason, c.Sources[0], c.Sources[1], c.Preferred), 10181046)
+}
+
+// pruneSourceReports drops reports too old to pair with anything.
+func pruneSourceReports() {
+	cutoff := clock.Now().Add(-conflictPairWindow)
+	conflictMutex.Lock()
+	defer conflictMutex.Unlock()
+	for hex, reports := range sourceReports {
+		for source, r := range reports {
+			if r.Time.Before(cutoff) {
+				delete(reports, source)
+			}
+		}
+		if len(reports) == 0 {
+			delete(sourceReports, hex)
+		}
+	}
+	for hex, t := range flaggedConflicts {
+		if since(t) > 24*time.Hour {
+			delete(flaggedConflicts, hex)
+		}
+	}
+}
+
+// GET /conflicts
+func handleConflicts(w http.ResponseWriter, r *http.Request) {
+	conflictMutex.Lock()
+	out := append([]SourceConflict{}, recentConflicts...)
+	conflictMutex.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- validate --online ---
+// The static checks can't tell a deleted webhook from a live one, or notice
+// that this host can't reach adsb.lol. With --online, validate also asks
+// Discord about every webhook (a GET returns the webhook's details without
+// posting anything), and checks that the aircraft feeds and adsbdb answer.
+// --post goes further and sends a short test message to every webhook.
+type connectivityCheck struct {
+	Post bool
+}
+
+const connectivityTimeout = 10 * time.Second
+
+type feedCheck struct {
+	path string // config path errors are reported against
+	url  string
+}
+
+func (v *configValidator) checkConnectivity(c *Config, cc connectivityCheck) {
+	client := newHTTPClient(connectivityTimeout)
+
+	urls := make([]string, 0, len(v.webhooks))
+	for u := range v.webhooks {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		path := v.webhooks[u]
+		if err := checkWebhookLive(client, u); err != nil {
+			v.errorf(path, "webhook check failed: %v", err)
+			continue
+		}
+		if cc.Post {
+			if err := postTestMessage(client, u, path); err != nil {
+				v.errorf(path, "test message failed: %v", err)
+			}
+		}
+	}
+
+	for _, f := range feedChecks(c) {
+		if err := checkReachable(client, f.url); err != nil {
+			v.errorf(f.path, "%s: %v", f.url, err)
+		}
+	}
+}
+
+// checkWebhookLive: Discord answers a GET on a valid webhook with its name
+// and channel, and 401/404 once it has been deleted.
+func checkWebhookLive(client *http.Client, webhook string) error {
+	resp, err := client.Get(webhook)
+	if err != nil {
+		return unwrapURLError(err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// Rate limited, but it exists
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("Discord says the webhook doesn't exist (%s)", resp.Status)
+	}
+	return fmt.Errorf("Discord returned %s", resp.Status)
+}
+
+func postTestMessage(client *http.Client, webhook, path string) error {
+	msg := DiscordWebhook{Content: fmt.Sprintf("✅ flight-ingestor config check: `%s` can post here.", path)}
+	payload, _ := json.Marshal(msg)
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return unwrapURLError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Discord returned %s", resp.Status)
+	}
+	return nil
+}
+
+// checkReachable only cares that something answered; a 404 for an unknown
+// aircraft still means the service is up. tcp:// entries only need to accept
+// a connection.
+func checkReachable(client *http.Client, u string) error {
+	if addr, ok := strings.CutPrefix(u, "tcp://"); ok {
+		conn, err := net.DialTimeout("tcp", addr, connectivityTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return unwrapURLError(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("returned %s", resp.Status)
+	}
+	return nil
+}
+
+// unwrapURLError drops the URL from a request error; webhook URLs carry
+// their token.
+func unwrapURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Err
+	}
+	return err
+}
+
+// feedChecks lists the aircraft sources and lookup services the config will
+// poll, one entry per URL.
+func feedChecks(c *Config) []feedCheck {
+	var checks []feedCheck
+	seen := make(map[string]bool)
+	aggregator := primaryAggregator(c)
+	add := func(path, u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			checks = append(checks, feedCheck{path, u})
+		}
+	}
+
+	if !c.Push.DisablePolling {
+		lat, lon, radius := apiLat, apiLng, apiRadiusNM
+		if c.Observer.Lat != nil && c.Observer.Lon != nil {
+			lat, lon = *c.Observer.Lat, *c.Observer.Lon
+		}
+		if c.Observer.RadiusNM > 0 {
+			radius = c.Observer.RadiusNM
+		}
+		polled := false
+		for i, sc := range c.Sources {
+			path := fmt.Sprintf("sources[%d]", i)
+			switch typ := normalizeSourceType(sc.Type); {
+			case typ == "readsb":
+				polled = true
+				if strings.HasPrefix(sc.URL, "http") {
+					add(path+".url", sc.URL)
+				}
+			case typ == "sbs":
+				// A TCP feed, checked by dialing it
+				polled = true
+				add(path+".address", "tcp://"+sc.address(defaultSBSAddress))
+			case typ == "beast":
+				polled = true
+				add(path+".address", "tcp://"+sc.address(defaultBeastAddress))
+			case isAggregator(typ) && !c.Offline.Enabled:
+				polled = true
+				add(path, aggregatorPointURL(typ, lat, lon, radius))
+			case typ == "opensky" && !c.Offline.Enabled:
+				// states/all is billed per call, so only the host is checked
+				polled = true
+				add(path, "https://opensky-network.org/")
+			}
+		}
+		switch {
+		case polled:
+		case c.Offline.Enabled && c.Offline.ReceiverURL != "":
+			add("offline.receiver_url", c.Offline.ReceiverURL)
+		case c.Offline.Enabled:
+			add("feeder.url", c.Feeder.URL)
+		default:
+			add("observer", aggregatorPointURL("adsb.lol", lat, lon, radius))
+		}
+	}
+	for i, l := range c.Locations {
+		if l.FeedURL != "" {
+			add(fmt.Sprintf("locations[%d].feed_url", i), l.FeedURL)
+		} else if !c.Offline.Enabled {
+			add(fmt.Sprintf("locations[%d]", i), aggregatorPointURL(aggregator, l.Lat, l.Lon, l.radius()))
+		}
+	}
+	if !c.Offline.Enabled {
+		add("adsbdb", adsbdbAPIURL+"a835af")
+	}
+	return checks
+}
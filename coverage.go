@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Receiver coverage by bearing ---
+// Tracks the furthest position and number of positions seen in each bearing
+// sector around the observer. Persisted to a JSON file so the plot survives
+// restarts; served at GET /coverage (JSON) and GET /coverage.svg (polar plot).
+type CoverageConfig struct {
+	Path      string   `json:"path"`       // empty disables coverage tracking
+	SectorDeg float64  `json:"sector_deg"` // default 10
+	SaveEvery Duration `json:"save_every"` // default 10m
+}
+
+type CoverageSector struct {
+	BearingDeg float64   `json:"bearing_deg"` // sector start
+	MaxRangeNM float64   `json:"max_range_nm"`
+	MaxRangeAt time.Time `json:"max_range_at,omitempty"`
+	MaxHex     string    `json:"max_hex,omitempty"`
+	Positions  int64     `json:"positions"`
+}
+
+type CoverageStats struct {
+	Since   time.Time        `json:"since"`
+	Sectors []CoverageSector `json:"sectors"`
+}
+
+var (
+	coverage      *CoverageStats
+	coverageSaved time.Time
+	coverageMutex = &sync.Mutex{}
+)
+
+func coverageSectorDeg() float64 {
+	if deg := cfg().Coverage.SectorDeg; deg > 0 && deg <= 90 {
+		return deg
+	}
+	return 10
+}
+
+// loadCoverageLocked reads the persisted stats, starting fresh if the file is
+// missing or was written with a different sector size.
+func loadCoverageLocked(path string) {
+	sectorDeg := coverageSectorDeg()
+	n := int(math.Ceil(360 / sectorDeg))
+	var stats CoverageStats
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &stats); err != nil {
+			fmt.Printf("[COV] Error reading %s, starting fresh: %v\n", path, err)
+		}
+	}
+	if len(stats.Sectors) != n {
+		stats = CoverageStats{Since: time.Now(), Sectors: make([]CoverageSector, n)}
+		for i := range stats.Sectors {
+			stats.Sectors[i].BearingDeg = float64(i) * sectorDeg
+		}
+	}
+	coverage = &stats
+	coverageSaved = time.Now()
+}
+
+func recordCoverage(rec SightingRecord) {
+	path := cfg().Coverage.Path
+	if path == "" || rec.Lat == nil || rec.Lon == nil || rec.Distance == nil {
+		return
+	}
+	bearing := geo.InitialBearing(apiLat, apiLng, *rec.Lat, *rec.Lon)
+
+	coverageMutex.Lock()
+	defer coverageMutex.Unlock()
+	if coverage == nil {
+		loadCoverageLocked(path)
+	}
+	i := int(bearing/coverageSectorDeg()) % len(coverage.Sectors)
+	s := &coverage.Sectors[i]
+	s.Positions++
+	if *rec.Distance > s.MaxRangeNM {
+		s.MaxRangeNM, s.MaxRangeAt, s.MaxHex = *rec.Distance, rec.Time, rec.Hex
+	}
+
+	saveEvery := cfg().Coverage.SaveEvery.Duration
+	if saveEvery <= 0 {
+		saveEvery = 10 * time.Minute
+	}
+	if time.Since(coverageSaved) >= saveEvery {
+		saveCoverageLocked(path)
+	}
+}
+
+func saveCoverageLocked(path string) {
+	data, err := json.MarshalIndent(coverage, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		fmt.Printf("[COV] Error saving %s: %v\n", path, err)
+	}
+	coverageSaved = time.Now()
+}
+
+func coverageSnapshot() (CoverageStats, bool) {
+	coverageMutex.Lock()
+	defer coverageMutex.Unlock()
+	if coverage == nil {
+		if cfg().Coverage.Path == "" {
+			return CoverageStats{}, false
+		}
+		loadCoverageLocked(cfg().Coverage.Path)
+	}
+	snap := *coverage
+	snap.Sectors = append([]CoverageSector(nil), coverage.Sectors...)
+	return snap, true
+}
+
+func handleCoverage(w http.ResponseWriter, r *http.Request) {
+	stats, ok := coverageSnapshot()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("coverage tracking is disabled"))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleCoverageSVG draws max range per sector as a filled polygon on range
+// rings, with sector opacity scaled by position count.
+func handleCoverageSVG(w http.ResponseWriter, r *http.Request) {
+	stats, ok := coverageSnapshot()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("coverage tracking is disabled"))
+		return
+	}
+	const size, margin = 600.0, 30.0
+	center := size / 2
+	maxRange, maxCount := 0.0, int64(1)
+	for _, s := range stats.Sectors {
+		maxRange = math.Max(maxRange, s.MaxRangeNM)
+		if s.Positions > maxCount {
+			maxCount = s.Positions
+		}
+	}
+	ringStep := 50.0
+	if maxRange <= 100 {
+		ringStep = 10
+	}
+	scaleMax := math.Max(ringStep, math.Ceil(maxRange/ringStep)*ringStep)
+	scale := (center - margin) / scaleMax
+	point := func(bearingDeg, rangeNM float64) (float64, float64) {
+		rad := bearingDeg * math.Pi / 180
+		return center + rangeNM*scale*math.Sin(rad), center - rangeNM*scale*math.Cos(rad)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]g" height="%[1]g" viewBox="0 0 %[1]g %[1]g" font-family="sans-serif" font-size="11">`, size)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#10141c"/>`)
+	for r := ringStep; r <= scaleMax; r += ringStep {
+		fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="%.1f" fill="none" stroke="#3a4455"/>`, center, center, r*scale)
+		fmt.Fprintf(&b, `<text x="%g" y="%.1f" fill="#7a8699">%s</text>`, center+3, center-r*scale-3, fmtRadius(r))
+	}
+	sectorDeg := 360 / float64(len(stats.Sectors))
+	var outline []string
+	for _, s := range stats.Sectors {
+		if s.MaxRangeNM <= 0 {
+			continue
+		}
+		x1, y1 := point(s.BearingDeg, s.MaxRangeNM)
+		x2, y2 := point(s.BearingDeg+sectorDeg, s.MaxRangeNM)
+		opacity := 0.15 + 0.6*math.Log1p(float64(s.Positions))/math.Log1p(float64(maxCount))
+		fmt.Fprintf(&b, `<path d="M%g,%g L%.1f,%.1f A%.1f,%.1f 0 0,1 %.1f,%.1f Z" fill="#3fa7ff" fill-opacity="%.2f"><title>%g°: %.1f nm, %d positions</title></path>`,
+			center, center, x1, y1, s.MaxRangeNM*scale, s.MaxRangeNM*scale, x2, y2, opacity, s.BearingDeg, s.MaxRangeNM, s.Positions)
+		outline = append(outline, fmt.Sprintf("%.1f,%.1f %.1f,%.1f", x1, y1, x2, y2))
+	}
+	if len(outline) > 0 {
+		fmt.Fprintf(&b, `<polygon points="%s" fill="none" stroke="#9fd3ff" stroke-width="1.5"/>`, strings.Join(outline, " "))
+	}
+	for _, l := range []struct {
+		label   string
+		bearing float64
+	}{{"N", 0}, {"E", 90}, {"S", 180}, {"W", 270}} {
+		x, y := point(l.bearing, scaleMax+margin/(2*scale))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="#ffffff" text-anchor="middle" dominant-baseline="middle">%s</text>`, x, y, l.label)
+	}
+	fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="3" fill="#ffffff"/></svg>`, center, center)
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write([]byte(b.String()))
+}
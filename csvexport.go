@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Daily CSV export ---
+// alerts-YYYY-MM-DD.csv gets one row per alert, aircraft-YYYY-MM-DD.csv one
+// row per unique aircraft seen that day. A plane-spotting log for
+// spreadsheet users, no query tooling needed.
+type CSVExportConfig struct {
+	Dir string `json:"dir"` // empty disables the export
+}
+
+var (
+	csvExportMutex = &sync.Mutex{}
+	csvSeenDay     string
+	csvSeenToday   = make(map[string]bool)
+)
+
+var (
+	csvAlertHeader    = []string{"time", "type", "rule", "hex", "flight", "registration", "aircraft_type", "owner", "squawk", "alt_baro", "lat", "lon", "tags", "outcome"}
+	csvAircraftHeader = []string{"first_seen", "hex", "flight", "registration", "type", "mil", "alt_baro", "gs", "distance_nm"}
+)
+
+func optFloat(v *float64, format string) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf(format, *v)
+}
+
+func exportAlertCSV(rec AlertRecord) {
+	dir := cfg().CSVExport.Dir
+	if dir == "" {
+		return
+	}
+	row := []string{
+		rec.Time.Format(time.RFC3339), rec.Type, rec.Rule, rec.Hex, rec.Flight, rec.Registration,
+		rec.AircraftType, rec.Owner, rec.Squawk, rec.AltBaro, optFloat(rec.Lat, "%.6f"), optFloat(rec.Lon, "%.6f"),
+		strings.Join(rec.Tags, " "), rec.Outcome,
+	}
+	if rec.Type == "aggregate" {
+		row[3] = strings.Join(rec.Members, " ")
+	}
+
+	csvExportMutex.Lock()
+	defer csvExportMutex.Unlock()
+	appendCSVRow(filepath.Join(dir, "alerts-"+rec.Time.Format("2006-01-02")+".csv"), csvAlertHeader, row)
+}
+
+func exportSightingCSV(rec SightingRecord) {
+	dir := cfg().CSVExport.Dir
+	if dir == "" {
+		return
+	}
+	day := rec.Time.Format("2006-01-02")
+	path := filepath.Join(dir, "aircraft-"+day+".csv")
+
+	csvExportMutex.Lock()
+	defer csvExportMutex.Unlock()
+
+	if csvSeenDay != day {
+		csvSeenDay = day
+		csvSeenToday = loadSeenHexes(path)
+	}
+	if csvSeenToday[rec.Hex] {
+		return
+	}
+	csvSeenToday[rec.Hex] = true
+
+	appendCSVRow(path, csvAircraftHeader, []string{
+		rec.Time.Format(time.RFC3339), rec.Hex, rec.Flight, rec.Reg, rec.Type, strconv.FormatBool(rec.Mil),
+		rec.AltBaro, fmt.Sprintf("%.1f", rec.GS), optFloat(rec.Distance, "%.1f"),
+	})
+}
+
+// loadSeenHexes reads back today's file so a restart doesn't duplicate rows.
+func loadSeenHexes(path string) map[string]bool {
+	seen := make(map[string]bool)
+	f, err := os.Open(path)
+	if err != nil {
+		return seen
+	}
+	defer f.Close()
+	records, _ := csv.NewReader(f).ReadAll()
+	for i, row := range records {
+		if i > 0 && len(row) > 1 {
+			seen[row[1]] = true
+		}
+	}
+	return seen
+}
+
+func appendCSVRow(path string, header, row []string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Printf("[CSV] Error creating export dir: %v\n", err)
+		return
+	}
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Printf("[CSV] Error opening %s: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if os.IsNotExist(statErr) {
+		w.Write(header)
+	}
+	w.Write(row)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Printf("[CSV] Error writing %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// --- Desktop notifications ---
+// For running the ingestor on a workstation: alerts of the opted-in types
+// pop up as native notifications, through notify-send (D-Bus) on Linux and
+// Notification Center (osascript) on macOS. They don't need a webhook.
+type DesktopConfig struct {
+	Enabled bool     `json:"enabled"`
+	Types   []string `json:"types"` // as for mastodon.types
+	Icon    string   `json:"icon"`  // Linux: icon name or path, default "airplane-mode"
+	Sound   string   `json:"sound"` // macOS: sound name, e.g. "Glass"
+}
+
+func notifyDesktop(rec AlertRecord) {
+	dc := cfg().Desktop
+	title := alertTitle(rec)
+	body := strings.NewReplacer("**", "", "`", "").Replace(alertSummary(rec))
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		icon := dc.Icon
+		if icon == "" {
+			icon = "airplane-mode"
+		}
+		urgency := "normal"
+		if strings.HasPrefix(rec.Type, "emergency") {
+			urgency = "critical"
+		}
+		cmd = exec.Command("notify-send", "--app-name=flight-ingestor", "--urgency="+urgency, "--icon="+icon, title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(body), strconv.Quote(title))
+		if dc.Sound != "" {
+			script += " sound name " + strconv.Quote(dc.Sound)
+		}
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		fmt.Printf("[DN] Desktop notifications aren't supported on %s\n", runtime.GOOS)
+		return
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		fmt.Printf("[DN] Error showing notification: %v: %s\n", err, strings.TrimSpace(string(out)))
+	}
+}
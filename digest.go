@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- Daily digest ---
+// Once a day, a summary of what the ingestor did and how the receiver and
+// feeders are doing goes to the digest channel.
+type DigestConfig struct {
+	Channel string `json:"channel"` // empty disables the digest
+	At      string `json:"at"`      // "HH:MM" in display.timezone, default "08:00"
+}
+
+func manageDigest() {
+	last := ingestSnapshot()
+	for {
+		time.Sleep(time.Until(nextDigestTime(time.Now())))
+		now := ingestSnapshot()
+		if webhook := resolveChannel(cfg().Digest.Channel); webhook != "" {
+			if err := postDiscordWebhook(webhook, DiscordWebhook{Embeds: []Embed{buildDigest(last, now)}}); err != nil {
+				fmt.Printf("[DG] Error posting digest: %v\n", err)
+			}
+			postDigestRecaps(webhook)
+		}
+		last = now
+	}
+}
+
+func nextDigestTime(now time.Time) time.Time {
+	minute := parseClock(cfg().Digest.At, 8*60)
+	local := now.In(displayLocation())
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, local.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func buildDigest(prev, now ingestStatus) Embed {
+	fields := []Field{
+		{Name: "Alerts", Value: fmt.Sprintf("%d sent, %d dropped", now.AlertsSent-prev.AlertsSent, now.AlertsDropped-prev.AlertsDropped), Inline: true},
+		{Name: "Polls", Value: fmt.Sprintf("%d (%d errors)", now.Polls-prev.Polls, now.PollErrors-prev.PollErrors), Inline: true},
+	}
+	if f := feederSnapshot(); f != nil {
+		state := "healthy"
+		switch {
+		case f.Stalled:
+			state = "**stalled**"
+		case f.Degraded:
+			state = "**degraded**"
+		}
+		fields = append(fields, Field{Name: "Receiver", Value: fmt.Sprintf("%s · %.0f msg/s (baseline %.0f) · %d aircraft", state, f.MessageRate, f.Baseline, f.Aircraft)})
+	}
+	if field, ok := airportDigest(); ok {
+		fields = append(fields, field)
+	}
+	if statuses := feedStatusSnapshot(); len(statuses) > 0 {
+		var lines []string
+		for _, s := range statuses {
+			icon := "✅"
+			if !s.OK {
+				icon = "⚠️"
+			}
+			lines = append(lines, fmt.Sprintf("%s **%s** — %s", icon, s.Name, s.Detail))
+		}
+		fields = append(fields, Field{Name: "Feeds", Value: strings.Join(lines, "\n")})
+	}
+	return Embed{
+		Title:       "📋 Daily Digest",
+		Description: fmt.Sprintf("Since %s", formatTime(time.Now().Add(-24*time.Hour))),
+		Color:       3447003, // Blue
+		Fields:      fields,
+		Footer:      Footer{Text: footerText(nil)},
+	}
+}
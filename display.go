@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// --- Display settings ---
+// How times are shown in alert text, the history page and schedules. Discord
+// renders <t:unix> markup in each reader's own timezone, so embeds use that
+// where they can; the configured zone covers plain text.
+type DisplayConfig struct {
+	Timezone   string      `json:"timezone"`    // IANA name, e.g. "America/New_York"; default: the host's zone
+	TimeFormat string      `json:"time_format"` // Go layout, default "2006-01-02 15:04 MST"
+	Units      UnitsConfig `json:"units"`
+	Locale     string      `json:"locale"` // alert text language: "de", "fr", "es"; default English
+}
+
+const defaultTimeFormat = "2006-01-02 15:04 MST"
+
+var (
+	locationCache = make(map[string]*time.Location)
+	locationMutex = &sync.Mutex{}
+)
+
+// displayLocation is the configured timezone, falling back to the host's.
+func displayLocation() *time.Location {
+	return cachedLocation(cfg().Display.Timezone)
+}
+
+// cachedLocation loads an IANA zone once. Empty or unknown names mean the
+// host's zone.
+func cachedLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	locationMutex.Lock()
+	defer locationMutex.Unlock()
+	if loc, ok := locationCache[name]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Printf("[CF] Unknown timezone %q, using local time: %v\n", name, err)
+		loc = time.Local
+	}
+	locationCache[name] = loc
+	return loc
+}
+
+func formatTime(t time.Time) string {
+	layout := cfg().Display.TimeFormat
+	if layout == "" {
+		layout = defaultTimeFormat
+	}
+	return t.In(displayLocation()).Format(layout)
+}
+
+// discordTime renders Discord timestamp markup; style is one of t T d D f F R.
+func discordTime(t time.Time, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
+
+// --- First seen ---
+// When each aircraft in the radius feed was first seen this visit. A gap of
+// more than sightingGap starts a new visit.
+const sightingGap = 30 * time.Minute
+
+type sightingSpan struct {
+	First, Last time.Time
+}
+
+var (
+	sightingSpans = make(map[string]sightingSpan)
+	sightingMutex = &sync.Mutex{}
+)
+
+func noteSighting(hex string, t time.Time) {
+	sightingMutex.Lock()
+	defer sightingMutex.Unlock()
+	span, ok := sightingSpans[hex]
+	if !ok || t.Sub(span.Last) > sightingGap {
+		span.First = t
+	}
+	span.Last = t
+	sightingSpans[hex] = span
+}
+
+func firstSeen(hex string) (time.Time, bool) {
+	sightingMutex.Lock()
+	defer sightingMutex.Unlock()
+	span, ok := sightingSpans[hex]
+	if !ok || since(span.Last) > sightingGap {
+		return time.Time{}, false
+	}
+	return span.First, true
+}
+
+func pruneSightingSpans() {
+	sightingMutex.Lock()
+	defer sightingMutex.Unlock()
+	for hex, span := range sightingSpans {
+		if since(span.Last) > sightingGap {
+			delete(sightingSpans, hex)
+		}
+	}
+}
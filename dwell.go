@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Dwell-time rules ---
+// Fires when an aircraft stays inside a circular zone for longer than
+// MinDuration. Catches survey flights and orbiting helicopters.
+type DwellRule struct {
+	Name        string     `json:"name"`
+	Lat         float64    `json:"lat"` // zone centre, defaults to the observer
+	Lon         float64    `json:"lon"`
+	RadiusNM    float64    `json:"radius_nm"`
+	MinDuration Duration   `json:"min_duration"`
+	Classes     []string   `json:"classes"`   // optional: "mil", "helicopter", "watchlist", ADS-B category ("A7") or ICAO type ("H60")
+	OnGround    *bool      `json:"on_ground"` // optional: only aircraft on the ground (true) or airborne (false)
+	Channel     string     `json:"channel"`
+	Schedule    []Schedule `json:"schedule"`
+	Tags        []string   `json:"tags"`
+	PhotoMode   bool       `json:"photo_mode"` // see photomode.go
+}
+
+// How long an aircraft may drop out of the feed before its dwell timer restarts
+const dwellGapTolerance = 5 * time.Minute
+
+// RuleHitState is the per-rule, per-aircraft state shared by the configurable rules.
+type RuleHitState struct {
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Alerted   bool
+}
+
+var globalRuleState = make(map[string]RuleHitState)
+
+func ruleStateKey(rule, hex string) string {
+	return rule + "|" + hex
+}
+
+// matchesClass reports whether the aircraft belongs to any of the given classes.
+// An empty class list matches everything.
+func matchesClass(ac Aircraft, classes []string) bool {
+	if len(classes) == 0 {
+		return true
+	}
+	for _, class := range classes {
+		switch c := strings.ToUpper(strings.TrimSpace(class)); c {
+		case "MIL", "MILITARY":
+			if isMilitary(ac) {
+				return true
+			}
+		case "HELICOPTER", "ROTORCRAFT":
+			if ac.Category == "A7" {
+				return true
+			}
+		case "WATCHLIST":
+			if _, ok := lookupWatchlist(ac.Hex); ok {
+				return true
+			}
+		default:
+			if strings.EqualFold(ac.Category, c) || strings.EqualFold(ac.Type, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func processDwellAlerts(site sourceCenter, ac Aircraft) {
+	// A stale or missing fix would either reset the timer or keep it running
+	// on an aircraft that may have left; hold the state until a fresh one.
+	if positionStatus(ac) != PositionFresh {
+		return
+	}
+	lat, lon, hasCoords := getActualCoords(ac)
+	now := clock.Now()
+
+	for _, rule := range cfg().DwellRules {
+		key := ruleStateKey(rule.Name, ac.Hex)
+
+		centerLat, centerLon := rule.Lat, rule.Lon
+		if centerLat == 0 && centerLon == 0 {
+			centerLat, centerLon = site.Lat, site.Lon
+		}
+
+		inZone := hasCoords && matchesClass(ac, rule.Classes) && groundStateMatches(rule.OnGround, ac) &&
+			geo.DistanceNM(centerLat, centerLon, lat, lon) <= rule.RadiusNM
+		if !inZone {
+			delete(globalRuleState, key)
+			continue
+		}
+
+		state, seen := globalRuleState[key]
+		if !seen || now.Sub(state.LastSeen) > dwellGapTolerance {
+			state = RuleHitState{FirstSeen: now}
+		}
+		state.LastSeen = now
+
+		dwell := now.Sub(state.FirstSeen)
+		if !state.Alerted && dwell >= rule.MinDuration.Duration && ruleActive(rule.Name, rule.Schedule, now) {
+			fmt.Printf("[Radius] !!! DWELL DETECTED: %s in zone '%s' for %v\n", ac.Hex, rule.Name, dwell.Round(time.Second))
+			details, _ := getAircraftDetails(ac.Hex)
+			sendDiscordAlert(resolveChannel(rule.Channel), ac, details, "dwell", &AlertContext{
+				Rule:      rule.Name,
+				Tags:      rule.Tags,
+				PhotoMode: rule.PhotoMode,
+				Note:      fmt.Sprintf("**In zone '%s' for %v**", rule.Name, dwell.Round(time.Minute)),
+			})
+			state.Alerted = true
+		}
+		globalRuleState[key] = state
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Terrain elevation (AGL) ---
+// Ground elevation comes from local SRTM .hgt tiles when srtm_dir is set,
+// otherwise from an elevation API (Open-Meteo by default). Lookups are cached
+// on a ~100 m grid since terrain doesn't move.
+type ElevationConfig struct {
+	SRTMDir string `json:"srtm_dir"` // directory of N35W079.hgt style tiles
+	APIURL  string `json:"api_url"`  // default https://api.open-meteo.com/v1/elevation
+}
+
+const (
+	metersToFeet       = 3.28084
+	elevationCacheSize = 100000
+)
+
+var (
+	elevationCache = make(map[[2]int]float64) // key: lat/lon * 1000, value: feet MSL
+	elevationMutex = &sync.Mutex{}
+	elevationHTTP  = newHTTPClient(10 * time.Second)
+)
+
+// groundElevationFT returns the terrain elevation in feet MSL at a position.
+func groundElevationFT(lat, lon float64) (float64, error) {
+	key := [2]int{int(math.Round(lat * 1000)), int(math.Round(lon * 1000))}
+	elevationMutex.Lock()
+	if ft, ok := elevationCache[key]; ok {
+		elevationMutex.Unlock()
+		return ft, nil
+	}
+	elevationMutex.Unlock()
+
+	var meters float64
+	var err error
+	if dir := cfg().Elevation.SRTMDir; dir != "" {
+		meters, err = srtmElevation(dir, lat, lon)
+	} else if offlineMode() {
+		err = fmt.Errorf("elevation API: %w; set elevation.srtm_dir", errOffline)
+	} else {
+		meters, err = apiElevation(lat, lon)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ft := meters * metersToFeet
+	elevationMutex.Lock()
+	if len(elevationCache) >= elevationCacheSize {
+		clear(elevationCache)
+	}
+	elevationCache[key] = ft
+	elevationMutex.Unlock()
+	return ft, nil
+}
+
+// srtmElevation reads one sample from an SRTM tile (big-endian int16 metres,
+// rows north to south, 1201 or 3601 samples per side).
+func srtmElevation(dir string, lat, lon float64) (float64, error) {
+	tileLat, tileLon := math.Floor(lat), math.Floor(lon)
+	ns, ew := 'N', 'E'
+	if tileLat < 0 {
+		ns = 'S'
+	}
+	if tileLon < 0 {
+		ew = 'W'
+	}
+	name := fmt.Sprintf("%c%02d%c%03d.hgt", ns, int(math.Abs(tileLat)), ew, int(math.Abs(tileLon)))
+
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	samples := int(math.Sqrt(float64(info.Size() / 2)))
+	if samples*samples*2 != int(info.Size()) {
+		return 0, fmt.Errorf("%s: unexpected tile size %d", name, info.Size())
+	}
+
+	row := int(math.Round((1 - (lat - tileLat)) * float64(samples-1)))
+	col := int(math.Round((lon - tileLon) * float64(samples-1)))
+	buf := make([]byte, 2)
+	if _, err := f.ReadAt(buf, int64(row*samples+col)*2); err != nil {
+		return 0, err
+	}
+	v := int16(binary.BigEndian.Uint16(buf))
+	if v == -32768 {
+		return 0, fmt.Errorf("%s: no data at %.4f,%.4f", name, lat, lon)
+	}
+	return float64(v), nil
+}
+
+func apiElevation(lat, lon float64) (float64, error) {
+	base := cfg().Elevation.APIURL
+	if base == "" {
+		base = "https://api.open-meteo.com/v1/elevation"
+	}
+	resp, err := elevationHTTP.Get(fmt.Sprintf("%s?latitude=%.5f&longitude=%.5f", base, lat, lon))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("elevation API returned non-200 status: %s", resp.Status)
+	}
+	var data struct {
+		Elevation []float64 `json:"elevation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, err
+	}
+	if len(data.Elevation) == 0 {
+		return 0, fmt.Errorf("elevation API returned no data")
+	}
+	return data.Elevation[0], nil
+}
+
+// --- Observer ---
+// The receiver site. lat/lon/radius_nm move the radius poll away from the
+// built-in location; they're read at startup, so moving needs a restart.
+//
+// Look angles and "above observer" altitudes are measured from the antenna:
+// elevation_ft plus antenna_height_ft. Without elevation_ft the site's ground
+// is looked up like any other terrain, and failing that taken as sea level.
+type ObserverConfig struct {
+	Name            string   `json:"name"` // tags alerts when there are several locations
+	Lat             *float64 `json:"lat"`
+	Lon             *float64 `json:"lon"`
+	RadiusNM        float64  `json:"radius_nm"`         // radius poll, default 50, at most 250
+	ElevationFT     *float64 `json:"elevation_ft"`      // ground at the site, feet MSL
+	AntennaHeightFT float64  `json:"antenna_height_ft"` // antenna above that ground
+}
+
+// applyObserverLocation points the radius poll at the configured site.
+func applyObserverLocation(c *Config) {
+	oc := c.Observer
+	if oc.Lat == nil || oc.Lon == nil {
+		return
+	}
+	apiLat, apiLng = *oc.Lat, *oc.Lon
+	if oc.RadiusNM > 0 {
+		apiRadiusNM = oc.RadiusNM
+	}
+}
+
+// observerStandInNM is how far out the site's configured elevation stands
+// in for terrain that can't be looked up.
+const observerStandInNM = 25
+
+func observerGroundFT() float64 {
+	if e := cfg().Observer.ElevationFT; e != nil {
+		return *e
+	}
+	ft, err := groundElevationFT(apiLat, apiLng)
+	if err != nil {
+		return 0
+	}
+	return ft
+}
+
+// observerHeightFT is the antenna's height in feet MSL.
+func observerHeightFT() float64 {
+	return observerGroundFT() + cfg().Observer.AntennaHeightFT
+}
+
+// altitudeAboveObserver is the aircraft's height above the antenna.
+func altitudeAboveObserver(ac Aircraft) (float64, bool) {
+	altitudeFT, ok := altitudeFeet(ac)
+	if !ok {
+		return 0, false
+	}
+	return altitudeFT - observerHeightFT(), true
+}
+
+// altitudeAGL returns the aircraft's height above the terrain under it.
+func altitudeAGL(ac Aircraft) (float64, bool) {
+	altitudeFT, ok := altitudeFeet(ac)
+	lat, lon, hasCoords := getActualCoords(ac)
+	if !ok || !hasCoords {
+		return 0, false
+	}
+	ground, err := groundElevationFT(lat, lon)
+	if err != nil {
+		e := cfg().Observer.ElevationFT
+		if e == nil || geo.DistanceNM(apiLat, apiLng, lat, lon) > observerStandInNM {
+			fmt.Printf("[EL] Error looking up terrain for %s: %v\n", ac.Hex, err)
+			return 0, false
+		}
+		ground = *e
+	}
+	return math.Max(0, altitudeFT-ground), true
+}
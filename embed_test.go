@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Golden-file tests for alert embeds: each case sends one alert with a fixed
+// clock and every HTTP request stubbed, and compares the webhook payload
+// with testdata/golden/<name>.json. Run with -update to rewrite them.
+var updateGolden = flag.Bool("update", false, "rewrite the golden files")
+
+const testWebhook = "https://discord.com/api/webhooks/1/test"
+
+type fakeClock struct{ t time.Time }
+
+func (c fakeClock) Now() time.Time { return c.t }
+
+// stubTransport answers webhook posts with 204, records their bodies, and
+// 404s everything else (adsbdb, routes, photos). With fail set webhook
+// posts get a 500 and aren't recorded.
+type stubTransport struct {
+	mu    sync.Mutex
+	posts [][]byte
+	fail  bool
+}
+
+func (s *stubTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	status := http.StatusNotFound
+	if r.Method == http.MethodPost && r.URL.String() == testWebhook && s.fail {
+		status = http.StatusInternalServerError
+	} else if r.Method == http.MethodPost && r.URL.String() == testWebhook {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.posts = append(s.posts, body)
+		s.mu.Unlock()
+		status = http.StatusNoContent
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+func withTestEnv(t *testing.T) *stubTransport {
+	t.Helper()
+	stub := &stubTransport{}
+	prevClock, prevTransport, prevConfig := clock, httpTransport, activeConfig.Load()
+	clock = fakeClock{time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)}
+	httpTransport = stub
+	c := defaultConfig()
+	c.Display.Timezone = "UTC"
+	activeConfig.Store(c)
+	t.Cleanup(func() {
+		clock, httpTransport = prevClock, prevTransport
+		activeConfig.Store(prevConfig)
+	})
+	return stub
+}
+
+func testAircraft() Aircraft {
+	track, rate := 270.0, -640.0
+	return Aircraft{
+		Hex:      "a1b2c3",
+		Flight:   "N123AB  ",
+		NNumber:  "N123AB",
+		Type:     "C172",
+		Squawk:   "1200",
+		AltBaro:  3500.0,
+		GS:       someFloat(110),
+		Track:    &track,
+		BaroRate: &rate,
+		Lat:      someFloat(35.79),
+		Lon:      someFloat(-78.64),
+	}
+}
+
+func TestAlertEmbedGolden(t *testing.T) {
+	cases := []struct {
+		name      string
+		alertType string
+		ac        func() Aircraft
+		details   AircraftDetail
+		actx      *AlertContext
+	}{
+		{
+			name:      "emergency",
+			alertType: "emergency",
+			ac: func() Aircraft {
+				ac := testAircraft()
+				ac.Squawk = "7700"
+				return ac
+			},
+			details: AircraftDetail{Registration: "N123AB", AircraftType: "Cessna 172", Owner: "Private"},
+		},
+		{
+			name:      "proximity",
+			alertType: "proximity",
+			ac:        testAircraft,
+			details:   AircraftDetail{Registration: "N123AB", AircraftType: "Cessna 172"},
+			actx:      &AlertContext{Rule: "low and close", Note: "2.1 nm from home at 3,500 ft"},
+		},
+		{
+			name:      "watchlist",
+			alertType: "watchlist",
+			ac:        testAircraft,
+			details:   AircraftDetail{Registration: "N123AB", Owner: "State Police"},
+			actx: &AlertContext{Entry: &WatchlistEntry{
+				ICAO: "A1B2C3", Registration: "N123AB", Note: "Police helicopter",
+				Category: "Police Forces", Operator: "State Police", CMPG: "Pol",
+			}},
+		},
+		{
+			name:      "special_military",
+			alertType: "special_military",
+			ac: func() Aircraft {
+				ac := testAircraft()
+				ac.Flight, ac.Type, ac.Mil = "IRON11", "E6", true
+				return ac
+			},
+			details: AircraftDetail{Owner: "United States Navy", CountryName: "United States", CountryISO: "US"},
+			actx:    &AlertContext{Title: "☢️ TACAMO: IRON11", Color: 0xff0000, Note: "Seen nationwide"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withTestEnv(t)
+			sendDiscordAlert(testWebhook, tc.ac(), tc.details, tc.alertType, tc.actx)
+			if len(stub.posts) != 1 {
+				t.Fatalf("got %d webhook posts, want 1", len(stub.posts))
+			}
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, stub.posts[0], "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			pretty.WriteByte('\n')
+
+			path := filepath.Join("testdata", "golden", tc.name+".json")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, pretty.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(pretty.Bytes(), want) {
+				t.Errorf("payload differs from %s:\n%s", path, pretty.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// --- Emergency escalation ---
+// A persisting emergency only alerts once per squawk value. With escalation
+// configured, an aircraft still squawking after EscalateAfter is re-alerted
+// as an escalation (optionally repeated), with elapsed time and latest position.
+type EmergencyConfig struct {
+	EscalateAfter     Duration `json:"escalate_after"`     // 0 disables escalation
+	RepeatEvery       Duration `json:"repeat_every"`       // 0 escalates only once per incident
+	EscalationChannel string   `json:"escalation_channel"` // optional extra destination
+	Mention           string   `json:"mention"`            // e.g. "@here", added to escalations
+	DedupPath         string   `json:"dedup_path"`         // default emergency-dedup.json
+	DedupWindow       Duration `json:"dedup_window"`       // squawk gap that starts a new incident, default 30m
+
+	// Codes handled as emergencies (dedup, escalation, "ended" follow-ups).
+	// Default 7500, 7600 and 7700; add e.g. 7400 (lost-link UAS) or a local
+	// SAR code here, or leave one of the defaults out.
+	Squawks []EmergencySquawk `json:"squawks"`
+}
+
+type EmergencySquawk struct {
+	Code    string `json:"code"`
+	Meaning string `json:"meaning"` // shown on the alert; the defaults have their own
+	Title   string `json:"title"`   // default "🔴 EMERGENCY: SQUAWK <code>"
+	Color   int    `json:"color"`   // default red
+	Channel string `json:"channel"` // default the watchlist webhook
+}
+
+var defaultEmergencySquawks = []EmergencySquawk{{Code: "7500"}, {Code: "7600"}, {Code: "7700"}}
+
+func emergencySquawks() []EmergencySquawk {
+	if sq := cfg().Emergency.Squawks; len(sq) > 0 {
+		return sq
+	}
+	return defaultEmergencySquawks
+}
+
+func emergencySquawk(code string) (EmergencySquawk, bool) {
+	for _, sq := range emergencySquawks() {
+		if sq.Code == code {
+			return sq, true
+		}
+	}
+	return EmergencySquawk{}, false
+}
+
+func isEmergencySquawk(squawk string) bool {
+	_, ok := emergencySquawk(squawk)
+	return ok
+}
+
+// emergencyWebhook is where alerts about a code go.
+func emergencyWebhook(code string) string {
+	sq, _ := emergencySquawk(code)
+	if webhook := resolveChannel(sq.Channel); webhook != "" {
+		return webhook
+	}
+	return discordHookWatchlist
+}
+
+// emergencyContext carries a code's title and color onto its alert.
+func emergencyContext(code string) *AlertContext {
+	sq, _ := emergencySquawk(code)
+	if sq.Title == "" && sq.Color == 0 {
+		return nil
+	}
+	return &AlertContext{Title: sq.Title, Color: sq.Color}
+}
+
+func checkEmergencyEscalation(ac Aircraft, state *RadiusAircraftState) {
+	ec := cfg().Emergency
+	if ec.EscalateAfter.Duration <= 0 || state.EmergencySince.IsZero() {
+		return
+	}
+
+	elapsed := since(state.EmergencySince)
+	if elapsed < ec.EscalateAfter.Duration {
+		return
+	}
+	if !state.LastEscalation.IsZero() &&
+		(ec.RepeatEvery.Duration <= 0 || since(state.LastEscalation) < ec.RepeatEvery.Duration) {
+		return
+	}
+
+	fmt.Printf("[Radius] !!! EMERGENCY ESCALATION: %s squawking %s for %v\n", ac.Hex, ac.Squawk, elapsed.Round(time.Second))
+
+	note := fmt.Sprintf("**Squawking %s for %v**", ac.Squawk, elapsed.Round(time.Minute))
+	if lat, lon, ok := getActualCoords(ac); ok {
+		note += fmt.Sprintf("\nLatest position: `%.4f, %.4f`", lat, lon)
+	}
+	actx := &AlertContext{Note: note, Mention: ec.Mention}
+
+	details, _ := getAircraftDetails(ac.Hex)
+	webhook := emergencyWebhook(ac.Squawk)
+	sendDiscordAlert(webhook, ac, details, "emergency_escalation", actx)
+	if extra := resolveChannel(ec.EscalationChannel); extra != "" && extra != webhook {
+		sendDiscordAlert(extra, ac, details, "emergency_escalation", actx)
+	}
+	state.LastEscalation = clock.Now()
+}
+
+// --- Durable dedup ---
+// Each incident is fingerprinted by hex and squawk and kept on disk, so a
+// restart mid-emergency picks the incident up (including its escalation
+// clock) instead of paging again. A different squawk is a different
+// fingerprint, and one not seen for dedup_window is a new incident.
+type emergencyIncident struct {
+	Hex            string    `json:"hex"`
+	Squawk         string    `json:"squawk"`
+	Since          time.Time `json:"since"`
+	LastSeen       time.Time `json:"last_seen"`
+	LastEscalation time.Time `json:"last_escalation,omitempty"`
+}
+
+var (
+	emergencyIncidents      map[string]emergencyIncident // hex|squawk
+	emergencyIncidentsSaved time.Time
+	emergencyIncidentsMutex = &sync.Mutex{}
+)
+
+func (ec EmergencyConfig) dedupPath() string {
+	if ec.DedupPath != "" {
+		return ec.DedupPath
+	}
+	return "emergency-dedup.json"
+}
+
+func (ec EmergencyConfig) dedupWindow() time.Duration {
+	if ec.DedupWindow.Duration > 0 {
+		return ec.DedupWindow.Duration
+	}
+	return 30 * time.Minute
+}
+
+func loadEmergencyIncidentsLocked() {
+	if emergencyIncidents != nil {
+		return
+	}
+	emergencyIncidents = make(map[string]emergencyIncident)
+	data, err := os.ReadFile(cfg().Emergency.dedupPath())
+	if err != nil {
+		return
+	}
+	var list []emergencyIncident
+	if err := json.Unmarshal(data, &list); err != nil {
+		fmt.Printf("[Radius] Error reading emergency dedup file: %v\n", err)
+		return
+	}
+	for _, inc := range list {
+		emergencyIncidents[inc.Hex+"|"+inc.Squawk] = inc
+	}
+}
+
+// ongoingEmergency finds an incident for this hex and squawk that's still
+// within the dedup window, i.e. one that was alerted before a restart.
+func ongoingEmergency(hex, squawk string) (emergencyIncident, bool) {
+	emergencyIncidentsMutex.Lock()
+	defer emergencyIncidentsMutex.Unlock()
+	loadEmergencyIncidentsLocked()
+	inc, ok := emergencyIncidents[hex+"|"+squawk]
+	if !ok || since(inc.LastSeen) > cfg().Emergency.dedupWindow() {
+		return emergencyIncident{}, false
+	}
+	return inc, true
+}
+
+// noteEmergency records that the incident is still going. New incidents
+// and escalations are written straight away, everything else once a minute.
+func noteEmergency(hex, squawk string, state RadiusAircraftState) {
+	emergencyIncidentsMutex.Lock()
+	defer emergencyIncidentsMutex.Unlock()
+	loadEmergencyIncidentsLocked()
+	key := hex + "|" + squawk
+	prev, known := emergencyIncidents[key]
+	now := clock.Now()
+	emergencyIncidents[key] = emergencyIncident{
+		Hex:            hex,
+		Squawk:         squawk,
+		Since:          state.EmergencySince,
+		LastSeen:       now,
+		LastEscalation: state.LastEscalation,
+	}
+	changed := !known || !prev.Since.Equal(state.EmergencySince) || !prev.LastEscalation.Equal(state.LastEscalation)
+	if changed || now.Sub(emergencyIncidentsSaved) >= time.Minute {
+		saveEmergencyIncidentsLocked(now)
+	}
+}
+
+// endEmergency forgets an incident once the aircraft stops squawking, so
+// squawking the code again is a new incident that pages, however soon.
+func endEmergency(hex, squawk string) {
+	emergencyIncidentsMutex.Lock()
+	defer emergencyIncidentsMutex.Unlock()
+	loadEmergencyIncidentsLocked()
+	key := hex + "|" + squawk
+	if _, ok := emergencyIncidents[key]; !ok {
+		return
+	}
+	delete(emergencyIncidents, key)
+	saveEmergencyIncidentsLocked(clock.Now())
+}
+
+func saveEmergencyIncidentsLocked(now time.Time) {
+	window := cfg().Emergency.dedupWindow()
+	list := []emergencyIncident{}
+	for key, inc := range emergencyIncidents {
+		if now.Sub(inc.LastSeen) > window {
+			delete(emergencyIncidents, key)
+			continue
+		}
+		list = append(list, inc)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return
+	}
+	path := cfg().Emergency.dedupPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		fmt.Printf("[Radius] Error saving %s: %v\n", path, err)
+	}
+	emergencyIncidentsSaved = now
+}
+
+// squawkMeaning explains an emergency squawk code, translated.
+func squawkMeaning(squawk string) string {
+	if sq, _ := emergencySquawk(squawk); sq.Meaning != "" {
+		return sq.Meaning
+	}
+	switch squawk {
+	case "7500":
+		return T("Unlawful interference (hijack)")
+	case "7600":
+		return T("Radio failure")
+	case "7700":
+		return T("General emergency")
+	}
+	return ""
+}
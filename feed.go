@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- RSS/Atom feeds of recent alerts ---
+// The last sent alerts are kept in memory and served at /feed.rss and
+// /feed.atom, optionally filtered with ?type=watchlist,emergency.
+type FeedConfig struct {
+	Title    string `json:"title"`
+	BaseURL  string `json:"base_url"`  // public URL of the API, used for self links
+	MaxItems int    `json:"max_items"` // default 100
+}
+
+var (
+	recentAlerts      []AlertRecord
+	recentAlertsMutex = &sync.Mutex{}
+)
+
+func rememberAlert(rec AlertRecord) {
+	if rec.Outcome != "sent" {
+		return
+	}
+	maxItems := cfg().Feed.MaxItems
+	if maxItems <= 0 {
+		maxItems = 100
+	}
+	recentAlertsMutex.Lock()
+	defer recentAlertsMutex.Unlock()
+	recentAlerts = append(recentAlerts, rec)
+	if len(recentAlerts) > maxItems {
+		recentAlerts = recentAlerts[len(recentAlerts)-maxItems:]
+	}
+}
+
+// recentAlertsOfType returns the remembered alerts, newest first. An empty type list matches all.
+func recentAlertsOfType(types []string) []AlertRecord {
+	recentAlertsMutex.Lock()
+	defer recentAlertsMutex.Unlock()
+	var out []AlertRecord
+	for i := len(recentAlerts) - 1; i >= 0; i-- {
+		if len(types) == 0 || containsFold(types, recentAlerts[i].Type) {
+			out = append(out, recentAlerts[i])
+		}
+	}
+	return out
+}
+
+func alertTypesParam(r *http.Request) []string {
+	var types []string
+	for _, v := range r.URL.Query()["type"] {
+		types = append(types, strings.Split(v, ",")...)
+	}
+	return types
+}
+
+func alertTitle(rec AlertRecord) string {
+	label := rec.Registration
+	if label == "" {
+		label = rec.Hex
+	}
+	if rec.Type == "aggregate" {
+		label = fmt.Sprintf("%d aircraft", len(rec.Members))
+	}
+	title := fmt.Sprintf("[%s] %s", rec.Type, label)
+	if rec.Flight != "" {
+		title += " " + rec.Flight
+	}
+	if rec.AircraftType != "" {
+		title += " (" + rec.AircraftType + ")"
+	}
+	return title
+}
+
+func alertSummary(rec AlertRecord) string {
+	parts := []string{}
+	if rec.Rule != "" {
+		parts = append(parts, "Rule: "+rec.Rule)
+	}
+	if rec.Owner != "" {
+		parts = append(parts, "Owner: "+rec.Owner)
+	}
+	if rec.Squawk != "" {
+		parts = append(parts, "Squawk: "+rec.Squawk)
+	}
+	if rec.AltBaro != "" {
+		parts = append(parts, "Altitude: "+fmtRecordAltitude(rec.AltBaro))
+	}
+	if rec.Lat != nil && rec.Lon != nil {
+		parts = append(parts, fmt.Sprintf("Position: %.4f, %.4f", *rec.Lat, *rec.Lon))
+	}
+	if rec.Note != "" {
+		parts = append(parts, rec.Note)
+	}
+	if len(rec.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(rec.Tags, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func alertLink(rec AlertRecord) string {
+	if rec.Hex == "" {
+		return "https://globe.adsb.lol/"
+	}
+	return fmt.Sprintf("https://globe.adsb.lol/?icao=%s", rec.Hex)
+}
+
+func alertGUID(rec AlertRecord) string {
+	return fmt.Sprintf("%s-%s-%d", rec.Type, rec.Hex, rec.Time.UnixNano())
+}
+
+func feedTitle() string {
+	if t := cfg().Feed.Title; t != "" {
+		return t
+	}
+	return "Flight Ingestor Alerts"
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		Description string    `xml:"description"`
+		Items       []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	GUID        struct {
+		Value     string `xml:",chardata"`
+		Permalink bool   `xml:"isPermaLink,attr"`
+	} `xml:"guid"`
+	PubDate  string `xml:"pubDate"`
+	Category string `xml:"category"`
+}
+
+func handleRSSFeed(w http.ResponseWriter, r *http.Request) {
+	var feed rssFeed
+	feed.Version = "2.0"
+	feed.Channel.Title = feedTitle()
+	feed.Channel.Link = cfg().Feed.BaseURL
+	feed.Channel.Description = "Recent aircraft alerts"
+	for _, rec := range recentAlertsOfType(alertTypesParam(r)) {
+		item := rssItem{
+			Title:       alertTitle(rec),
+			Link:        alertLink(rec),
+			Description: alertSummary(rec),
+			PubDate:     rec.Time.Format(time.RFC1123Z),
+			Category:    rec.Type,
+		}
+		item.GUID.Value = alertGUID(rec)
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	writeXML(w, "application/rss+xml", feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title    string   `xml:"title"`
+	ID       string   `xml:"id"`
+	Updated  string   `xml:"updated"`
+	Link     atomLink `xml:"link"`
+	Summary  string   `xml:"summary"`
+	Category struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+func handleAtomFeed(w http.ResponseWriter, r *http.Request) {
+	alerts := recentAlertsOfType(alertTypesParam(r))
+	feed := atomFeed{
+		Title:   feedTitle(),
+		ID:      "urn:flight-ingestor:alerts",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+	feed.Author.Name = "flight-ingestor"
+	if len(alerts) > 0 {
+		feed.Updated = alerts[0].Time.UTC().Format(time.RFC3339)
+	}
+	if base := cfg().Feed.BaseURL; base != "" {
+		feed.Links = append(feed.Links, atomLink{Href: strings.TrimSuffix(base, "/") + r.URL.RequestURI(), Rel: "self"})
+	}
+	for _, rec := range alerts {
+		entry := atomEntry{
+			Title:   alertTitle(rec),
+			ID:      "urn:flight-ingestor:alert:" + alertGUID(rec),
+			Updated: rec.Time.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: alertLink(rec)},
+			Summary: alertSummary(rec),
+		}
+		entry.Category.Term = rec.Type
+		feed.Entries = append(feed.Entries, entry)
+	}
+	writeXML(w, "application/atom+xml", feed)
+}
+
+func writeXML(w http.ResponseWriter, contentType string, v any) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(v)
+}
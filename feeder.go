@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --- Local feeder health ---
+// With a local receiver (readsb/dump1090 polled at feeder.url, or pushing to
+// /ingest) we watch its message rate and aircraft count, and tell the ops
+// channel when the feed stalls or falls well below its recent level: an
+// unplugged antenna or a crashed decoder shows up here long before anyone
+// notices the alerts went quiet.
+type FeederConfig struct {
+	URL         string   `json:"url"`          // e.g. "http://piaware/skyaware/data/aircraft.json"
+	Interval    Duration `json:"interval"`     // default 30s
+	StallAfter  Duration `json:"stall_after"`  // no messages for this long, default 5m
+	DropPercent float64  `json:"drop_percent"` // alert when the rate falls this far below its baseline, default 70
+}
+
+// FeederHealth is reported at /status.
+type FeederHealth struct {
+	LastSample  *time.Time `json:"last_sample,omitempty"`
+	MessageRate float64    `json:"message_rate"` // messages/s over the last sample interval
+	Baseline    float64    `json:"baseline_rate"`
+	Aircraft    int        `json:"aircraft"`
+	Stalled     bool       `json:"stalled"`
+	Degraded    bool       `json:"degraded"`
+
+	lastMessages int64
+	lastNow      float64
+	lastMsgAt    time.Time
+	lowSamples   int
+}
+
+const (
+	feederBaselineAlpha = 0.02 // ~25 minute memory at 30s samples
+	feederMinBaseline   = 5    // msg/s; below this a drop is just quiet sky
+	feederDropSamples   = 3    // consecutive low samples before alerting
+)
+
+var (
+	feeder      FeederHealth
+	feederMutex = &sync.Mutex{}
+)
+
+func (fc FeederConfig) stallAfter() time.Duration {
+	if fc.StallAfter.Duration > 0 {
+		return fc.StallAfter.Duration
+	}
+	return 5 * time.Minute
+}
+
+// manageFeeder polls the local receiver and checks for stalls, including
+// when samples stop arriving from a pushing receiver.
+func manageFeeder() {
+	for {
+		fc := cfg().Feeder
+		interval := fc.Interval.Duration
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		if fc.URL != "" {
+			if err := pollFeeder(fc.URL); err != nil {
+				fmt.Printf("[FD] Error polling feeder: %v\n", err)
+			}
+		}
+		checkFeederStall(fc)
+		time.Sleep(interval)
+	}
+}
+
+func pollFeeder(url string) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("feeder returned non-200 status: %s", resp.Status)
+	}
+	var data feederCounters
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return err
+	}
+	noteFeederSample(data)
+	return nil
+}
+
+// feederCounters are the fields of readsb/dump1090 aircraft.json we need.
+type feederCounters struct {
+	Now      float64           `json:"now"`
+	Messages int64             `json:"messages"`
+	Aircraft []json.RawMessage `json:"aircraft"`
+}
+
+func noteFeederSample(c feederCounters) {
+	if c.Now == 0 {
+		return // not from a receiver, e.g. an adsb.lol-shaped push
+	}
+	fc := cfg().Feeder
+	drop := fc.DropPercent
+	if drop <= 0 {
+		drop = 70
+	}
+	now := clock.Now()
+
+	feederMutex.Lock()
+	f := &feeder
+	f.LastSample, f.Aircraft = &now, len(c.Aircraft)
+	if f.lastNow > 0 && c.Now > f.lastNow && c.Messages >= f.lastMessages {
+		f.MessageRate = float64(c.Messages-f.lastMessages) / (c.Now - f.lastNow)
+	}
+	if c.Messages != f.lastMessages || f.lastMsgAt.IsZero() {
+		f.lastMsgAt = now
+	}
+	f.lastMessages, f.lastNow = c.Messages, c.Now
+
+	var degraded, recovered bool
+	low := f.Baseline >= feederMinBaseline && f.MessageRate < f.Baseline*(1-drop/100)
+	if low {
+		f.lowSamples++
+		if f.lowSamples >= feederDropSamples && !f.Degraded {
+			f.Degraded, degraded = true, true
+		}
+	} else {
+		f.lowSamples = 0
+		recovered = f.Degraded
+		f.Degraded = false
+		// Only learn from healthy samples so a dead feed doesn't become the norm
+		if f.Baseline == 0 {
+			f.Baseline = f.MessageRate
+		} else {
+			f.Baseline += feederBaselineAlpha * (f.MessageRate - f.Baseline)
+		}
+	}
+	rate, baseline, aircraft := f.MessageRate, f.Baseline, f.Aircraft
+	feederMutex.Unlock()
+
+	if degraded {
+		fmt.Printf("[FD] Feeder message rate dropped to %.0f/s (baseline %.0f/s).\n", rate, baseline)
+		notifyOps("📉 Feeder Degraded", fmt.Sprintf("The local receiver is decoding **%.0f msg/s** against a recent baseline of %.0f msg/s (%d aircraft). Check the antenna, cabling and gain.", rate, baseline, aircraft), 16753920)
+	}
+	if recovered {
+		fmt.Println("[FD] Feeder message rate is back to normal.")
+		notifyOps("✅ Feeder Recovered", fmt.Sprintf("The local receiver is back to %.0f msg/s (%d aircraft).", rate, aircraft), 5763719)
+	}
+}
+
+func checkFeederStall(fc FeederConfig) {
+	feederMutex.Lock()
+	f := &feeder
+	if f.lastMsgAt.IsZero() {
+		feederMutex.Unlock()
+		return // no local receiver
+	}
+	silent := since(f.lastMsgAt)
+	var stalled, recovered bool
+	if silent >= fc.stallAfter() {
+		if !f.Stalled {
+			f.Stalled, stalled = true, true
+		}
+		f.MessageRate = 0
+	} else if f.Stalled {
+		f.Stalled, recovered = false, true
+	}
+	feederMutex.Unlock()
+
+	if stalled {
+		fmt.Printf("[FD] No messages from the feeder for %v.\n", silent.Round(time.Second))
+		notifyOps("📡 Feeder Stalled", fmt.Sprintf("No messages from the local receiver for **%v**. The decoder may have crashed or the SDR dropped off USB.", silent.Round(time.Minute)), 16711680)
+	}
+	if recovered {
+		fmt.Println("[FD] Feeder is receiving messages again.")
+		notifyOps("✅ Feeder Recovered", "The local receiver is decoding messages again.", 5763719)
+	}
+}
+
+func feederSnapshot() *FeederHealth {
+	feederMutex.Lock()
+	defer feederMutex.Unlock()
+	if feeder.LastSample == nil {
+		return nil
+	}
+	snap := feeder
+	return &snap
+}
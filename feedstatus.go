@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Feeder status integrations ---
+// Checks the status pages of the feeder clients running alongside the
+// receiver, so one tool answers "is everything feeding?". Results show up in
+// /status and the daily digest, and the ops channel hears about changes.
+type FeedStatusConfig struct {
+	PiAware  string   `json:"piaware"`  // PiAware status.json, e.g. "http://piaware/status.json"
+	FR24     string   `json:"fr24"`     // fr24feed monitor.json, e.g. "http://piaware:8754/monitor.json"
+	ADSBLol  bool     `json:"adsb_lol"` // ask adsb.lol whether it's receiving from this IP
+	Interval Duration `json:"interval"` // default 5m
+}
+
+const adsbLolMeURL = "https://api.adsb.lol/api/0/me"
+
+type FeedStatus struct {
+	Name      string    `json:"name"`
+	OK        bool      `json:"ok"`
+	Detail    string    `json:"detail"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var (
+	feedStatuses     = make(map[string]FeedStatus)
+	feedStatusMutex  = &sync.Mutex{}
+	feedStatusClient = newHTTPClient(15 * time.Second)
+)
+
+func manageFeedStatus() {
+	for {
+		fs := cfg().FeedStatus
+		interval := fs.Interval.Duration
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		if fs.PiAware != "" {
+			updateFeedStatus(checkPiAware(fs.PiAware))
+		}
+		if fs.FR24 != "" {
+			updateFeedStatus(checkFR24(fs.FR24))
+		}
+		if fs.ADSBLol && !offlineMode() {
+			updateFeedStatus(checkADSBLol())
+		}
+		time.Sleep(interval)
+	}
+}
+
+func updateFeedStatus(s FeedStatus) {
+	s.CheckedAt = time.Now()
+	feedStatusMutex.Lock()
+	prev, seen := feedStatuses[s.Name]
+	feedStatuses[s.Name] = s
+	feedStatusMutex.Unlock()
+
+	if !seen && s.OK || seen && prev.OK == s.OK {
+		return
+	}
+	if s.OK {
+		fmt.Printf("[FS] %s is feeding again.\n", s.Name)
+		notifyOps(fmt.Sprintf("✅ %s Feeding", s.Name), s.Detail, 5763719)
+	} else {
+		fmt.Printf("[FS] %s reports a problem: %s\n", s.Name, s.Detail)
+		notifyOps(fmt.Sprintf("⚠️ %s Problem", s.Name), s.Detail, 16753920)
+	}
+}
+
+func getStatusJSON(url string, out any) error {
+	resp, err := feedStatusClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 status: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// PiAware reports each component as green/amber/red with a message.
+func checkPiAware(url string) FeedStatus {
+	var body map[string]json.RawMessage
+	if err := getStatusJSON(url, &body); err != nil {
+		return FeedStatus{Name: "PiAware", Detail: err.Error()}
+	}
+	status := FeedStatus{Name: "PiAware", OK: true}
+	var problems, all []string
+	for _, component := range []string{"piaware", "adept", "radio", "mlat"} {
+		var c struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		if raw, ok := body[component]; !ok || json.Unmarshal(raw, &c) != nil {
+			continue
+		}
+		all = append(all, c.Message)
+		// mlat is amber while it syncs; only red counts there
+		if c.Status == "red" || c.Status == "amber" && component != "mlat" {
+			status.OK = false
+			problems = append(problems, c.Message)
+		}
+	}
+	if status.OK {
+		status.Detail = strings.Join(all, "; ")
+	} else {
+		status.Detail = strings.Join(problems, "; ")
+	}
+	return status
+}
+
+func checkFR24(url string) FeedStatus {
+	var body struct {
+		FeedStatus  string `json:"feed_status"`
+		FeedAlias   string `json:"feed_alias"`
+		RxConnected string `json:"rx_connected"`
+		Tracked     string `json:"feed_num_ac_tracked"`
+	}
+	if err := getStatusJSON(url, &body); err != nil {
+		return FeedStatus{Name: "FR24", Detail: err.Error()}
+	}
+	status := FeedStatus{
+		Name:   "FR24",
+		OK:     body.FeedStatus == "connected" && body.RxConnected == "1",
+		Detail: fmt.Sprintf("%s: feed %s, receiver connected=%s, %s aircraft tracked", body.FeedAlias, body.FeedStatus, body.RxConnected, body.Tracked),
+	}
+	return status
+}
+
+// adsb.lol identifies feeders by source IP, so this only works from the
+// network the feeder sends from.
+func checkADSBLol() FeedStatus {
+	var body struct {
+		Clients struct {
+			Beast []json.RawMessage `json:"beast"`
+			MLAT  []json.RawMessage `json:"mlat"`
+		} `json:"clients"`
+	}
+	if err := getStatusJSON(adsbLolMeURL, &body); err != nil {
+		return FeedStatus{Name: "adsb.lol", Detail: err.Error()}
+	}
+	return FeedStatus{
+		Name:   "adsb.lol",
+		OK:     len(body.Clients.Beast) > 0,
+		Detail: fmt.Sprintf("%d beast and %d mlat connections from this IP", len(body.Clients.Beast), len(body.Clients.MLAT)),
+	}
+}
+
+func feedStatusSnapshot() []FeedStatus {
+	feedStatusMutex.Lock()
+	defer feedStatusMutex.Unlock()
+	var out []FeedStatus
+	for _, name := range []string{"PiAware", "FR24", "adsb.lol"} {
+		if s, ok := feedStatuses[name]; ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
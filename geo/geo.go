@@ -0,0 +1,96 @@
+// Package geo holds the spherical-earth geometry used by the ingestor:
+// distances, bearings, projections and polygon tests. Coordinates are
+// decimal degrees, distances nautical miles, bearings degrees true.
+package geo
+
+import "math"
+
+// EarthRadiusNM is the mean Earth radius.
+const EarthRadiusNM = 3440.065
+
+const rad = math.Pi / 180
+
+// DistanceNM is the great-circle (haversine) distance between two points.
+func DistanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	radLat1, radLat2 := lat1*rad, lat2*rad
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(radLat1)*math.Cos(radLat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * math.Asin(math.Sqrt(math.Min(1, a))) * EarthRadiusNM
+}
+
+// InitialBearing is the true course (0-360) leaving point 1 for point 2.
+func InitialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	radLat1, radLat2 := lat1*rad, lat2*rad
+	dLon := (lon2 - lon1) * rad
+	y := math.Sin(dLon) * math.Cos(radLat2)
+	x := math.Cos(radLat1)*math.Sin(radLat2) - math.Sin(radLat1)*math.Cos(radLat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)/rad+360, 360)
+}
+
+// Destination moves a point distNM along an initial bearing on a great
+// circle. Longitude is normalised to -180..180.
+func Destination(lat, lon, bearing, distNM float64) (float64, float64) {
+	d := distNM / EarthRadiusNM
+	latR, lonR, brg := lat*rad, lon*rad, bearing*rad
+	lat2 := math.Asin(math.Sin(latR)*math.Cos(d) + math.Cos(latR)*math.Sin(d)*math.Cos(brg))
+	lon2 := lonR + math.Atan2(math.Sin(brg)*math.Sin(d)*math.Cos(latR), math.Cos(d)-math.Sin(latR)*math.Sin(lat2))
+	return lat2 / rad, math.Mod(lon2/rad+540, 360) - 180
+}
+
+// CrossTrackNM is how far point P lies off the great circle from point 1
+// through point 2. Positive is right of the path, negative left.
+func CrossTrackNM(lat1, lon1, lat2, lon2, latP, lonP float64) float64 {
+	d13 := DistanceNM(lat1, lon1, latP, lonP) / EarthRadiusNM
+	theta13 := InitialBearing(lat1, lon1, latP, lonP) * rad
+	theta12 := InitialBearing(lat1, lon1, lat2, lon2) * rad
+	return math.Asin(math.Sin(d13)*math.Sin(theta13-theta12)) * EarthRadiusNM
+}
+
+// AlongTrackNM is the distance from point 1, along the path towards point 2,
+// to the point on the path closest to P. Negative when P is behind point 1.
+func AlongTrackNM(lat1, lon1, lat2, lon2, latP, lonP float64) float64 {
+	d13 := DistanceNM(lat1, lon1, latP, lonP) / EarthRadiusNM
+	xt := CrossTrackNM(lat1, lon1, lat2, lon2, latP, lonP) / EarthRadiusNM
+	at := math.Acos(math.Max(-1, math.Min(1, math.Cos(d13)/math.Cos(xt))))
+	theta13 := InitialBearing(lat1, lon1, latP, lonP) * rad
+	theta12 := InitialBearing(lat1, lon1, lat2, lon2) * rad
+	if math.Cos(theta13-theta12) < 0 {
+		at = -at
+	}
+	return at * EarthRadiusNM
+}
+
+// AngleDiff is the absolute difference between two headings (0-180).
+func AngleDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// Point is a [lat, lon] pair, the order used in config files.
+type Point [2]float64
+
+// Polygon is a ring of vertices, implicitly closed.
+type Polygon []Point
+
+// Contains uses ray casting on a flat lat/lon plane, which is accurate enough
+// for state-sized areas that don't cross the antimeridian.
+func (p Polygon) Contains(lat, lon float64) bool {
+	if len(p) < 3 {
+		return false
+	}
+	inside := false
+	j := len(p) - 1
+	for i := range p {
+		latI, lonI := p[i][0], p[i][1]
+		latJ, lonJ := p[j][0], p[j][1]
+		if (lonI > lon) != (lonJ > lon) &&
+			lat < (latJ-latI)*(lon-lonI)/(lonJ-lonI)+latI {
+			inside = !inside
+		}
+		j = i
+	}
+	return inside
+}
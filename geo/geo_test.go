@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceNM(t *testing.T) {
+	// Published great-circle distances are on the WGS84 ellipsoid; the
+	// sphere is within half a percent of them.
+	cases := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"JFK-LAX", 40.6398, -73.7789, 33.9425, -118.4081, 2145},
+		{"LHR-JFK", 51.4700, -0.4543, 40.6398, -73.7789, 2999},
+		{"RDU-DCA", 35.8776, -78.7875, 38.8512, -77.0402, 197},
+		{"SYD-MEL", -33.9461, 151.1772, -37.6690, 144.8410, 379},
+		{"same point", 35.79, -78.64, 35.79, -78.64, 0},
+		{"one degree of latitude", 10, 20, 11, 20, 60.04},
+	}
+	for _, c := range cases {
+		got := DistanceNM(c.lat1, c.lon1, c.lat2, c.lon2)
+		if math.Abs(got-c.want) > 0.005*c.want+0.01 {
+			t.Errorf("%s: DistanceNM = %.1f, want %.1f", c.name, got, c.want)
+		}
+		if back := DistanceNM(c.lat2, c.lon2, c.lat1, c.lon1); math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: distance is not symmetric: %v vs %v", c.name, got, back)
+		}
+	}
+}
+
+func TestInitialBearing(t *testing.T) {
+	cases := []struct {
+		name       string
+		lat2, lon2 float64
+		want       float64
+	}{
+		{"north", 1, 0, 0},
+		{"east", 0, 1, 90},
+		{"south", -1, 0, 180},
+		{"west", 0, -1, 270},
+	}
+	for _, c := range cases {
+		if got := InitialBearing(0, 0, c.lat2, c.lon2); AngleDiff(got, c.want) > 1e-9 {
+			t.Errorf("%s: InitialBearing = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDestinationRoundTrip(t *testing.T) {
+	cases := []struct {
+		lat, lon, bearing, dist float64
+	}{
+		{35.79, -78.64, 0, 10},
+		{35.79, -78.64, 45, 120},
+		{35.79, -78.64, 200, 500},
+		{-33.95, 151.18, 315, 250},
+		{51.47, -0.45, 270, 3000},
+		{10, 179.5, 90, 100}, // crosses the antimeridian
+	}
+	for _, c := range cases {
+		lat2, lon2 := Destination(c.lat, c.lon, c.bearing, c.dist)
+		if lon2 < -180 || lon2 > 180 {
+			t.Errorf("Destination(%v, %v, %v, %v) longitude %v not normalised", c.lat, c.lon, c.bearing, c.dist, lon2)
+		}
+		if d := DistanceNM(c.lat, c.lon, lat2, lon2); math.Abs(d-c.dist) > 1e-6 {
+			t.Errorf("Destination(%v, %v, %v, %v): distance back is %v", c.lat, c.lon, c.bearing, c.dist, d)
+		}
+		if b := InitialBearing(c.lat, c.lon, lat2, lon2); AngleDiff(b, c.bearing) > 1e-6 {
+			t.Errorf("Destination(%v, %v, %v, %v): bearing back is %v", c.lat, c.lon, c.bearing, c.dist, b)
+		}
+	}
+}
+
+// predictOverhead takes the absolute cross-track distance and treats a
+// positive along-track distance as "ahead", so the signs matter.
+func TestTrackSigns(t *testing.T) {
+	// Path from the origin heading due north
+	lat1, lon1 := 0.0, 0.0
+	lat2, lon2 := Destination(lat1, lon1, 0, 10)
+
+	cases := []struct {
+		name           string
+		bearing, dist  float64
+		wantXT, wantAT float64
+	}{
+		{"ahead on the path", 0, 20, 0, 20},
+		{"behind on the path", 180, 20, 0, -20},
+		{"right of the path", 90, 5, 5, 0},
+		{"left of the path", 270, 5, -5, 0},
+		{"ahead and right", 45, 10, 10 * math.Sin(math.Pi/4), 10 * math.Cos(math.Pi/4)},
+		{"behind and left", 225, 10, -10 * math.Sin(math.Pi/4), -10 * math.Cos(math.Pi/4)},
+	}
+	for _, c := range cases {
+		latP, lonP := Destination(lat1, lon1, c.bearing, c.dist)
+		xt := CrossTrackNM(lat1, lon1, lat2, lon2, latP, lonP)
+		at := AlongTrackNM(lat1, lon1, lat2, lon2, latP, lonP)
+		if math.Abs(xt-c.wantXT) > 0.01 {
+			t.Errorf("%s: CrossTrackNM = %.3f, want %.3f", c.name, xt, c.wantXT)
+		}
+		if math.Abs(at-c.wantAT) > 0.01 {
+			t.Errorf("%s: AlongTrackNM = %.3f, want %.3f", c.name, at, c.wantAT)
+		}
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	square := Polygon{{35, -79}, {35, -78}, {36, -78}, {36, -79}}
+	// An L shape: the 1x1 square at the top right is cut out
+	concave := Polygon{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+
+	cases := []struct {
+		name     string
+		poly     Polygon
+		lat, lon float64
+		want     bool
+	}{
+		{"square centre", square, 35.5, -78.5, true},
+		{"square outside", square, 37, -78.5, false},
+		{"just inside the east edge", square, 35.5, -78.000001, true},
+		{"just outside the east edge", square, 35.5, -77.999999, false},
+		{"just inside the north edge", square, 35.999999, -78.5, true},
+		{"just outside the north edge", square, 36.000001, -78.5, false},
+		{"level with a vertex, inside", square, 35.5, -79 + 1e-9, true},
+		{"level with a vertex, outside", square, 35, -80, false},
+		{"concave, lower arm", concave, 0.5, 1.5, true},
+		{"concave, upper arm", concave, 1.5, 0.5, true},
+		{"concave, in the notch", concave, 1.5, 1.5, false},
+		{"concave, in line with the notch corner", concave, 0.5, 1, true},
+		{"concave, beyond the notch corner", concave, 2.5, 1, false},
+		{"too few vertices", Polygon{{0, 0}, {1, 1}}, 0.5, 0.5, false},
+		{"empty", nil, 0, 0, false},
+	}
+	for _, c := range cases {
+		if got := c.poly.Contains(c.lat, c.lon); got != c.want {
+			t.Errorf("%s: Contains(%v, %v) = %v, want %v", c.name, c.lat, c.lon, got, c.want)
+		}
+	}
+}
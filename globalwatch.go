@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Global watch ---
+// Specific airframes to look for anywhere in the world, by hex or
+// registration, polled one by one against the adsb.lol lookup endpoints on
+// their own schedule. Alerts once when the aircraft shows up, and again only
+// after it has been gone for global_watch.gap.
+type GlobalWatchConfig struct {
+	Aircraft []GlobalWatch `json:"aircraft"`
+	Interval Duration      `json:"interval"` // per full pass, default 5m
+	Gap      Duration      `json:"gap"`      // default 2h
+	Channel  string        `json:"channel"`
+}
+
+type GlobalWatch struct {
+	Hex          string   `json:"hex"`
+	Registration string   `json:"registration"`
+	Note         string   `json:"note"`
+	Tags         []string `json:"tags"`
+}
+
+func (g GlobalWatch) key() string {
+	if g.Hex != "" {
+		return strings.ToLower(g.Hex)
+	}
+	return strings.ToUpper(g.Registration)
+}
+
+func (g GlobalWatch) path() string {
+	if g.Hex != "" {
+		return "hex/" + url.PathEscape(strings.ToLower(g.Hex))
+	}
+	return "reg/" + url.PathEscape(strings.ToUpper(g.Registration))
+}
+
+var (
+	globalWatchSeen  = make(map[string]time.Time) // key -> last seen airborne
+	globalWatchMutex = &sync.Mutex{}
+)
+
+func manageGlobalWatch() {
+	for {
+		gc := cfg().GlobalWatch
+		interval := gc.Interval.Duration
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		if len(gc.Aircraft) == 0 || offlineMode() {
+			time.Sleep(interval)
+			continue
+		}
+		// Spread the lookups over the interval rather than bursting them
+		pause := interval / time.Duration(len(gc.Aircraft))
+		for _, watch := range gc.Aircraft {
+			checkGlobalWatch(watch, gc)
+			time.Sleep(pause)
+		}
+	}
+}
+
+func checkGlobalWatch(watch GlobalWatch, gc GlobalWatchConfig) {
+	if watch.key() == "" {
+		return
+	}
+	aircraft, err := fetchADSBLol(watch.path())
+	if err != nil {
+		fmt.Printf("[GW] Error looking up %s: %v\n", watch.key(), err)
+		return
+	}
+	if len(aircraft) == 0 {
+		return
+	}
+	ac := aircraft[0]
+	if isBlocked(ac) {
+		return
+	}
+	gap := gc.Gap.Duration
+	if gap <= 0 {
+		gap = 2 * time.Hour
+	}
+
+	globalWatchMutex.Lock()
+	last, seen := globalWatchSeen[watch.key()]
+	globalWatchSeen[watch.key()] = clock.Now()
+	globalWatchMutex.Unlock()
+	if seen && since(last) < gap {
+		return
+	}
+	if !ruleActive("global_watch", cfg().Schedules["global_watch"], clock.Now()) {
+		return
+	}
+
+	fmt.Printf("[GW] !!! GLOBAL WATCH: %s is up\n", watch.key())
+	details, _ := getAircraftDetails(ac.Hex)
+	note := watch.Note
+	if lat, lon, ok := getActualCoords(ac); ok {
+		where := fmt.Sprintf("At %.3f, %.3f, %s from the station", lat, lon, fmtDistance(geo.DistanceNM(apiLat, apiLng, lat, lon)))
+		note = strings.TrimSpace(note + "\n" + where)
+	}
+	sendDiscordAlert(resolveChannel(gc.Channel), ac, details, "global_watch", &AlertContext{
+		Rule: watch.key(),
+		Note: note,
+		Tags: watch.Tags,
+	})
+}
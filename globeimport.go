@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- CLI: import globe-history ---
+// Seeds the history database from readsb/tar1090 globe_history archives
+// (globe_history/YYYY/MM/DD/traces/xx/trace_full_<hex>.json, usually
+// gzipped). Each trace is split into flights the same way live sightings are.
+//
+//	flight-ingestor import globe-history /var/globe_history
+//	flight-ingestor import globe-history --radius-nm 50 --sightings /var/globe_history/2026/10
+type globeTrace struct {
+	ICAO      string  `json:"icao"`
+	Reg       string  `json:"r"`
+	Type      string  `json:"t"`
+	Timestamp float64 `json:"timestamp"`
+	Trace     [][]any `json:"trace"`
+}
+
+type importedFlight struct {
+	flight    string
+	first     time.Time
+	last      time.Time
+	points    int
+	minAlt    *float64
+	maxAlt    *float64
+	maxGS     float64
+	closestNM *float64
+	closeLat  float64
+	closeLon  float64
+}
+
+func runGlobeImport(args []string) int {
+	fset := flag.NewFlagSet("import globe-history", flag.ContinueOnError)
+	radius := fset.Float64("radius-nm", apiRadiusNM, "only import positions within this distance of the observer (0 = everything)")
+	sightings := fset.Bool("sightings", false, "also insert every trace point into aircraft_sightings")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	if fset.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import globe-history [--radius-nm NM] [--sightings] DIR")
+		return 2
+	}
+	initHistoryDB()
+	if historyDB == nil {
+		fmt.Fprintln(os.Stderr, "error: history database is not configured (history.database_url or DATABASE_URL)")
+		return 1
+	}
+
+	var files, flights int
+	err := filepath.WalkDir(fset.Arg(0), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasPrefix(d.Name(), "trace_full_") {
+			return err
+		}
+		trace, err := readGlobeTrace(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", path, err)
+			return nil
+		}
+		n, err := importGlobeTrace(trace, *radius, *sightings)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		files++
+		flights += n
+		if files%500 == 0 {
+			fmt.Printf("[IMP] %d traces, %d flights so far...\n", files, flights)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("[IMP] Imported %d flights from %d traces.\n", flights, files)
+	return 0
+}
+
+func readGlobeTrace(path string) (globeTrace, error) {
+	var trace globeTrace
+	f, err := os.Open(path)
+	if err != nil {
+		return trace, err
+	}
+	defer f.Close()
+
+	// readsb gzips traces without always using a .gz extension
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return trace, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	err = json.NewDecoder(r).Decode(&trace)
+	return trace, err
+}
+
+// importGlobeTrace writes the flights in one trace. Trace points are
+// [seconds after timestamp, lat, lon, altitude|"ground"|null, ground speed, track, flags, vertical rate, {aircraft}|null, ...].
+func importGlobeTrace(trace globeTrace, radiusNM float64, withSightings bool) (int, error) {
+	hex := strings.ToLower(strings.TrimPrefix(trace.ICAO, "~"))
+	if hex == "" {
+		return 0, nil
+	}
+	base := time.Unix(0, int64(trace.Timestamp*float64(time.Second)))
+	gap := flightGap()
+
+	var flights []*importedFlight
+	var current *importedFlight
+	callsign := ""
+	for _, point := range trace.Trace {
+		if len(point) < 5 {
+			continue
+		}
+		offset, _ := point[0].(float64)
+		lat, _ := point[1].(float64)
+		lon, _ := point[2].(float64)
+		gs, _ := point[4].(float64)
+		t := base.Add(time.Duration(offset * float64(time.Second)))
+		if len(point) > 8 {
+			if details, ok := point[8].(map[string]any); ok {
+				if flight, ok := details["flight"].(string); ok && strings.TrimSpace(flight) != "" {
+					callsign = strings.TrimSpace(flight)
+				}
+			}
+		}
+
+		dist := geo.DistanceNM(apiLat, apiLng, lat, lon)
+		if radiusNM > 0 && dist > radiusNM {
+			continue
+		}
+		if current == nil || t.Sub(current.last) > gap {
+			current = &importedFlight{first: t}
+			flights = append(flights, current)
+		}
+		current.last = t
+		current.points++
+		if callsign != "" {
+			current.flight = callsign
+		}
+		alt := point[3]
+		if s, ok := alt.(string); ok && s == "ground" {
+			alt = 0.0
+		}
+		if a, ok := alt.(float64); ok {
+			a = math.Round(a)
+			if current.minAlt == nil || a < *current.minAlt {
+				current.minAlt = &a
+			}
+			if current.maxAlt == nil || a > *current.maxAlt {
+				current.maxAlt = &a
+			}
+		}
+		if gs > current.maxGS {
+			current.maxGS = gs
+		}
+		if current.closestNM == nil || dist < *current.closestNM {
+			d := dist
+			current.closestNM, current.closeLat, current.closeLon = &d, lat, lon
+		}
+
+		if withSightings {
+			// A trace point already logged for this hex is skipped, so re-running
+			// the import doesn't inflate sighting counts and rarity scores
+			// (seen_at is written verbatim from the trace).
+			_, err := historyDB.Exec(`INSERT INTO aircraft_sightings (seen_at, hex, flight, n_number, alt_baro, gs, lat, lon)
+				SELECT $1, $2, $3, $4, $5, $6, $7, $8
+				WHERE NOT EXISTS (SELECT 1 FROM aircraft_sightings WHERE hex = $2 AND seen_at = $1)`,
+				t, hex, callsign, trace.Reg, formatAltitudeString(point[3]), gs, lat, lon)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	imported := 0
+	for _, f := range flights {
+		// Re-running the import over the same archive doesn't duplicate flights
+		res, err := historyDB.Exec(`INSERT INTO flights (hex, flight, registration, aircraft_type, first_seen, last_seen,
+				sightings, min_alt_ft, max_alt_ft, max_gs, closest_nm, closest_lat, closest_lon)
+			SELECT $1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13
+			WHERE NOT EXISTS (SELECT 1 FROM flights WHERE hex = $1 AND first_seen = $5)`,
+			hex, f.flight, trace.Reg, trace.Type, f.first, f.last, f.points, f.minAlt, f.maxAlt, f.maxGS, f.closestNM, f.closeLat, f.closeLon)
+		if err != nil {
+			return imported, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			imported++
+		}
+	}
+	return imported, nil
+}
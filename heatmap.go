@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/http"
+	"time"
+)
+
+// --- Traffic density heatmap ---
+// Bins the raw positions in aircraft_sightings into a square grid around the
+// observer. Served as GeoJSON (GET /heatmap.geojson) and as a PNG
+// (GET /heatmap.png), and attached to the weekly leaderboard post.
+type HeatmapConfig struct {
+	CellNM   float64  `json:"cell_nm"`   // grid resolution, default 1
+	RadiusNM float64  `json:"radius_nm"` // default: the poll radius
+	Window   Duration `json:"window"`    // default 7 days
+	Size     int      `json:"size"`      // PNG width/height in pixels, default 800
+	Weekly   bool     `json:"weekly"`    // attach to the weekly leaderboard post
+}
+
+type heatmapGrid struct {
+	cells   int // per side
+	dLat    float64
+	dLon    float64
+	minLat  float64
+	minLon  float64
+	counts  [][]int
+	maxHits int
+	since   time.Time
+}
+
+func (hc HeatmapConfig) withDefaults() HeatmapConfig {
+	if hc.CellNM <= 0 {
+		hc.CellNM = 1
+	}
+	if hc.RadiusNM <= 0 {
+		hc.RadiusNM = apiRadiusNM
+	}
+	if hc.Window.Duration <= 0 {
+		hc.Window.Duration = 7 * 24 * time.Hour
+	}
+	if hc.Size <= 0 {
+		hc.Size = 800
+	}
+	return hc
+}
+
+func buildHeatmap() (*heatmapGrid, error) {
+	if historyDB == nil {
+		return nil, fmt.Errorf("history database is not configured")
+	}
+	hc := cfg().Heatmap.withDefaults()
+	cells := int(math.Ceil(2 * hc.RadiusNM / hc.CellNM))
+	g := &heatmapGrid{
+		cells: cells,
+		dLat:  hc.CellNM / 60,
+		dLon:  hc.CellNM / (60 * math.Cos(apiLat*math.Pi/180)),
+		since: time.Now().Add(-hc.Window.Duration),
+	}
+	g.minLat = apiLat - float64(cells)/2*g.dLat
+	g.minLon = apiLng - float64(cells)/2*g.dLon
+	g.counts = make([][]int, cells)
+	for i := range g.counts {
+		g.counts[i] = make([]int, cells)
+	}
+
+	rows, err := historyDB.Query(`SELECT floor((lat - $1) / $2)::int AS y, floor((lon - $3) / $4)::int AS x, COUNT(*)
+		FROM aircraft_sightings
+		WHERE seen_at >= $5 AND lat IS NOT NULL AND lon IS NOT NULL
+			AND lat >= $1 AND lat < $1 + $2 * $6 AND lon >= $3 AND lon < $3 + $4 * $6
+		GROUP BY 1, 2`, g.minLat, g.dLat, g.minLon, g.dLon, g.since, cells)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var x, y, n int
+		if err := rows.Scan(&y, &x, &n); err != nil {
+			return nil, err
+		}
+		if x < 0 || y < 0 || x >= cells || y >= cells {
+			continue
+		}
+		g.counts[y][x] = n
+		if n > g.maxHits {
+			g.maxHits = n
+		}
+	}
+	return g, rows.Err()
+}
+
+func (g *heatmapGrid) geoJSON() map[string]any {
+	var features []map[string]any
+	for y, row := range g.counts {
+		for x, n := range row {
+			if n == 0 {
+				continue
+			}
+			lat0, lon0 := g.minLat+float64(y)*g.dLat, g.minLon+float64(x)*g.dLon
+			lat1, lon1 := lat0+g.dLat, lon0+g.dLon
+			features = append(features, map[string]any{
+				"type":       "Feature",
+				"properties": map[string]int{"count": n},
+				"geometry": map[string]any{
+					"type":        "Polygon",
+					"coordinates": [][][2]float64{{{lon0, lat0}, {lon1, lat0}, {lon1, lat1}, {lon0, lat1}, {lon0, lat0}}},
+				},
+			})
+		}
+	}
+	return map[string]any{"type": "FeatureCollection", "features": features}
+}
+
+// renderPNG draws the grid north-up with a log colour scale, the observer
+// and range rings every 10 nm.
+func (g *heatmapGrid) renderPNG(size int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{16, 20, 28, 255}}, image.Point{}, draw.Src)
+
+	px := float64(size) / float64(g.cells)
+	for y, row := range g.counts {
+		for x, n := range row {
+			if n == 0 {
+				continue
+			}
+			c := heatColor(math.Log1p(float64(n)) / math.Log1p(float64(g.maxHits)))
+			x0, y0 := int(float64(x)*px), size-int(float64(y+1)*px)
+			x1, y1 := int(float64(x+1)*px), size-int(float64(y)*px)
+			for yy := max(y0, 0); yy < min(y1, size); yy++ {
+				for xx := max(x0, 0); xx < min(x1, size); xx++ {
+					img.SetRGBA(xx, yy, c)
+				}
+			}
+		}
+	}
+
+	center := float64(size) / 2
+	pxPerNM := px / cfg().Heatmap.withDefaults().CellNM
+	ring := color.RGBA{90, 100, 120, 255}
+	for r := 10.0; r*pxPerNM < center; r += 10 {
+		radius := r * pxPerNM
+		for a := 0.0; a < 2*math.Pi; a += 1 / radius {
+			img.SetRGBA(int(center+radius*math.Cos(a)), int(center+radius*math.Sin(a)), ring)
+		}
+	}
+	for dy := -3; dy <= 3; dy++ {
+		for dx := -3; dx <= 3; dx++ {
+			img.SetRGBA(int(center)+dx, int(center)+dy, color.RGBA{255, 255, 255, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// heatColor maps 0..1 onto blue -> green -> yellow -> red.
+func heatColor(v float64) color.RGBA {
+	v = math.Max(0, math.Min(1, v))
+	stops := []color.RGBA{{30, 60, 200, 255}, {40, 200, 80, 255}, {250, 220, 40, 255}, {230, 40, 30, 255}}
+	pos := v * float64(len(stops)-1)
+	i := int(pos)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	t := pos - float64(i)
+	lerp := func(a, b uint8) uint8 { return uint8(float64(a) + t*(float64(b)-float64(a))) }
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
+
+func handleHeatmapGeoJSON(w http.ResponseWriter, r *http.Request) {
+	g, err := buildHeatmap()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g.geoJSON())
+}
+
+func handleHeatmapPNG(w http.ResponseWriter, r *http.Request) {
+	g, err := buildHeatmap()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	data, err := g.renderPNG(cfg().Heatmap.withDefaults().Size)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
+
+func postHeatmap(webhookURL string) {
+	if quietHold(webhookURL, "heatmap", heldAlert{Title: "🗺️ Traffic density heatmap"}) != "" {
+		return
+	}
+	g, err := buildHeatmap()
+	if err != nil {
+		fmt.Printf("[HM] Error building heatmap: %v\n", err)
+		return
+	}
+	data, err := g.renderPNG(cfg().Heatmap.withDefaults().Size)
+	if err != nil {
+		fmt.Printf("[HM] Error rendering heatmap: %v\n", err)
+		return
+	}
+	content := fmt.Sprintf("🗺️ Traffic density since %s", g.since.Format("Mon Jan 2"))
+	if err := postDiscordFile(webhookURL, content, "heatmap.png", data); err != nil {
+		fmt.Printf("[HM] Error posting heatmap: %v\n", err)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+)
+
+// --- Postgres history database (see setup.sql) ---
+// Raw sightings go to aircraft_sightings, consecutive sightings of the same
+// hex are collapsed into one row in flights, and every alert decision lands
+// in alerts linked to its flight. The connection string comes from
+// history.database_url or DATABASE_URL (a .env file is honoured).
+type HistoryConfig struct {
+	DatabaseURL  string   `json:"database_url"`
+	FlightGap    Duration `json:"flight_gap"`    // a hex unseen for this long starts a new flight, default 10m
+	RawSightings bool     `json:"raw_sightings"` // also keep every per-poll row in aircraft_sightings
+}
+
+// flightSession mirrors the open row in the flights table.
+type flightSession struct {
+	ID        int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Sightings int
+	MinAltFT  *float64
+	MaxAltFT  *float64
+	MaxGS     float64
+	ClosestNM *float64
+	Alerts    []string
+}
+
+var (
+	historyDB      *sql.DB
+	historyFlights = make(map[string]*flightSession)
+	historyMutex   = &sync.Mutex{}
+)
+
+func historyDatabaseURL() string {
+	if url := cfg().History.DatabaseURL; url != "" {
+		return url
+	}
+	return os.Getenv("DATABASE_URL")
+}
+
+func initHistoryDB() {
+	godotenv.Load()
+	url := historyDatabaseURL()
+	if url == "" {
+		return
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		fmt.Printf("[DB] Error opening history database: %v\n", err)
+		return
+	}
+	if err := db.Ping(); err != nil {
+		fmt.Printf("[DB] Error connecting to history database: %v\n", err)
+		db.Close()
+		return
+	}
+	db.SetMaxOpenConns(4)
+	historyDB = db
+	fmt.Println("[DB] Connected to history database.")
+}
+
+func flightGap() time.Duration {
+	if gap := cfg().History.FlightGap.Duration; gap > 0 {
+		return gap
+	}
+	return 10 * time.Minute
+}
+
+func recordHistorySighting(ac Aircraft, rec SightingRecord) {
+	if historyDB == nil {
+		return
+	}
+	if cfg().History.RawSightings {
+		_, err := historyDB.Exec(`INSERT INTO aircraft_sightings (seen_at, hex, flight, n_number, squawk, mil, alt_baro, gs, lat, lon)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			rec.Time, rec.Hex, rec.Flight, rec.Reg, rec.Squawk, rec.Mil, rec.AltBaro, rec.GS, rec.Lat, rec.Lon)
+		if err != nil {
+			fmt.Printf("[DB] Error inserting sighting for %s: %v\n", rec.Hex, err)
+		}
+	}
+
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	session := historyFlights[rec.Hex]
+	if session != nil && rec.Time.Sub(session.LastSeen) > flightGap() {
+		session = nil
+	}
+	if session == nil {
+		session = &flightSession{FirstSeen: rec.Time}
+		err := historyDB.QueryRow(`INSERT INTO flights (hex, flight, registration, aircraft_type, mil, first_seen, last_seen)
+			VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING id`,
+			rec.Hex, rec.Flight, rec.Reg, rec.Type, rec.Mil, rec.Time).Scan(&session.ID)
+		if err != nil {
+			fmt.Printf("[DB] Error opening flight for %s: %v\n", rec.Hex, err)
+			return
+		}
+		historyFlights[rec.Hex] = session
+	}
+
+	session.LastSeen = rec.Time
+	session.Sightings++
+	if alt, ok := altitudeFeet(ac); ok {
+		alt = math.Round(alt)
+		if session.MinAltFT == nil || alt < *session.MinAltFT {
+			session.MinAltFT = &alt
+		}
+		if session.MaxAltFT == nil || alt > *session.MaxAltFT {
+			session.MaxAltFT = &alt
+		}
+	}
+	if rec.GS > session.MaxGS {
+		session.MaxGS = rec.GS
+	}
+	closer := rec.Distance != nil && (session.ClosestNM == nil || *rec.Distance < *session.ClosestNM)
+	if closer {
+		session.ClosestNM = rec.Distance
+	}
+
+	_, err := historyDB.Exec(`UPDATE flights SET
+			flight = COALESCE(NULLIF($2, ''), flight),
+			registration = COALESCE(NULLIF($3, ''), registration),
+			aircraft_type = COALESCE(NULLIF($4, ''), aircraft_type),
+			last_seen = $5, sightings = $6, min_alt_ft = $7, max_alt_ft = $8, max_gs = $9, closest_nm = $10,
+			closest_lat = CASE WHEN $11 THEN $12 ELSE closest_lat END,
+			closest_lon = CASE WHEN $11 THEN $13 ELSE closest_lon END
+		WHERE id = $1`,
+		session.ID, rec.Flight, rec.Reg, rec.Type, rec.Time, session.Sightings,
+		session.MinAltFT, session.MaxAltFT, session.MaxGS, session.ClosestNM, closer, rec.Lat, rec.Lon)
+	if err != nil {
+		fmt.Printf("[DB] Error updating flight %d: %v\n", session.ID, err)
+	}
+}
+
+func recordHistoryAlert(rec AlertRecord) {
+	if historyDB == nil {
+		return
+	}
+
+	var flightID *int64
+	historyMutex.Lock()
+	if session := historyFlights[rec.Hex]; session != nil {
+		flightID = &session.ID
+		if rec.Outcome == "sent" {
+			session.Alerts = append(session.Alerts, rec.Type)
+			if _, err := historyDB.Exec(`UPDATE flights SET alerts = $2 WHERE id = $1`, session.ID, pq.Array(session.Alerts)); err != nil {
+				fmt.Printf("[DB] Error updating alerts for flight %d: %v\n", session.ID, err)
+			}
+		}
+	}
+	historyMutex.Unlock()
+
+	hex := rec.Hex
+	if rec.Type == "aggregate" {
+		hex = strings.Join(rec.Members, " ")
+	}
+	var embed *string
+	if len(rec.Embed) > 0 {
+		s := string(rec.Embed)
+		embed = &s
+	}
+	_, err := historyDB.Exec(`INSERT INTO alerts (alerted_at, flight_id, type, rule, hex, flight, registration, aircraft_type, owner, squawk, alt_baro, lat, lon, note, tags, outcome, embed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		rec.Time, flightID, rec.Type, rec.Rule, hex, rec.Flight, rec.Registration, rec.AircraftType, rec.Owner,
+		rec.Squawk, rec.AltBaro, rec.Lat, rec.Lon, rec.Note, pq.Array(rec.Tags), rec.Outcome, embed)
+	if err != nil {
+		fmt.Printf("[DB] Error inserting %s alert: %v\n", rec.Type, err)
+	}
+}
+
+// closeStaleFlights forgets open flights whose aircraft has left; their rows are already final.
+func closeStaleFlights() {
+	cutoff := clock.Now().Add(-flightGap())
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	for hex, session := range historyFlights {
+		if session.LastSeen.Before(cutoff) {
+			delete(historyFlights, hex)
+		}
+	}
+}
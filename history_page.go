@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// --- Alert history page ---
+// GET /history lists past alerts from the alerts table, filterable by type,
+// date range, hex and tag. Each row links to the flight's track export and to
+// the embed exactly as it was posted (GET /history/{id}/embed).
+type AlertFilter struct {
+	Type  string
+	Hex   string
+	Tag   string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+type AlertHistoryRow struct {
+	ID int64
+	AlertRecord
+	TrackURL string
+}
+
+func parseAlertFilter(r *http.Request) AlertFilter {
+	q := r.URL.Query()
+	f := AlertFilter{
+		Type:  strings.TrimSpace(q.Get("type")),
+		Hex:   strings.ToLower(strings.TrimSpace(q.Get("hex"))),
+		Tag:   strings.TrimSpace(q.Get("tag")),
+		Limit: 200,
+	}
+	if t, err := time.ParseInLocation("2006-01-02", q.Get("from"), time.Local); err == nil {
+		f.From = t
+	}
+	if t, err := time.ParseInLocation("2006-01-02", q.Get("to"), time.Local); err == nil {
+		f.To = t.AddDate(0, 0, 1) // inclusive
+	}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 && n <= 1000 {
+		f.Limit = n
+	}
+	return f
+}
+
+func queryAlertHistory(f AlertFilter) ([]AlertHistoryRow, error) {
+	if historyDB == nil {
+		return nil, fmt.Errorf("history database is not configured")
+	}
+	where := []string{"outcome = 'sent'"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Type != "" {
+		add("type = $%d", f.Type)
+	}
+	if f.Hex != "" {
+		add("hex = $%d", f.Hex)
+	}
+	if f.Tag != "" {
+		add("$%d = ANY(tags)", f.Tag)
+	}
+	if !f.From.IsZero() {
+		add("alerted_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("alerted_at < $%d", f.To)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := historyDB.Query(fmt.Sprintf(`SELECT id, alerted_at, type, coalesce(rule, ''), coalesce(hex, ''), coalesce(flight, ''),
+			coalesce(registration, ''), coalesce(aircraft_type, ''), coalesce(owner, ''), coalesce(squawk, ''),
+			coalesce(alt_baro, ''), lat, lon, coalesce(note, ''), coalesce(tags, '{}')
+		FROM alerts WHERE %s ORDER BY alerted_at DESC LIMIT $%d`, strings.Join(where, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AlertHistoryRow
+	for rows.Next() {
+		var row AlertHistoryRow
+		r := &row.AlertRecord
+		if err := rows.Scan(&row.ID, &r.Time, &r.Type, &r.Rule, &r.Hex, &r.Flight, &r.Registration, &r.AircraftType,
+			&r.Owner, &r.Squawk, &r.AltBaro, &r.Lat, &r.Lon, &r.Note, pq.Array(&r.Tags)); err != nil {
+			return nil, err
+		}
+		row.TrackURL = trackURLFor(r.Hex, r.Time)
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// trackURLFor finds the track covering an alert: the live one, or the
+// exported file whose session started last before the alert.
+func trackURLFor(hex string, at time.Time) string {
+	if hex == "" || !cfg().Tracks.Enabled {
+		return ""
+	}
+	if session, ok := currentTrack(hex); ok && !session.Start.After(at) {
+		return "/tracks/" + hex
+	}
+	dir := cfg().Tracks.Dir
+	if dir == "" {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*_"+hex+".gpx"))
+	best, bestStart := "", time.Time{}
+	for _, m := range matches {
+		name := filepath.Base(m)
+		start, err := time.Parse("20060102T150405Z", strings.SplitN(name, "_", 2)[0])
+		if err != nil || start.After(at) || start.Before(bestStart) {
+			continue
+		}
+		best, bestStart = name, start
+	}
+	if best == "" {
+		return ""
+	}
+	return "/track-files/" + best
+}
+
+var historyPageTemplate = template.Must(template.New("history").Funcs(template.FuncMap{
+	"fmtTime": formatTime,
+	"fmtDate": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	},
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Alert history</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; font-size: 14px; }
+form input { width: 9em; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>Alert history</h1>
+<p><a href="/coverage.svg">Coverage</a> · <a href="/heatmap.png">Heatmap</a> · <a href="/airport">Airport</a> · <a href="/feed.atom">Feed</a></p>
+<form method="get">
+  Type <input name="type" value="{{.Filter.Type}}">
+  Hex <input name="hex" value="{{.Filter.Hex}}">
+  Tag <input name="tag" value="{{.Filter.Tag}}">
+  From <input type="date" name="from" value="{{fmtDate .Filter.From}}">
+  To <input type="date" name="to" value="{{.To}}">
+  <button type="submit">Filter</button>
+</form>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+<p class="muted">{{len .Rows}} alerts</p>
+<table>
+<tr><th>Time</th><th>Type</th><th>Aircraft</th><th>Callsign</th><th>Owner</th><th>Note</th><th>Tags</th><th></th></tr>
+{{range .Rows}}
+<tr>
+  <td>{{fmtTime .Time}}</td>
+  <td>{{.Type}}{{if .Rule}} <span class="muted">({{.Rule}})</span>{{end}}</td>
+  <td><a href="https://globe.adsb.lol/?icao={{.Hex}}">{{if .Registration}}{{.Registration}}{{else}}{{.Hex}}{{end}}</a> {{.AircraftType}}</td>
+  <td>{{.Flight}}</td>
+  <td>{{.Owner}}</td>
+  <td>{{.Note}}</td>
+  <td>{{join .Tags ", "}}</td>
+  <td><a href="/history/{{.ID}}/embed">embed</a>{{if .TrackURL}} · <a href="{{.TrackURL}}">track</a>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+func handleHistoryPage(w http.ResponseWriter, r *http.Request) {
+	f := parseAlertFilter(r)
+	data := struct {
+		Filter AlertFilter
+		To     string
+		Rows   []AlertHistoryRow
+		Error  string
+	}{Filter: f, To: r.URL.Query().Get("to")}
+	rows, err := queryAlertHistory(f)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	data.Rows = rows
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := historyPageTemplate.Execute(w, data); err != nil {
+		fmt.Printf("[API] Error rendering history page: %v\n", err)
+	}
+}
+
+// GET /history/{id}/embed returns the Discord message exactly as it was posted.
+func handleHistoryEmbed(w http.ResponseWriter, r *http.Request) {
+	if historyDB == nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("history database is not configured"))
+		return
+	}
+	var embed []byte
+	err := historyDB.QueryRow(`SELECT embed FROM alerts WHERE id = $1 AND embed IS NOT NULL`, r.PathValue("id")).Scan(&embed)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no embed stored for alert %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, json.RawMessage(embed))
+}
+
+func trackFilesHandler() http.Handler {
+	dir := cfg().Tracks.Dir
+	if dir == "" {
+		return http.NotFoundHandler()
+	}
+	if _, err := os.Stat(dir); err != nil {
+		os.MkdirAll(dir, 0o755)
+	}
+	return http.StripPrefix("/track-files/", http.FileServer(http.Dir(dir)))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// --- Alert text translations ---
+// Alert titles, field names and squawk meanings are looked up by their English
+// text in locales/<lang>.json, which are compiled in. Anything missing from a
+// locale (or an unknown locale) stays English, so a partial translation is
+// fine.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	translations     map[string]map[string]string
+	translationsOnce sync.Once
+)
+
+func loadTranslations() {
+	translations = make(map[string]map[string]string)
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, e := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			continue
+		}
+		var table map[string]string
+		if err := json.Unmarshal(data, &table); err != nil {
+			fmt.Printf("[CF] Bad translation file %s: %v\n", e.Name(), err)
+			continue
+		}
+		translations[strings.ToLower(strings.TrimSuffix(e.Name(), ".json"))] = table
+	}
+}
+
+// T translates an English message (a fmt format when args are given) into
+// display.locale.
+func T(msg string, args ...any) string {
+	if locale := strings.ToLower(cfg().Display.Locale); locale != "" && locale != "en" {
+		translationsOnce.Do(loadTranslations)
+		// "pt-BR" falls back to "pt"
+		base, _, _ := strings.Cut(locale, "-")
+		for _, l := range []string{locale, base} {
+			if s, ok := translations[l][msg]; ok && s != "" {
+				msg = s
+				break
+			}
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
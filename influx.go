@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- InfluxDB / line-protocol output ---
+// Positions are batched per poll, alerts are written as they happen. Works
+// with InfluxDB v1/v2 write endpoints and anything else speaking line protocol
+// (Telegraf, VictoriaMetrics, QuestDB).
+type InfluxConfig struct {
+	URL         string `json:"url"`   // e.g. http://localhost:8086/api/v2/write?org=home&bucket=adsb&precision=s
+	Token       string `json:"token"` // sent as "Authorization: Token <token>" when set
+	Measurement string `json:"measurement"`
+}
+
+var (
+	influxBuffer bytes.Buffer
+	influxMutex  = &sync.Mutex{}
+)
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+var influxStringEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+func influxMeasurement(suffix string) string {
+	prefix := cfg().Influx.Measurement
+	if prefix == "" {
+		prefix = "adsb"
+	}
+	return influxTagEscaper.Replace(prefix + "_" + suffix)
+}
+
+// influxLine builds one line; empty tag values are skipped, fields are pre-formatted.
+func influxLine(measurement string, tags [][2]string, fields []string, t time.Time) string {
+	var b strings.Builder
+	b.WriteString(measurement)
+	for _, tag := range tags {
+		if tag[1] != "" {
+			fmt.Fprintf(&b, ",%s=%s", tag[0], influxTagEscaper.Replace(tag[1]))
+		}
+	}
+	b.WriteByte(' ')
+	b.WriteString(strings.Join(fields, ","))
+	fmt.Fprintf(&b, " %d\n", t.Unix())
+	return b.String()
+}
+
+func influxString(key, value string) string {
+	return fmt.Sprintf(`%s="%s"`, key, influxStringEscaper.Replace(value))
+}
+
+func bufferInfluxSighting(rec SightingRecord) {
+	if cfg().Influx.URL == "" {
+		return
+	}
+	fields := []string{"gs=" + strconv.FormatFloat(rec.GS, 'f', 1, 64)}
+	if alt, err := strconv.ParseFloat(rec.AltBaro, 64); err == nil {
+		fields = append(fields, fmt.Sprintf("alt_baro=%di", int64(alt)))
+	}
+	if rec.Lat != nil && rec.Lon != nil {
+		fields = append(fields, fmt.Sprintf("lat=%.6f", *rec.Lat), fmt.Sprintf("lon=%.6f", *rec.Lon))
+	}
+	if rec.Distance != nil {
+		fields = append(fields, fmt.Sprintf("distance_nm=%.2f", *rec.Distance))
+	}
+	line := influxLine(influxMeasurement("position"), [][2]string{
+		{"hex", rec.Hex}, {"flight", rec.Flight}, {"type", rec.Type}, {"mil", strconv.FormatBool(rec.Mil)},
+	}, fields, rec.Time)
+
+	influxMutex.Lock()
+	influxBuffer.WriteString(line)
+	influxMutex.Unlock()
+}
+
+func writeInfluxAlert(rec AlertRecord) {
+	if cfg().Influx.URL == "" {
+		return
+	}
+	fields := []string{influxString("outcome", rec.Outcome), "count=1i"}
+	if rec.Note != "" {
+		fields = append(fields, influxString("note", rec.Note))
+	}
+	if rec.Lat != nil && rec.Lon != nil {
+		fields = append(fields, fmt.Sprintf("lat=%.6f", *rec.Lat), fmt.Sprintf("lon=%.6f", *rec.Lon))
+	}
+	line := influxLine(influxMeasurement("alert"), [][2]string{
+		{"type", rec.Type}, {"rule", rec.Rule}, {"hex", rec.Hex}, {"aircraft_type", rec.AircraftType},
+		{"tags", strings.Join(rec.Tags, "|")},
+	}, fields, rec.Time)
+	go postInflux([]byte(line))
+}
+
+// flushInflux sends the positions buffered during the last poll.
+func flushInflux() {
+	influxMutex.Lock()
+	if influxBuffer.Len() == 0 {
+		influxMutex.Unlock()
+		return
+	}
+	payload := append([]byte(nil), influxBuffer.Bytes()...)
+	influxBuffer.Reset()
+	influxMutex.Unlock()
+	postInflux(payload)
+}
+
+func postInflux(payload []byte) {
+	ic := cfg().Influx
+	if ic.URL == "" {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, ic.URL, bytes.NewReader(payload))
+	if err != nil {
+		fmt.Printf("[IX] Error building request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if ic.Token != "" {
+		req.Header.Set("Authorization", "Token "+ic.Token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("[IX] Error writing to InfluxDB: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Printf("[IX] InfluxDB returned non-2xx status: %s\n", resp.Status)
+	}
+}
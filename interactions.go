@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// --- Discord slash commands ---
+// Discord POSTs slash command invocations to the interactions endpoint URL
+// configured for the application (point it at <http_listen>/discord/interactions).
+// Commands are registered once through Discord's API; supported so far:
+//
+//	/search query:<text>
+type DiscordBotConfig struct {
+	PublicKey string `json:"public_key"` // application public key, hex; empty disables the endpoint
+}
+
+const (
+	interactionPing               = 1
+	interactionApplicationCommand = 2
+
+	responsePong           = 1
+	responseChannelMessage = 4
+)
+
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+func (i discordInteraction) option(name string) string {
+	for _, o := range i.Data.Options {
+		if o.Name == name {
+			return fmt.Sprint(o.Value)
+		}
+	}
+	return ""
+}
+
+func handleDiscordInteraction(w http.ResponseWriter, r *http.Request) {
+	key, err := hex.DecodeString(cfg().DiscordBot.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("discord_bot.public_key is not configured"))
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sig, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), append([]byte(timestamp), body...), sig) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid request signature"))
+		return
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	switch interaction.Type {
+	case interactionPing:
+		writeJSON(w, http.StatusOK, map[string]int{"type": responsePong})
+	case interactionApplicationCommand:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"type": responseChannelMessage,
+			"data": map[string]string{"content": runDiscordCommand(interaction)},
+		})
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported interaction type %d", interaction.Type))
+	}
+}
+
+func runDiscordCommand(i discordInteraction) string {
+	switch i.Data.Name {
+	case "search":
+		query := i.option("query")
+		results, err := searchHistory(query, 10)
+		if err != nil {
+			return fmt.Sprintf("Search failed: %v", err)
+		}
+		return formatSearchResults(query, results)
+	}
+	return fmt.Sprintf("Unknown command /%s", i.Data.Name)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- Leaderboards ---
+// Rolling top lists computed from the flights table: most frequent visitors,
+// fastest, highest and closest passes. Served at GET /leaderboards and
+// optionally posted to Discord once a week.
+type LeaderboardConfig struct {
+	Window      Duration `json:"window"` // default 7 days
+	Limit       int      `json:"limit"`  // entries per board, default 5
+	Channel     string   `json:"channel"`
+	PostWeekday string   `json:"post_weekday"` // default "sunday"
+	PostHour    int      `json:"post_hour"`    // local hour, 0-23
+}
+
+type LeaderboardEntry struct {
+	Hex          string  `json:"hex"`
+	Registration string  `json:"registration,omitempty"`
+	AircraftType string  `json:"aircraft_type,omitempty"`
+	Flight       string  `json:"flight,omitempty"`
+	Value        float64 `json:"value"`
+}
+
+type Leaderboards struct {
+	Since    time.Time          `json:"since"`
+	MostSeen []LeaderboardEntry `json:"most_seen"` // value: flights
+	Fastest  []LeaderboardEntry `json:"fastest"`   // value: knots
+	Highest  []LeaderboardEntry `json:"highest"`   // value: feet
+	Closest  []LeaderboardEntry `json:"closest"`   // value: nm
+}
+
+func (lc LeaderboardConfig) window() time.Duration {
+	if lc.Window.Duration > 0 {
+		return lc.Window.Duration
+	}
+	return 7 * 24 * time.Hour
+}
+
+func (lc LeaderboardConfig) limit() int {
+	if lc.Limit > 0 {
+		return lc.Limit
+	}
+	return 5
+}
+
+func computeLeaderboards() (Leaderboards, error) {
+	if historyDB == nil {
+		return Leaderboards{}, fmt.Errorf("history database is not configured")
+	}
+	lc := cfg().Leaderboards
+	lb := Leaderboards{Since: time.Now().Add(-lc.window())}
+
+	boards := []struct {
+		dest  *[]LeaderboardEntry
+		value string
+		order string
+	}{
+		{&lb.MostSeen, "COUNT(*)", "DESC"},
+		{&lb.Fastest, "MAX(max_gs)", "DESC"},
+		{&lb.Highest, "MAX(max_alt_ft)", "DESC"},
+		{&lb.Closest, "MIN(closest_nm)", "ASC"},
+	}
+	for _, b := range boards {
+		query := fmt.Sprintf(`SELECT hex, COALESCE(MAX(registration), ''), COALESCE(MAX(aircraft_type), ''), COALESCE(MAX(flight), ''), %[1]s AS v
+			FROM flights WHERE first_seen >= $1
+			GROUP BY hex HAVING %[1]s IS NOT NULL
+			ORDER BY v %[2]s LIMIT $2`, b.value, b.order)
+		rows, err := historyDB.Query(query, lb.Since, lc.limit())
+		if err != nil {
+			return lb, err
+		}
+		for rows.Next() {
+			var e LeaderboardEntry
+			if err := rows.Scan(&e.Hex, &e.Registration, &e.AircraftType, &e.Flight, &e.Value); err != nil {
+				rows.Close()
+				return lb, err
+			}
+			*b.dest = append(*b.dest, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return lb, err
+		}
+	}
+	return lb, nil
+}
+
+// manageLeaderboards posts the weekly leaderboard when one is configured.
+func manageLeaderboards() {
+	var lastPosted string
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		lc := cfg().Leaderboards
+		if lc.Channel == "" || historyDB == nil {
+			continue
+		}
+		weekday := lc.PostWeekday
+		if weekday == "" {
+			weekday = "sunday"
+		}
+		today := now.Format("2006-01-02")
+		if !strings.EqualFold(now.Weekday().String(), weekday) || now.Hour() != lc.PostHour || lastPosted == today {
+			continue
+		}
+		lastPosted = today
+		postLeaderboards(resolveChannel(lc.Channel))
+	}
+}
+
+func postLeaderboards(webhookURL string) {
+	lb, err := computeLeaderboards()
+	if err != nil {
+		fmt.Printf("[LB] Error computing leaderboards: %v\n", err)
+		return
+	}
+	boardField := func(name string, entries []LeaderboardEntry, format func(float64) string) Field {
+		var lines []string
+		for i, e := range entries {
+			label := e.Registration
+			if label == "" {
+				label = e.Hex
+			}
+			if e.AircraftType != "" {
+				label += " (" + e.AircraftType + ")"
+			}
+			lines = append(lines, fmt.Sprintf("%d. `%s` — %s", i+1, label, format(e.Value)))
+		}
+		if len(lines) == 0 {
+			lines = []string{"—"}
+		}
+		return Field{Name: name, Value: strings.Join(lines, "\n"), Inline: false}
+	}
+
+	embed := Embed{
+		Title:       "🏆 Weekly Leaderboards",
+		Description: fmt.Sprintf("Since %s", lb.Since.Format("Mon Jan 2")),
+		Color:       15844367, // Gold
+		Fields: []Field{
+			boardField("Most Seen", lb.MostSeen, func(v float64) string { return fmt.Sprintf("%.0f visits", v) }),
+			boardField("Fastest", lb.Fastest, fmtSpeed),
+			boardField("Highest", lb.Highest, fmtAltitude),
+			boardField("Closest", lb.Closest, fmtDistance),
+		},
+	}
+	if err := postDiscordWebhook(webhookURL, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[LB] Error posting leaderboards: %v\n", err)
+		return
+	}
+	fmt.Println("[LB] Posted weekly leaderboards.")
+	if cfg().Heatmap.Weekly {
+		postHeatmap(webhookURL)
+	}
+}
@@ -3,17 +3,21 @@ package main
 import (
 	"bufio" // <-- NEW
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math"
+	"maps"
 	"net/http"
 	"os" // <-- NEW
 	"strconv"
 	"strings" // <-- NEW
 	"sync"
 	"time"
+
+	"main.go/geo"
 )
 
 // --- Configuration
@@ -25,16 +29,11 @@ const (
 
 	//--- Files
 	militaryTypesFile = "military_types.txt" // <-- NEW: Local config file
+	defaultConfigFile = "config.json"
 
-	//--- API Parameters for Radius Fetching
-	apiLat      = 35.740971
-	apiLng      = -78.498878
-	apiRadiusNM = 50
-
-	//--- Proximity Alert Zone
+	//--- Proximity Alert Zone (defaults, see config.json)
 	proximityRadiusNM   = 5.0
 	proximityAltitudeFT = 2000.0
-	earthRadiusNM       = 3440.065
 
 	//--- Other Consts
 	adsbdbAPIURL           = "https://api.adsbdb.com/v0/aircraft/"
@@ -43,11 +42,16 @@ const (
 	radiusPollInterval     = 60 * time.Second
 	nationwidePollInterval = 10 * time.Minute
 	watchlistInterval      = 24 * time.Hour
+	detailCacheTTL         = 6 * time.Hour
+	detailErrorCacheTTL    = 30 * time.Minute
 )
 
 // --- Global Variables ---
 var (
-	radiusAPIURL = fmt.Sprintf("https://api.adsb.lol/v2/point/%.6f/%.6f/%d", apiLat, apiLng, apiRadiusNM)
+	//--- API Parameters for Radius Fetching (observer.lat/lon/radius_nm override)
+	apiLat      = 35.740971
+	apiLng      = -78.498878
+	apiRadiusNM = 50.0
 	// specialAircraftTypes REMOVED - We load this dynamically now
 )
 
@@ -56,21 +60,39 @@ type ADSBResponse struct {
 	Aircraft []Aircraft `json:"ac"`
 }
 type Aircraft struct {
-	Hex     string  `json:"hex"`
-	Flight  string  `json:"flight"`
-	NNumber string  `json:"r"`
-	Type    string  `json:"t"`
-	Squawk  string  `json:"squawk"`
-	Mil     bool    `json:"mil"`
-	AltBaro any     `json:"alt_baro"`
-	GS      float64 `json:"gs"`
-
-	Lat any `json:"lat"`
-	Lon any `json:"lon"`
+	Hex      string   `json:"hex"`
+	Flight   string   `json:"flight"`
+	NNumber  string   `json:"r"`
+	Type     string   `json:"t"`
+	Category string   `json:"category"`
+	Squawk   string   `json:"squawk"`
+	Mil      bool     `json:"mil"`
+	AltBaro  any      `json:"alt_baro"`
+	GS       OptFloat `json:"gs"`
+	Track    *float64 `json:"track"`
+	BaroRate *float64 `json:"baro_rate"`
+	DBFlags  int      `json:"dbFlags"`
+	MsgType  string   `json:"type"` // best source: adsb_icao, mlat, uat, tisb_icao, ...
+
+	// Comm-B derived, readsb only (see modes.go)
+	NavAltMCP  *float64 `json:"nav_altitude_mcp"`
+	NavAltFMS  *float64 `json:"nav_altitude_fms"`
+	NavHeading *float64 `json:"nav_heading"`
+	NavQNH     *float64 `json:"nav_qnh"`
+	IAS        *float64 `json:"ias"`
+	TAS        *float64 `json:"tas"`
+	Mach       *float64 `json:"mach"`
+	WindDir    *float64 `json:"wd"`
+	WindSpeed  *float64 `json:"ws"`
+	OAT        *float64 `json:"oat"`
+
+	Lat     OptFloat `json:"lat"`
+	Lon     OptFloat `json:"lon"`
+	SeenPos OptFloat `json:"seen_pos"` // seconds since the position was updated
 
 	LastPos struct {
-		Lat any `json:"lat"`
-		Lon any `json:"lon"`
+		Lat OptFloat `json:"lat"`
+		Lon OptFloat `json:"lon"`
 	} `json:"lastPosition"`
 }
 type AircraftDetail struct {
@@ -110,9 +132,38 @@ type WatchlistEntry struct {
 	Registration string
 	Note         string
 	PlaneType    string
+	Category     string
+	Tags         []string
+
+	// The rest of the plane-alert-db row
+	Operator  string
+	TypeName  string   // "Boeing 757-200", where PlaneType is "B752"
+	CMPG      string   // Civ, Mil, Pol or Gov
+	Link      string   // curator's info page
+	ImageURLs []string // curated photos
+}
+
+// AlertContext carries the optional, alert-type specific bits into sendDiscordAlert.
+type AlertContext struct {
+	Entry   *WatchlistEntry // watchlist alerts
+	Rule    string          // name of the configurable rule that fired
+	Note    string          // extra description line
+	Mention string          // message content sent alongside the embed, e.g. "@here"
+	Tags    []string        // rule tags, merged with trigger_tags for the alert type
+
+	PhotoMode bool // rule asked for golden-hour photo mode
+
+	Title string // replaces the alert type's title
+	Color int    // replaces the alert type's colour
 }
+
+// alertTypes lists every type sendDiscordAlert formats.
+var alertTypes = []string{"watchlist", "emergency", "emergency_escalation", "military", "proximity", "special_military",
+	"dwell", "operator", "descent", "squawk", "global_watch", "overhead", "transit"}
+
 type DiscordWebhook struct {
-	Embeds []Embed `json:"embeds"`
+	Content string  `json:"content,omitempty"`
+	Embeds  []Embed `json:"embeds"`
 }
 type Embed struct {
 	Title       string    `json:"title"`
@@ -123,6 +174,11 @@ type Embed struct {
 	Footer      Footer    `json:"footer"`
 	Image       Image     `json:"image,omitempty"`
 	Thumbnail   Thumbnail `json:"thumbnail,omitempty"`
+	Author      *Author   `json:"author,omitempty"`
+}
+type Author struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url,omitempty"`
 }
 type Image struct {
 	URL string `json:"url"`
@@ -143,13 +199,23 @@ type RadiusAircraftState struct {
 	MilAlerted       bool
 	WatchlistAlerted bool
 	ProximityAlerted bool
+	ProximityTier    string // tightest tier alerted this visit
 	LastSeen         time.Time
+	EmergencySince   time.Time
+	LastEscalation   time.Time
 }
 
 var globalRadiusState = make(map[string]RadiusAircraftState)
 
 // --- State for the worldwide poller
-var globalNationwideState = make(map[string]time.Time)
+type NationwideAlertState struct {
+	AlertedAt time.Time
+	Lat       float64
+	Lon       float64
+	HasPos    bool
+}
+
+var globalNationwideState = make(map[string]NationwideAlertState)
 var nationwideStateMutex = &sync.Mutex{}
 
 // --- State for the watchlist
@@ -159,59 +225,117 @@ var (
 )
 
 // --- Main Application ---
+// configFile is config.json unless --config says otherwise
+var configFile = defaultConfigFile
+
 func main() {
-	go manageWatchlist()
-	go mainRadiusLoop()
-	go mainNationwideLoop()
-	select {}
+	args, ok := parseGlobalFlags(os.Args[1:])
+	if !ok {
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		args = []string{"run"}
+	}
+	if args[0] == "run" {
+		filterLogs()
+	}
+	if needsConfig(args) {
+		activeConfig.Store(loadConfig())
+		applyObserverLocation(cfg())
+	}
+	os.Exit(runCommand(args))
+}
+
+// startDaemon initialises shared state and starts every background loop.
+func startDaemon() {
+	initMutes(cfg().Mutes)
+	initHistoryDB()
+	initSharedState()
+	supervise("watchConfig", watchConfig)
+	supervise("manageParquet", manageParquet)
+	supervise("manageLeaderboards", manageLeaderboards)
+	supervise("manageRetention", manageRetention)
+	supervise("manageS3", manageS3)
+	supervise("manageSigmets", manageSigmets)
+	supervise("manageFeeder", manageFeeder)
+	supervise("manageFeedStatus", manageFeedStatus)
+	supervise("manageGlobalWatch", manageGlobalWatch)
+	supervise("manageDigest", manageDigest)
+	supervise("manageQuietHours", manageQuietHours)
+	supervise("startAPIServer", startAPIServer)
+	supervise("startMQTT", startMQTT)
+	supervise("startTelegram", startTelegram)
+	supervise("manageWatchlist", manageWatchlist)
+	if !cfg().Push.DisablePolling {
+		supervise("mainRadiusLoop", mainRadiusLoop)
+	}
+	supervise("mainNationwideLoop", mainNationwideLoop)
+	startLocations()
 }
 
 // --- Watchlist Manager
 func manageWatchlist() {
 	ticker := time.NewTicker(watchlistInterval)
 	defer ticker.Stop()
-	loadWatchlistFromCSV := func() {
-		fmt.Println("[WL] Refreshing aircraft watchlist from GitHub...")
-		resp, err := http.Get(watchlistCSVURL)
+	loadWatchlistFromCSV()
+	for range ticker.C {
+		loadWatchlistFromCSV()
+	}
+}
+
+func loadWatchlistFromCSV() {
+	var body io.ReadCloser
+	if offlineMode() {
+		fmt.Printf("[WL] Loading aircraft watchlist from %s (offline)...\n", offlineWatchlistPath())
+		f, err := os.Open(offlineWatchlistPath())
 		if err != nil {
-			fmt.Printf("[WL] Error fetching watchlist CSV: %v\n", err)
+			fmt.Printf("[WL] Error opening watchlist CSV: %v\n", err)
 			return
 		}
-		defer resp.Body.Close()
-
-		reader := csv.NewReader(resp.Body)
-		records, err := reader.ReadAll()
+		body = f
+	} else {
+		fmt.Println("[WL] Refreshing aircraft watchlist from GitHub...")
+		resp, err := httpClient.Get(watchlistCSVURL)
 		if err != nil {
-			fmt.Printf("[WL] Error parsing watchlist CSV: %v\n", err)
+			fmt.Printf("[WL] Error fetching watchlist CSV: %v\n", err)
 			return
 		}
+		body = resp.Body
+	}
+	defer body.Close()
 
-		newWatchlist := make(map[string]WatchlistEntry)
-		for i, row := range records {
-			if i == 0 {
-				continue
-			}
-			if len(row) > 6 {
-				entry := WatchlistEntry{
-					ICAO:         row[0],
-					Registration: row[1],
-					PlaneType:    row[4],
-					Note:         row[6],
-				}
-				newWatchlist[entry.ICAO] = entry
-			}
-		}
+	reader := csv.NewReader(body)
+	records, err := reader.ReadAll()
+	if err != nil {
+		fmt.Printf("[WL] Error parsing watchlist CSV: %v\n", err)
+		return
+	}
 
-		watchlistMutex.Lock()
-		globalWatchlist = newWatchlist
-		watchlistMutex.Unlock()
-		fmt.Printf("[WL] Successfully loaded %d aircraft into watchlist.\n", len(globalWatchlist))
+	newWatchlist := make(map[string]WatchlistEntry)
+	var cols watchlistColumns
+	for i, row := range records {
+		if i == 0 {
+			cols = parseWatchlistHeader(row)
+			continue
+		}
+		if entry, ok := watchlistEntryFromRow(cols, row); ok {
+			newWatchlist[entry.ICAO] = entry
+		}
 	}
 
-	loadWatchlistFromCSV()
-	for range ticker.C {
-		loadWatchlistFromCSV()
+	watchlistMutex.Lock()
+	var added, removed []WatchlistEntry
+	if upstreamWatchlist != nil {
+		added, removed = diffWatchlist(upstreamWatchlist, newWatchlist)
 	}
+	upstreamWatchlist = maps.Clone(newWatchlist)
+	for hex, entry := range manualWatchlist {
+		newWatchlist[hex] = entry
+	}
+	globalWatchlist = newWatchlist
+	watchlistMutex.Unlock()
+	notifyWatchlistDiff(added, removed)
+	fmt.Printf("[WL] Successfully loaded %d aircraft into watchlist.\n", len(globalWatchlist))
 }
 
 // --- Main 50nm Radius Poller ---
@@ -220,53 +344,111 @@ func mainRadiusLoop() {
 	defer ticker.Stop()
 
 	for {
-		// fmt.Println("[RD] Fetching new aircraft data (50nm)...")
-		resp, err := http.Get(radiusAPIURL)
+		aircraft, err := fetchRadiusAircraft()
 		if err != nil {
-			fmt.Printf("[RD] Error fetching ADSB data: %v\n", err)
+			fmt.Printf("[RD] %v\n", err)
+			recordPollError(err)
 			time.Sleep(radiusPollInterval)
 			continue
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			fmt.Printf("[RD] ADSB API returned non-200 status: %s\n", resp.Status)
-			time.Sleep(radiusPollInterval)
-			continue
-		}
+		// fmt.Printf("[RD] Processing %d aircraft...\n", len(aircraft))
+		processRadiusSnapshotLocked(aircraft)
+		// Saved state is loaded now, so streaming sources may alert
+		liveAlerts.Store(true)
 
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			fmt.Printf("[RD] Error reading response body: %v\n", err)
-			time.Sleep(radiusPollInterval)
-			continue
-		}
+		debugf("[RD] Waiting for next poll in %v\n", radiusPollInterval)
+		<-ticker.C
+	}
+}
 
-		var data ADSBResponse
-		if err := json.Unmarshal(bodyBytes, &data); err != nil {
-			fmt.Printf("[RD] Error decoding JSON: %v\n", err)
-			time.Sleep(radiusPollInterval)
+// fetchRadiusAircraft does one radius poll of the configured source (see
+// source.go) merged with UAT.
+func fetchRadiusAircraft() ([]Aircraft, error) {
+	src, err := radiusSource()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+	aircraft, err := src.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mergeUAT(aircraft), nil
+}
+
+// processRadiusSnapshotLocked takes radiusMutex for one snapshot. The unlock
+// is deferred so a panic that supervise (or net/http) recovers from doesn't
+// leave the mutex held and hang every later poll.
+func processRadiusSnapshotLocked(aircraft []Aircraft) {
+	radiusMutex.Lock()
+	defer radiusMutex.Unlock()
+	processRadiusSnapshot(homeCenter(), aircraft)
+}
+
+// processRadiusSnapshot runs every radius rule over one poll's worth of
+// aircraft, measured from the site's center.
+func processRadiusSnapshot(site sourceCenter, aircraft []Aircraft) {
+	loadRadiusState()
+	// Sightings, coverage and the outputs after the loop belong to the home
+	// receiver; a location's aircraft would be measured from the wrong site
+	// and counted twice where the radii overlap.
+	home := activeLocation.Load() == nil
+	stats := collectPollStats(aircraft)
+	snapshot := make([]Aircraft, 0, len(aircraft))
+	for _, ac := range aircraft {
+		if isBlocked(ac) {
 			continue
 		}
-
-		// fmt.Printf("[RD] Processing %d aircraft...\n", len(data.Aircraft))
-		for _, ac := range data.Aircraft {
-			processRadiusAlerts(ac)
+		reportSource("radius", ac)
+		if home {
+			recordSighting(ac)
 		}
-		cleanupRadiusState()
-
-		// fmt.Printf("[RD] Waiting for next poll in %v\n", radiusPollInterval)
-		<-ticker.C
+		processRadiusAlerts(site, ac)
+		processDwellAlerts(site, ac)
+		processOperatorAlerts(site, ac)
+		processDescentAlerts(site, ac)
+		processSquawkAlerts(site, ac)
+		snapshot = append(snapshot, ac)
 	}
+	processAggregateAlerts(site, snapshot)
+	processTransitAlerts(site, snapshot)
+	cleanupRadiusState()
+	saveRadiusState()
+	if !home {
+		return
+	}
+	processOverheadPredictions(snapshot)
+	processAirportMovements(snapshot)
+	setLiveAircraft(snapshot)
+	recordPollStats(stats)
+	go flushInflux()
+	go pushRemoteWrite(snapshot)
 }
 
 // --- NEW: Helper to load types from text file ---
-func loadSpecialTypes() []string {
-	var types []string
+// special_types in config.json wins; the text file is the simple fallback
+// where every type gets the default settings.
+func loadSpecialTypes() []SpecialType {
+	if len(cfg().SpecialTypes) > 0 {
+		var types []SpecialType
+		for _, s := range cfg().SpecialTypes {
+			if !s.Disabled {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+
+	var types []SpecialType
 	file, err := os.Open(militaryTypesFile)
 	if err != nil {
 		fmt.Printf("[SM] Warning: Could not read %s. Using default list.\n", militaryTypesFile)
-		return []string{"B52", "B1", "B2", "U2", "C5", "HRON", "P8"} // Fallback defaults
+		for _, t := range []string{"B52", "B1", "B2", "U2", "C5", "HRON", "P8"} { // Fallback defaults
+			types = append(types, SpecialType{Type: t})
+		}
+		return types
 	}
 	defer file.Close()
 
@@ -275,7 +457,7 @@ func loadSpecialTypes() []string {
 		line := strings.TrimSpace(scanner.Text())
 		// Ignore empty lines or comments
 		if line != "" && !strings.HasPrefix(line, "#") {
-			types = append(types, line)
+			types = append(types, SpecialType{Type: line})
 		}
 	}
 	return types
@@ -287,101 +469,134 @@ func mainNationwideLoop() {
 	defer ticker.Stop()
 
 	for {
-		fmt.Println("[SM] Starting nationwide scan cycle...")
+		if offlineMode() {
+			// The nationwide scan only exists on adsb.lol
+			<-ticker.C
+			continue
+		}
+		runNationwideCycle(false)
+		debugf("[SM] Waiting for next poll in %v\n", nationwidePollInterval)
+		<-ticker.C
+	}
+}
 
-		// --- NEW: Load types dynamically ---
-		specialAircraftTypes := loadSpecialTypes()
-		fmt.Printf("[SM] Loaded %d target types from config.\n", len(specialAircraftTypes))
-		// -----------------------------------
+// runNationwideCycle checks every special type once. With dryRun nothing is
+// sent or remembered; the aircraft that would alert are returned instead.
+func runNationwideCycle(dryRun bool) (found []Aircraft) {
+	fmt.Println("[SM] Starting nationwide scan cycle...")
+	loadNationwideState()
 
-		for _, acType := range specialAircraftTypes {
-			fmt.Printf("[SM] Checking for type: %s\n", acType)
-			apiURL := fmt.Sprintf("https://api.adsb.lol/v2/type/%s", acType)
+	// --- NEW: Load types dynamically ---
+	specialAircraftTypes := loadSpecialTypes()
+	fmt.Printf("[SM] Loaded %d target types from config.\n", len(specialAircraftTypes))
+	// -----------------------------------
 
-			resp, err := http.Get(apiURL)
-			if err != nil {
-				fmt.Printf("[SM] Error fetching type %s: %v\n", acType, err)
+	for _, special := range specialAircraftTypes {
+		acType := special.Type
+		debugf("[SM] Checking for type: %s\n", acType)
+		apiURL := fmt.Sprintf("https://api.adsb.lol/v2/type/%s", acType)
+
+		resp, err := httpClient.Get(apiURL)
+		if err != nil {
+			fmt.Printf("[SM] Error fetching type %s: %v\n", acType, err)
+			continue
+		}
+		defer resp.Body.Close()
+
+		var data ADSBResponse
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			// Don't print error on empty result, some types just aren't flying
+			// fmt.Printf("[SM] Error decoding type %s: %v\n", acType, err)
+			continue
+		}
+
+		if len(data.Aircraft) > 0 {
+			fmt.Printf("[SM] Found %d aircraft of type %s\n", len(data.Aircraft), acType)
+		}
+
+		for _, ac := range data.Aircraft {
+			if isBlocked(ac) {
+				continue
+			}
+			reportSource("nationwide", ac)
+			nationwideStateMutex.Lock()
+			lastAlert, seen := globalNationwideState[ac.Hex]
+			nationwideStateMutex.Unlock()
+
+			lat, lon, hasCoords := getActualCoords(ac)
+			movedNM := 0.0
+			if seen && hasCoords && lastAlert.HasPos {
+				movedNM = geo.DistanceNM(lastAlert.Lat, lastAlert.Lon, lat, lon)
+			}
+			moved := special.realertDistance() > 0 && movedNM >= special.realertDistance()
+			if seen && since(lastAlert.AlertedAt) <= special.cooldown() && !moved {
+				continue
+			}
+			if !special.inRegion(ac) || !triggerActive("special_military") {
 				continue
 			}
-			defer resp.Body.Close()
 
-			var data ADSBResponse
-			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-				// Don't print error on empty result, some types just aren't flying
-				// fmt.Printf("[SM] Error decoding type %s: %v\n", acType, err)
+			fmt.Printf("[SM] NEW AIRCRAFT: %s (%s)\n", acType, ac.Hex)
+			if dryRun {
+				found = append(found, ac)
 				continue
 			}
 
-			if len(data.Aircraft) > 0 {
-				fmt.Printf("[SM] Found %d aircraft of type %s\n", len(data.Aircraft), acType)
+			details, err := getAircraftDetails(ac.Hex)
+			if err != nil {
+				fmt.Printf("[SM] Error getting details for %s: %v\n", ac.Hex, err)
 			}
 
-			for _, ac := range data.Aircraft {
-				nationwideStateMutex.Lock()
-				lastAlertTime, seen := globalNationwideState[ac.Hex]
-				nationwideStateMutex.Unlock()
-
-				if !seen || time.Since(lastAlertTime) > (24*time.Hour) {
-					fmt.Printf("[SM] NEW AIRCRAFT: %s (%s)\n", acType, ac.Hex)
-
-					details, err := getAircraftDetails(ac.Hex)
-					if err != nil {
-						fmt.Printf("[SM] Error getting details for %s: %v\n", ac.Hex, err)
-					}
-
-					// Fallback if detail type is missing
-					if details.AircraftType == "" {
-						if ac.Type != "" {
-							details.AircraftType = ac.Type
-						} else {
-							details.AircraftType = acType
-						}
-					}
-
-					sendDiscordAlert(discordHookSpecialMil, ac, details, "special_military", nil)
-
-					nationwideStateMutex.Lock()
-					globalNationwideState[ac.Hex] = time.Now()
-					nationwideStateMutex.Unlock()
+			// Fallback if detail type is missing
+			if details.AircraftType == "" {
+				if ac.Type != "" {
+					details.AircraftType = ac.Type
+				} else {
+					details.AircraftType = acType
 				}
 			}
-			time.Sleep(5 * time.Second)
-		}
 
-		fmt.Printf("[SM] Waiting for next poll in %v\n", nationwidePollInterval)
-		<-ticker.C
+			var notes []string
+			if special.Name != "" {
+				notes = append(notes, fmt.Sprintf("**%s**", special.Name))
+			}
+			if moved {
+				fmt.Printf("[SM] %s moved %.0f nm since last alert\n", ac.Hex, movedNM)
+				notes = append(notes, fmt.Sprintf("Position update: moved %s since the last alert %v ago", fmtRadius(movedNM), since(lastAlert.AlertedAt).Round(time.Minute)))
+			}
+			actx := special.alertContext(ac)
+			actx.Note = strings.Join(notes, "\n")
+			sendDiscordAlert(special.webhook(), ac, details, "special_military", actx)
+
+			alerted := NationwideAlertState{AlertedAt: clock.Now(), Lat: lat, Lon: lon, HasPos: hasCoords}
+			nationwideStateMutex.Lock()
+			globalNationwideState[ac.Hex] = alerted
+			nationwideStateMutex.Unlock()
+			saveNationwideState(ac.Hex, alerted)
+		}
+		time.Sleep(5 * time.Second)
 	}
+	return found
 }
 
 // --- Helper Functions ---
 
-func generateMapURL(lat, lon float64) string {
-	zoomLevel := 8
-	return fmt.Sprintf(
-		"https://maps.geoapify.com/v1/staticmap?style=osm-carto&width=500&height=300&center=lonlat:%.6f,%.6f&zoom=%d&marker=lonlat:%.6f,%.6f;type:awesome;color:red&apiKey=%s",
-		lon, lat,
-		zoomLevel,
-		lon, lat,
-		geoapifyAPIKey,
-	)
-}
-
-func haversine(lat1, lon1, lat2, lon2 float64) float64 {
-	radLat1, radLon1 := lat1*math.Pi/180, lon1*math.Pi/180
-	radLat2, radLon2 := lat2*math.Pi/180, lon2*math.Pi/180
-	dLon, dLat := radLon2-radLon1, radLat2-radLat1
-	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(radLat1)*math.Cos(radLat2)*math.Pow(math.Sin(dLon/2), 2)
-	c := 2 * math.Asin(math.Sqrt(a))
-	return c * earthRadiusNM
-}
-
 // --- Core Logic for Radius Poller ---
-func processRadiusAlerts(ac Aircraft) {
+func processRadiusAlerts(site sourceCenter, ac Aircraft) {
 	hex := ac.Hex
 	squawk := ac.Squawk
 	currentState, seen := globalRadiusState[hex]
-	isEmergency := (squawk == "7700" || squawk == "7600" || squawk == "7500")
-	lat, lon, hasCoords := getActualCoords(ac)
+	isEmergency := isEmergencySquawk(squawk)
+
+	// --- Emergency over? ---
+	if !isEmergency && !currentState.EmergencySince.IsZero() {
+		sendResolution(cfg().Resolutions.Emergency, "emergency", emergencyWebhook(currentState.LastSquawk), ac, "Emergency Ended",
+			fmt.Sprintf("`%s` stopped squawking %s after %v (now `%s`).",
+				ac.Hex, currentState.LastSquawk, since(currentState.EmergencySince).Round(time.Minute), squawk))
+		endEmergency(hex, currentState.LastSquawk)
+		currentState.EmergencySince = time.Time{}
+		currentState.LastEscalation = time.Time{}
+	}
 
 	// --- Trigger 1: Watchlist Hit ---
 	watchlistMutex.RLock()
@@ -389,81 +604,114 @@ func processRadiusAlerts(ac Aircraft) {
 	watchlistMutex.RUnlock()
 
 	if onWatchlist {
-		if !seen || !currentState.WatchlistAlerted {
+		if (!seen || !currentState.WatchlistAlerted) && triggerActive("watchlist") {
 			fmt.Printf("[Radius] !!! WATCHLIST DETECTED: %s (Note: %s)\n", hex, entry.Note)
 			details, _ := getAircraftDetails(hex)
-			sendDiscordAlert(discordHookWatchlist, ac, details, "watchlist", &entry)
+			sendDiscordAlert(watchlistWebhook(entry), ac, details, "watchlist", &AlertContext{Entry: &entry})
 			currentState.WatchlistAlerted = true
 		}
 		currentState.LastSquawk = squawk
-		currentState.LastSeen = time.Now()
+		currentState.LastSeen = clock.Now()
 		globalRadiusState[hex] = currentState
 		return
 	}
 
 	// --- Trigger 2: Emergency Squawk ---
 	if isEmergency {
-		if !seen || currentState.LastSquawk != squawk {
+		prior, ongoing := ongoingEmergency(hex, squawk)
+		newSquawk := !seen || currentState.LastSquawk != squawk
+		switch {
+		case newSquawk && ongoing:
+			// Alerted before a restart (or a brief drop-out); same incident
+			fmt.Printf("[Radius] Emergency %s squawking %s already alerted at %s, not re-paging\n", hex, squawk, formatTime(prior.Since))
+			currentState.EmergencySince = prior.Since
+			currentState.LastEscalation = prior.LastEscalation
+		case newSquawk && triggerActive("emergency"):
 			fmt.Printf("[Radius] !!! EMERGENCY DETECTED: %s squawking %s\n", hex, squawk)
 			details, _ := getAircraftDetails(hex)
-			sendDiscordAlert(discordHookWatchlist, ac, details, "emergency", nil)
+			sendDiscordAlert(emergencyWebhook(squawk), ac, details, "emergency", emergencyContext(squawk))
+			currentState.EmergencySince = clock.Now()
+			currentState.LastEscalation = time.Time{}
+		case newSquawk:
+			// Scheduled off: the squawk isn't recorded as handled, so it
+			// alerts once the trigger is active again
+			currentState.LastSeen = clock.Now()
+			globalRadiusState[hex] = currentState
+			return
+		default:
+			checkEmergencyEscalation(ac, &currentState)
+		}
+		if !currentState.EmergencySince.IsZero() {
+			noteEmergency(hex, squawk, currentState)
 		}
 		currentState.LastSquawk = squawk
-		currentState.LastSeen = time.Now()
+		currentState.LastSeen = clock.Now()
 		globalRadiusState[hex] = currentState
 		return
 	}
 
-	// --- Trigger 3: Military Aircraft ---
-	if ac.Mil {
-		if !seen || !currentState.MilAlerted {
-			fmt.Printf("[Radius] !!! MILITARY DETECTED: %s\n", hex)
+	// --- Trigger 3: Military Aircraft (mil flag or known callsign) ---
+	if isMilitary(ac) {
+		if (!seen || !currentState.MilAlerted) && triggerActive("military") {
+			fmt.Printf("[Radius] !!! MILITARY DETECTED: %s (%s)\n", hex, strings.TrimSpace(ac.Flight))
 			details, _ := getAircraftDetails(hex)
 			sendDiscordAlert(discordHookWatchlist, ac, details, "military", nil)
 			currentState.MilAlerted = true
 		}
 		currentState.LastSquawk = squawk
-		currentState.LastSeen = time.Now()
+		currentState.LastSeen = clock.Now()
 		globalRadiusState[hex] = currentState
 		return
 	}
 
 	// --- Trigger 4: Proximity Alert ---
-	if hasCoords {
-		distanceNM := haversine(apiLat, apiLng, lat, lon)
-		if distanceNM <= proximityRadiusNM {
-			altStr := formatAltitudeString(ac.AltBaro)
-			altitudeFT, err := strconv.ParseFloat(altStr, 64)
-
-			if err == nil && altitudeFT > 0 && altitudeFT <= proximityAltitudeFT {
-				if !seen || !currentState.ProximityAlerted {
-					fmt.Printf("[Radius] !!! PROXIMITY DETECTED: %s (%.1f nm, %.0f ft)\n", ac.Hex, distanceNM, altitudeFT)
-					details, _ := getAircraftDetails(hex)
-					sendDiscordAlert(discordHookProximity, ac, details, "proximity", nil)
-					currentState.ProximityAlerted = true
-				}
-			} else {
-				currentState.ProximityAlerted = false
-			}
-		} else {
-			currentState.ProximityAlerted = false
+	wasProximity, wasTier := currentState.ProximityAlerted, currentState.ProximityTier
+	switch distanceNM, altitudeFT, tier, inZone := proximityZone(site, ac); {
+	case positionStatus(ac) != PositionFresh:
+		// An old or missing fix neither raises nor clears proximity
+	case inZone:
+		// The compound conditions gate the alert only; once alerted, the
+		// aircraft stays "in proximity" until it leaves the zone, and only
+		// a tighter tier alerts again. A muted aircraft is never marked
+		// alerted, so it gets no "cleared" follow-up either.
+		escalated := !currentState.ProximityAlerted || proximityRank(tier.Name) < proximityRank(currentState.ProximityTier)
+		now := clock.Now()
+		_, muted := isMuted(ac)
+		if escalated && !muted && proximityConditionsMet(site, ac) && triggerActive("proximity") && proximityCooledDown(hex, tier, now) {
+			fmt.Printf("[Radius] !!! PROXIMITY DETECTED: %s (%.1f nm, %.0f ft) tier '%s'\n", ac.Hex, distanceNM, altitudeFT, tier.Name)
+			details, _ := getAircraftDetails(hex)
+			sendDiscordAlert(proximityWebhook(tier.Name), ac, details, "proximity", proximityContext(site, ac, tier, distanceNM, altitudeFT))
+			markProximityAlert(hex, tier, now)
+			currentState.ProximityAlerted = true
+			currentState.ProximityTier = tier.Name
 		}
-	} else {
+	default:
 		currentState.ProximityAlerted = false
+		currentState.ProximityTier = ""
+	}
+	if wasProximity && !currentState.ProximityAlerted {
+		sendResolution(cfg().Resolutions.Proximity, "proximity", proximityWebhook(wasTier), ac, "Proximity Cleared",
+			fmt.Sprintf("`%s` has left the proximity zone (now at %s).", ac.Hex, fmtAltBaro(ac.AltBaro)))
 	}
 
 	currentState.LastSquawk = squawk
-	currentState.LastSeen = time.Now()
+	currentState.LastSeen = clock.Now()
 	globalRadiusState[hex] = currentState
 }
 
 func cleanupRadiusState() {
-	cutoff := time.Now().Add(-30 * time.Minute)
+	cutoff := clock.Now().Add(-30 * time.Minute)
 	removedCount := 0
 	keysToDelete := []string{}
+	departedCutoff := clock.Now().Add(-cfg().Resolutions.DepartedAfter.Duration)
 	for hex, state := range globalRadiusState {
+		if state.WatchlistAlerted && state.LastSeen.Before(departedCutoff) {
+			notifyWatchlistDeparted(hex, state)
+			state.WatchlistAlerted = false
+			globalRadiusState[hex] = state
+		}
 		if state.LastSeen.IsZero() {
-			globalRadiusState[hex] = RadiusAircraftState{LastSeen: time.Now()}
+			globalRadiusState[hex] = RadiusAircraftState{LastSeen: clock.Now()}
 		} else if state.LastSeen.Before(cutoff) {
 			keysToDelete = append(keysToDelete, hex)
 		}
@@ -472,22 +720,81 @@ func cleanupRadiusState() {
 		delete(globalRadiusState, hex)
 		removedCount++
 	}
+	for key, state := range globalRuleState {
+		if state.LastSeen.Before(cutoff) {
+			delete(globalRuleState, key)
+		}
+	}
+	closeStaleTracks()
+	pruneSightingSpans()
+	closeStaleFlights()
+	pruneSourceReports()
 	// if removedCount > 0 {
 	// 	fmt.Printf("[Radius] State cleanup complete. Removed %d old aircraft. Tracking %d.\n", removedCount, len(globalRadiusState))
 	// }
 }
 
 // --- On-Demand Enrichment (No-DB) ---
+// Results are cached in memory so rules that need enrichment for every
+// candidate aircraft don't hit adsbdb on each poll.
+type cachedDetail struct {
+	detail    AircraftDetail
+	err       error
+	fetchedAt time.Time
+}
+
+var (
+	detailCache      = make(map[string]cachedDetail)
+	detailCacheMutex = &sync.Mutex{}
+)
+
 func getAircraftDetails(hex string) (AircraftDetail, error) {
+	detailCacheMutex.Lock()
+	cached, ok := detailCache[hex]
+	detailCacheMutex.Unlock()
+
+	ttl := detailCacheTTL
+	if cached.err != nil {
+		ttl = detailErrorCacheTTL
+	}
+	if ok && since(cached.fetchedAt) < ttl {
+		return cached.detail, cached.err
+	}
+
+	detail, err := coalescedDetails(hex)
+	if errors.Is(err, errRateLimited) {
+		// Not adsbdb's answer, so don't cache it
+		return detail, err
+	}
+
+	detailCacheMutex.Lock()
+	detailCache[hex] = cachedDetail{detail: detail, err: err, fetchedAt: clock.Now()}
+	for key, entry := range detailCache {
+		if since(entry.fetchedAt) > detailCacheTTL {
+			delete(detailCache, key)
+		}
+	}
+	detailCacheMutex.Unlock()
+	return detail, err
+}
+
+func fetchAircraftDetails(hex string) (AircraftDetail, error) {
+	if offlineMode() {
+		return localAircraftDetails(hex)
+	}
 	var detail AircraftDetail
-	fmt.Printf("[EN] API FETCH: Fetching details for %s from adsbdb.com\n", hex)
+	if err := waitForAdsbdb(); err != nil {
+		return detail, err
+	}
+	debugf("[EN] API FETCH: Fetching details for %s from adsbdb.com\n", hex)
 	apiURL := adsbdbAPIURL + hex
 
-	resp, err := http.Get(apiURL)
+	resp, err := httpClient.Get(apiURL)
 	if err != nil {
 		return detail, fmt.Errorf("API fetch error for %s: %v", hex, err)
 	}
 	defer resp.Body.Close()
+	noteAdsbdbStatus(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		return detail, fmt.Errorf("adsbdb API returned non-200 status: %s", resp.Status)
@@ -526,41 +833,156 @@ func getAircraftDetails(hex string) (AircraftDetail, error) {
 	return detail, nil
 }
 
-func sendDiscordAlert(webhookURL string, ac Aircraft, details AircraftDetail, alertType string, entry *WatchlistEntry) {
+// sendDiscordAlert returns the outcome it recorded (sent, muted, quiet,
+// queued, no_webhook, error).
+func sendDiscordAlert(webhookURL string, ac Aircraft, details AircraftDetail, alertType string, actx *AlertContext) (outcome string) {
 	lat, lon, hasCoords := getActualCoords(ac)
 
+	var ruleTags []string
+	if actx != nil {
+		ruleTags = actx.Tags
+	}
+	tags := alertTags(alertType, ruleTags)
+
+	// Every alert decision ends up in the records, whether or not it was sent
+	rec := newAlertRecord(ac, details, alertType, actx, tags)
+	site := alertCenter(alertType)
+	webhookURL = siteWebhook(alertType, webhookURL)
+	rec.Webhook = webhookURL
+	defer func() {
+		recordAlert(rec)
+		outcome = rec.Outcome
+	}()
+
+	if m, muted := isMuted(ac); muted {
+		fmt.Printf("[Discord] %s is muted (%s=%s). Skipping '%s' alert.\n", ac.Hex, m.Match, m.Value, alertType)
+		rec.Outcome = "muted"
+		return
+	}
+
+	quiet, isQuiet := quietHoursFor(webhookURL)
+	isQuiet = isQuiet && quiet.active(alertType, rec.Time)
+	if isQuiet && !quiet.queues() {
+		fmt.Printf("[QH] Quiet hours, dropping '%s' alert for %s\n", alertType, ac.Hex)
+		rec.Outcome = "quiet"
+		return
+	}
+	if !isQuiet {
+		// Whether or not Discord is configured, or up
+		notifyLocal(rec, site)
+	}
+
 	if webhookURL == "" || webhookURL == "https://discord.com/api/webhooks/..." {
 		fmt.Printf("[Discord] Webhook for alert type '%s' is not set. Skipping.\n", alertType)
+		rec.Outcome = "no_webhook"
 		return
 	}
 
 	var title, description string
 	var color int
-	altStr := formatAltitudeString(ac.AltBaro)
 
 	switch alertType {
 	case "watchlist":
-		title = "Watchlist Alert (50nm)"
-		description = fmt.Sprintf("**Note:** %s", entry.Note)
+		title = T("Watchlist Alert (%s)", fmtRadius(site.RadiusNM))
+		description = fmt.Sprintf("**%s:** %s", T("Note"), actx.Entry.Note)
 		color = 16776960 // Yellow
 	case "emergency":
-		title = fmt.Sprintf("🔴 EMERGENCY: SQUAWK %s", ac.Squawk)
+		title = T("🔴 EMERGENCY: SQUAWK %s", ac.Squawk)
+		description = fmt.Sprintf("**%s**", squawkMeaning(ac.Squawk))
 		color = 16711680 // Red
+	case "emergency_escalation":
+		title = T("🚨 ESCALATED EMERGENCY: SQUAWK %s", ac.Squawk)
+		description = actx.Note
+		color = 10038562 // Dark red
 	case "military":
-		title = "Military Aircraft (50nm)"
+		title = T("Military Aircraft (%s)", fmtRadius(site.RadiusNM))
 		color = 3447003 // Blue
 	case "proximity":
-		title = "Proximity Alert"
-		description = fmt.Sprintf("**Aircraft is at %s ft within 5nm**", altStr)
+		title = T("Proximity Alert")
+		if actx.Rule != "" {
+			title = T("Proximity Alert: %s", actx.Rule)
+		}
+		description = actx.Note
 		color = 16753920 // Orange
 	case "special_military":
-		title = fmt.Sprintf("Military Flight: %s", ac.Flight)
+		title = T("Military Flight: %s", ac.Flight)
 		description = ""
+		if actx != nil {
+			description = actx.Note
+		}
 		color = 11290111 // Purple
+	case "dwell":
+		title = T("Dwell Alert: %s", actx.Rule)
+		description = actx.Note
+		color = 1752220 // Teal
+	case "operator":
+		title = T("Operator Alert: %s", actx.Rule)
+		description = actx.Note
+		color = 15277667 // Pink
+	case "descent":
+		title = T("Descent Alert: %s", actx.Rule)
+		description = actx.Note
+		color = 5793266 // Blurple
+	case "squawk":
+		title = T("Squawk Alert: %s", actx.Rule)
+		description = actx.Note
+		color = 16776960 // Yellow
+	case "global_watch":
+		title = T("🌍 Global Watch: %s", actx.Rule)
+		description = actx.Note
+		color = 2067276 // Dark green
+	case "overhead":
+		title = T("⏱️ Heads-up: Overhead Soon")
+		description = actx.Note
+		color = 16753920 // Orange
+	case "transit":
+		title = T("☀️ Solar Transit Predicted")
+		if actx.Rule == "moon" {
+			title = T("🌙 Lunar Transit Predicted")
+		}
+		description = actx.Note
+		color = 15844367 // Gold
+	}
+
+	if actx != nil && actx.Title != "" {
+		title = actx.Title
+	}
+	if actx != nil && actx.Color != 0 {
+		color = actx.Color
+	}
+
+	photoMention := ""
+	if note, ok := photoOpportunity(site, alertType, ac, actx); ok {
+		title = "📷 " + title
+		tags = append(tags, "golden-hour")
+		rec.Tags = tags
+		description = strings.TrimSpace(description + "\n" + note)
+		photoMention = cfg().PhotoMode.Mention
+	}
+
+	if adv := advisoryFor(alertType, ac); adv != nil {
+		tags = append(tags, "sigmet")
+		rec.Tags = tags
+		description = strings.TrimSpace(description + "\n" + adv.describe())
 	}
 
 	if details.FullImageURL != "" && alertType != "proximity" {
-		description = fmt.Sprintf("[View Full Image](%s)\n%s", details.FullImageURL, description)
+		description = fmt.Sprintf("[%s](%s)\n%s", T("View Full Image"), details.FullImageURL, description)
+	}
+
+	route, err := lookupRoute(ac.Flight)
+	if err != nil {
+		fmt.Printf("[EN] Route lookup failed for %s: %v\n", ac.Flight, err)
+	}
+	if route != nil {
+		// Fill the gaps adsbdb leaves
+		if details.Registration == "" {
+			details.Registration = route.Registration
+		}
+		if details.AircraftType == "" {
+			details.AircraftType = route.AircraftType
+		}
+		rec.Route = route.String()
 	}
 
 	var fields []Field
@@ -569,7 +991,7 @@ func sendDiscordAlert(webhookURL string, ac Aircraft, details AircraftDetail, al
 		if ac.Type != "" {
 			finalType = ac.Type
 		} else {
-			finalType = "Unknown"
+			finalType = T("Unknown")
 		}
 	}
 
@@ -578,83 +1000,171 @@ func sendDiscordAlert(webhookURL string, ac Aircraft, details AircraftDetail, al
 		flagEmoji = fmt.Sprintf(":flag_%s: ", strings.ToLower(details.CountryISO))
 	}
 
+	airline, _, hasAirline := airlineFor(ac.Flight)
+	if details.Airline == "" && hasAirline {
+		details.Airline = airline.Name
+	}
+
 	if alertType == "special_military" {
 		fields = []Field{
-			{Name: "Callsign", Value: fmt.Sprintf("`%s`", ac.Flight), Inline: true},
+			{Name: "Callsign", Value: callsignLabel(ac.Flight), Inline: true},
 			{Name: "Reg", Value: fmt.Sprintf("`%s`", ac.NNumber), Inline: true},
 			{Name: "Squawk", Value: fmt.Sprintf("`%s`", ac.Squawk), Inline: true},
 			{Name: "Aircraft Type", Value: fmt.Sprintf("`%s`", finalType), Inline: true},
-			{Name: "Altitude", Value: fmt.Sprintf("%s ft", altStr), Inline: true},
-			{Name: "Speed", Value: fmt.Sprintf("%.1f kts", ac.GS), Inline: true},
+			{Name: "Altitude", Value: fmtAltBaro(ac.AltBaro), Inline: true},
+			{Name: "Speed", Value: fmtOptSpeed(ac.GS), Inline: true},
 			{Name: "Owner", Value: fmt.Sprintf("%s%s", flagEmoji, details.Owner), Inline: false},
 			{Name: "Country", Value: details.CountryName, Inline: false},
 		}
 	} else {
 		fields = []Field{
-			{Name: "Callsign", Value: fmt.Sprintf("`%s`", ac.Flight), Inline: true},
+			{Name: "Callsign", Value: callsignLabel(ac.Flight), Inline: true},
 			{Name: "ICAO Hex", Value: fmt.Sprintf("`%s`", ac.Hex), Inline: true},
 			{Name: "Squawk", Value: fmt.Sprintf("`%s`", ac.Squawk), Inline: true},
 			{Name: "Registration", Value: fmt.Sprintf("`%s`", details.Registration), Inline: true},
 			{Name: "Aircraft Type", Value: fmt.Sprintf("`%s`", finalType), Inline: true},
-			{Name: "Altitude", Value: fmt.Sprintf("%s ft", altStr), Inline: true},
-			{Name: "Speed", Value: fmt.Sprintf("%.1f kts", ac.GS), Inline: true},
+			{Name: "Altitude", Value: fmtAltBaro(ac.AltBaro), Inline: true},
+			{Name: "Speed", Value: fmtOptSpeed(ac.GS), Inline: true},
 			{Name: "Owner", Value: details.Owner, Inline: false},
 			{Name: "Airline", Value: details.Airline, Inline: false},
 		}
 	}
 
+	fields = append(fields, milCallsignFields(ac.Flight)...)
+	fields = append(fields, routeFields(route)...)
+	fields = append(fields, modeSFields(rec.ModeS)...)
+	fields = append(fields, positionFields(ac)...)
+
+	if first, ok := firstSeen(ac.Hex); ok {
+		fields = append(fields, Field{Name: "First Seen", Value: fmt.Sprintf("%s (%s)", discordTime(first, "t"), discordTime(first, "R")), Inline: true})
+	}
+	fields = append(fields, Field{Name: "Alert Time", Value: discordTime(rec.Time, "f"), Inline: true})
+	if rec.Site != "" {
+		fields = append(fields, Field{Name: "Site", Value: rec.Site, Inline: true})
+	}
+
+	if alertType == "watchlist" {
+		fields = append(fields, watchlistEntryFields(*actx.Entry)...)
+	}
+
+	for i := range fields {
+		fields[i].Name = T(fields[i].Name)
+	}
+
 	embed := Embed{
 		Title:       title,
 		Description: description,
 		Color:       color,
 		URL:         fmt.Sprintf("https://globe.adsb.lol/?icao=%s", ac.Hex),
 		Fields:      fields,
-		Footer:      Footer{Text: "ADSB.lol Alerter"},
+		Footer:      Footer{Text: footerText(tags) + " · " + formatTime(rec.Time)},
 	}
 
 	if hasCoords {
-		embed.Image = Image{URL: generateMapURL(lat, lon)}
+		// An uploaded map doesn't need a URL Discord can reach
+		if mapURL := generateMapURL(lat, lon); mapURL != "" || cfg().Attachments.Map {
+			embed.Image = Image{URL: mapURL}
+			if credit := mapAttribution(); credit != "" {
+				embed.Footer.Text += " · " + credit
+			}
+		}
 	}
 
 	if details.ThumbnailURL != "" {
 		embed.Thumbnail = Thumbnail{URL: details.ThumbnailURL}
 	}
+	if alertType == "watchlist" && len(actx.Entry.ImageURLs) > 0 {
+		// The curator picked this photo for a reason
+		embed.Thumbnail = Thumbnail{URL: actx.Entry.ImageURLs[0]}
+	}
+	if hasAirline {
+		embed.Author = &Author{Name: airline.Name, IconURL: airlineLogo(airline)}
+	}
 
-	payload, _ := json.Marshal(DiscordWebhook{Embeds: []Embed{embed}})
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(payload))
-	if err != nil {
-		fmt.Printf("[Discord] Error sending alert: %v\n", err)
+	msg := DiscordWebhook{Embeds: []Embed{embed}}
+	if actx != nil {
+		msg.Content = actx.Mention
+	}
+	if photoMention != "" {
+		msg.Content = strings.TrimSpace(msg.Content + " " + photoMention)
+	}
+	rec.Embed, _ = json.Marshal(msg)
+
+	if isQuiet {
+		fmt.Printf("[QH] Quiet hours, holding '%s' alert for %s\n", alertType, ac.Hex)
+		holdAlert(webhookURL, rec, title)
+		rec.Outcome = "queued"
 		return
 	}
+
+	send := func() error { return postDiscordWebhook(webhookURL, msg) }
+	if files := attachAlertImages(&msg.Embeds[0], lat, lon, hasCoords); len(files) > 0 {
+		send = func() error { return postDiscordFiles(webhookURL, msg, files) }
+	}
+	if err := send(); err != nil {
+		fmt.Printf("[Discord] Error sending alert: %v\n", err)
+		rec.Outcome = "error"
+	} else {
+		fmt.Printf("[Discord] Successfully sent alert for %s (Type: %s, Tags: %v)\n", ac.Hex, alertType, tags)
+		rec.Outcome = "sent"
+		rec.Photos = archivePhotos(details)
+		go publishSocial(rec, details)
+	}
+	return
+}
+
+func postDiscordWebhook(webhookURL string, msg DiscordWebhook) error {
+	payload, _ := json.Marshal(msg)
+	resp, err := httpClient.Post(webhookURL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		fmt.Printf("[Discord] API returned non-2xx status: %s\n", resp.Status)
-	} else {
-		fmt.Printf("[Discord] Successfully sent alert for %s (Type: %s)\n", ac.Hex, alertType)
+		return fmt.Errorf("API returned non-2xx status: %s", resp.Status)
 	}
+	return nil
+}
+
+// postDiscordFile uploads a file attachment with an optional message.
+func postDiscordFile(webhookURL, content, filename string, data []byte) error {
+	return postDiscordFiles(webhookURL, DiscordWebhook{Content: content}, []discordFile{{Name: filename, Data: data}})
 }
 
 // --- Format helpers
 func getActualCoords(ac Aircraft) (lat float64, lon float64, hasCoords bool) {
-	// 1. Try to parse top-level fields (from /v2/point)
-	lat = parseFloat(ac.Lat)
-	lon = parseFloat(ac.Lon)
-
-	if lat != 0 && lon != 0 {
-		return lat, lon, true
+	// 1. Top-level fields (from /v2/point)
+	if ac.Lat.Valid && ac.Lon.Valid {
+		return ac.Lat.V, ac.Lon.V, true
 	}
 
-	// 2. Top-level failed. Try 'lastPosition' fields (from /v2/type)
-	lat = parseFloat(ac.LastPos.Lat)
-	lon = parseFloat(ac.LastPos.Lon)
-
-	if lat != 0 && lon != 0 {
-		return lat, lon, true
+	// 2. Top-level missing. Try 'lastPosition' fields (from /v2/type)
+	if ac.LastPos.Lat.Valid && ac.LastPos.Lon.Valid {
+		return ac.LastPos.Lat.V, ac.LastPos.Lon.V, true
 	}
 
 	return 0, 0, false
 }
+
+// onGround reports readsb's alt_baro "ground", i.e. the transponder says the
+// aircraft is on the ground. Check it before altitudeFeet where it matters.
+func onGround(ac Aircraft) bool {
+	s, ok := ac.AltBaro.(string)
+	return ok && strings.EqualFold(strings.TrimSpace(s), "ground")
+}
+
+// groundStateMatches applies a rule's on_ground filter; nil matches either.
+func groundStateMatches(want *bool, ac Aircraft) bool {
+	return want == nil || *want == onGround(ac)
+}
+
+// altitudeFeet returns the barometric altitude as a number. "ground" and
+// missing values report ok=false.
+func altitudeFeet(ac Aircraft) (float64, bool) {
+	altitudeFT, err := strconv.ParseFloat(formatAltitudeString(ac.AltBaro), 64)
+	return altitudeFT, err == nil
+}
 func formatAltitudeString(alt any) string {
 	switch v := alt.(type) {
 	case float64:
@@ -665,19 +1175,3 @@ func formatAltitudeString(alt any) string {
 		return "N/A"
 	}
 }
-func parseFloat(val any) float64 {
-	var f float64
-	var err error
-	switch v := val.(type) {
-	case float64:
-		f = v
-	case string:
-		f, err = strconv.ParseFloat(v, 64)
-		if err != nil {
-			f = 0.0
-		}
-	default:
-		f = 0.0
-	}
-	return f
-}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Map images ---
+// Alert maps come from Geoapify's static map API by default. With
+// renderer "local" they're drawn here from slippy-map tiles instead, which
+// are kept in an on-disk cache with a size cap. In offline mode only cached
+// tiles are used; anything missing is left blank rather than fetched.
+//
+// Static map keys are rotated round-robin, and a key that answers with a
+// quota or auth error is rested until the next UTC day (when Geoapify's
+// daily credits reset). Discord fetches embed images itself, so quota
+// errors are only seen on images we download (social posts, uploads).
+//
+// Positions are snapped to a grid cell before a map is made, and the URL or
+// image for each cell is cached, so an aircraft that re-alerts or crawls
+// along doesn't cost a new map API call each time.
+type MapConfig struct {
+	Renderer     string   `json:"renderer"`       // "geoapify" (default) or "local"
+	APIKeys      []string `json:"api_keys"`       // static map keys, default the built-in Geoapify key
+	StaticURL    string   `json:"static_url"`     // template with {lat} {lon} {zoom} {key}, default Geoapify's
+	TileURL      string   `json:"tile_url"`       // local renderer, default https://tile.openstreetmap.org/{z}/{x}/{y}.png
+	TileCacheDir string   `json:"tile_cache_dir"` // default "tiles"
+	TileCacheMB  int      `json:"tile_cache_mb"`  // default 200
+	Offline      bool     `json:"offline"`        // never fetch tiles, use the cache only
+	Zoom         int      `json:"zoom"`           // default 8
+	CellDeg      float64  `json:"cell_deg"`       // cache grid size in degrees, default 0.01 (about 0.6 nm); negative disables the cache
+	CacheTTL     Duration `json:"cache_ttl"`      // default 24h
+	CacheEntries int      `json:"cache_entries"`  // default 500
+}
+
+const (
+	mapWidth, mapHeight = 500, 300
+	tileSize            = 256
+	tilePruneInterval   = time.Minute
+)
+
+func (mc MapConfig) withDefaults() MapConfig {
+	if mc.TileURL == "" {
+		mc.TileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+	}
+	if mc.TileCacheDir == "" {
+		mc.TileCacheDir = "tiles"
+	}
+	if mc.TileCacheMB <= 0 {
+		mc.TileCacheMB = 200
+	}
+	if mc.Zoom <= 0 {
+		mc.Zoom = 8
+	}
+	if mc.CellDeg == 0 {
+		mc.CellDeg = 0.01
+	}
+	if mc.CacheTTL.Duration <= 0 {
+		mc.CacheTTL.Duration = 24 * time.Hour
+	}
+	if mc.CacheEntries <= 0 {
+		mc.CacheEntries = 500
+	}
+	if len(mc.APIKeys) == 0 {
+		mc.APIKeys = []string{geoapifyAPIKey}
+	}
+	if mc.StaticURL == "" {
+		mc.StaticURL = "https://maps.geoapify.com/v1/staticmap?style=osm-carto&width=500&height=300&center=lonlat:{lon},{lat}&zoom={zoom}&marker=lonlat:{lon},{lat};type:awesome;color:red&apiKey={key}"
+	}
+	return mc
+}
+
+func (mc MapConfig) local() bool {
+	return strings.EqualFold(mc.Renderer, "local") || offlineMode()
+}
+
+func generateMapURL(lat, lon float64) string {
+	lat, lon = mapCell(lat, lon)
+	if cfg().Map.local() {
+		// Served by our own API; Discord needs to be able to reach it
+		base := strings.TrimRight(cfg().Feed.BaseURL, "/")
+		if base == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s/map.png?lat=%.5f&lon=%.5f", base, lat, lon)
+	}
+	if e, ok := cachedMap(lat, lon); ok && e.URL != "" {
+		return e.URL
+	}
+	u, key := staticMapURL(lat, lon)
+	storeMap(lat, lon, func(e *mapCacheEntry) { e.URL, e.Key = u, key })
+	return u
+}
+
+// staticMapURL fills in the provider template with the next usable key.
+func staticMapURL(lat, lon float64) (string, string) {
+	mc := cfg().Map.withDefaults()
+	key := nextMapKey(mc.APIKeys)
+	return strings.NewReplacer(
+		"{lat}", strconv.FormatFloat(lat, 'f', 6, 64),
+		"{lon}", strconv.FormatFloat(lon, 'f', 6, 64),
+		"{zoom}", strconv.Itoa(mc.Zoom),
+		"{key}", key,
+	).Replace(mc.StaticURL), key
+}
+
+// --- Map key rotation ---
+var (
+	mapKeyNext    int
+	mapKeyResting = make(map[string]time.Time)
+	mapKeyMutex   = &sync.Mutex{}
+)
+
+// nextMapKey round-robins over the keys that aren't resting. If they all
+// are, the rotation carries on regardless; a failing image beats none.
+func nextMapKey(keys []string) string {
+	mapKeyMutex.Lock()
+	defer mapKeyMutex.Unlock()
+	for range keys {
+		key := keys[mapKeyNext%len(keys)]
+		mapKeyNext++
+		if time.Now().After(mapKeyResting[key]) {
+			return key
+		}
+	}
+	key := keys[mapKeyNext%len(keys)]
+	mapKeyNext++
+	return key
+}
+
+func restMapKey(key string) {
+	now := time.Now().UTC()
+	until := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	mapKeyMutex.Lock()
+	mapKeyResting[key] = until
+	mapKeyMutex.Unlock()
+	forgetMapKey(key)
+	fmt.Printf("[MAP] Map key ...%s hit its quota, resting it until %s\n", key[max(0, len(key)-4):], formatTime(until))
+}
+
+func isQuotaStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusPaymentRequired ||
+		code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// mapAttribution is owed to the tile provider when we draw the map.
+func mapAttribution() string {
+	if cfg().Map.local() {
+		return "Map © OpenStreetMap contributors"
+	}
+	return ""
+}
+
+// mapImage returns the alert map as PNG bytes, rendering or downloading it.
+func mapImage(lat, lon float64) ([]byte, error) {
+	lat, lon = mapCell(lat, lon)
+	if e, ok := cachedMap(lat, lon); ok && e.Data != nil {
+		return e.Data, nil
+	}
+	data, err := fetchMapImage(lat, lon)
+	if err == nil {
+		storeMap(lat, lon, func(e *mapCacheEntry) { e.Data = data })
+	}
+	return data, err
+}
+
+func fetchMapImage(lat, lon float64) ([]byte, error) {
+	if cfg().Map.local() {
+		return renderMap(lat, lon)
+	}
+	var lastErr error
+	for range cfg().Map.withDefaults().APIKeys {
+		u, key := staticMapURL(lat, lon)
+		resp, err := socialHTTP.Get(u)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxSocialImageBytes))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return data, err
+		}
+		lastErr = fmt.Errorf("static map returned %s", resp.Status)
+		if !isQuotaStatus(resp.StatusCode) {
+			break
+		}
+		restMapKey(key)
+	}
+	return nil, lastErr
+}
+
+// --- Map cache ---
+type mapCacheEntry struct {
+	URL     string
+	Key     string // map key baked into URL
+	Data    []byte
+	Created time.Time
+}
+
+var (
+	mapCache      = make(map[string]*mapCacheEntry)
+	mapCacheMutex = &sync.Mutex{}
+)
+
+// mapCell snaps a position to the centre of its cache cell.
+func mapCell(lat, lon float64) (float64, float64) {
+	cell := cfg().Map.withDefaults().CellDeg
+	if cell < 0 {
+		return lat, lon
+	}
+	return math.Round(lat/cell) * cell, math.Round(lon/cell) * cell
+}
+
+func mapCellKey(lat, lon float64) string {
+	return fmt.Sprintf("%.5f,%.5f", lat, lon)
+}
+
+func cachedMap(lat, lon float64) (mapCacheEntry, bool) {
+	mc := cfg().Map.withDefaults()
+	if mc.CellDeg < 0 {
+		return mapCacheEntry{}, false
+	}
+	mapCacheMutex.Lock()
+	defer mapCacheMutex.Unlock()
+	e, ok := mapCache[mapCellKey(lat, lon)]
+	if !ok || time.Since(e.Created) > mc.CacheTTL.Duration {
+		return mapCacheEntry{}, false
+	}
+	return *e, true
+}
+
+// storeMap updates a cell's entry, evicting the oldest cells over the cap.
+func storeMap(lat, lon float64, update func(*mapCacheEntry)) {
+	mc := cfg().Map.withDefaults()
+	if mc.CellDeg < 0 {
+		return
+	}
+	mapCacheMutex.Lock()
+	defer mapCacheMutex.Unlock()
+	k := mapCellKey(lat, lon)
+	e, ok := mapCache[k]
+	if !ok || time.Since(e.Created) > mc.CacheTTL.Duration {
+		e = &mapCacheEntry{Created: time.Now()}
+		mapCache[k] = e
+	}
+	update(e)
+
+	for len(mapCache) > mc.CacheEntries {
+		oldest := ""
+		for ck, ce := range mapCache {
+			if oldest == "" || ce.Created.Before(mapCache[oldest].Created) {
+				oldest = ck
+			}
+		}
+		delete(mapCache, oldest)
+	}
+}
+
+// forgetMapKey drops cached URLs built with a key that has stopped working.
+func forgetMapKey(key string) {
+	mapCacheMutex.Lock()
+	defer mapCacheMutex.Unlock()
+	for _, e := range mapCache {
+		if e.Key == key {
+			e.URL, e.Key = "", ""
+		}
+	}
+}
+
+// --- Local renderer ---
+
+// tileXY is the Web Mercator position of a point in tile units.
+func tileXY(lat, lon float64, zoom int) (float64, float64) {
+	n := math.Exp2(float64(zoom))
+	latR := lat * math.Pi / 180
+	x := (lon + 180) / 360 * n
+	y := (1 - math.Log(math.Tan(latR)+1/math.Cos(latR))/math.Pi) / 2 * n
+	return x, y
+}
+
+func renderMap(lat, lon float64) ([]byte, error) {
+	mc := cfg().Map.withDefaults()
+	img := image.NewRGBA(image.Rect(0, 0, mapWidth, mapHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{221, 221, 221, 255}}, image.Point{}, draw.Src)
+
+	tx, ty := tileXY(lat, lon, mc.Zoom)
+	if missing := drawTiles(img, mc, mc.Zoom, tx*tileSize-mapWidth/2, ty*tileSize-mapHeight/2); missing > 0 {
+		fmt.Printf("[MAP] %d tile(s) unavailable for %.3f, %.3f\n", missing, lat, lon)
+	}
+
+	drawMarker(img, mapWidth/2, mapHeight/2)
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// drawTiles fills img with the tiles whose pixel origin at zoom is
+// (left, top), returning how many couldn't be had.
+func drawTiles(img *image.RGBA, mc MapConfig, zoom int, left, top float64) int {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	n := 1 << zoom
+	missing := 0
+	for y := int(math.Floor(top / tileSize)); float64(y*tileSize) < top+h; y++ {
+		if y < 0 || y >= n {
+			continue
+		}
+		for x := int(math.Floor(left / tileSize)); float64(x*tileSize) < left+w; x++ {
+			tile, err := loadTile(mc, zoom, ((x%n)+n)%n, y)
+			if err != nil {
+				missing++
+				continue
+			}
+			at := image.Pt(int(math.Round(float64(x*tileSize)-left)), int(math.Round(float64(y*tileSize)-top)))
+			draw.Draw(img, tile.Bounds().Add(at), tile, tile.Bounds().Min, draw.Over)
+		}
+	}
+	return missing
+}
+
+// drawMarker puts a red dot with a white ring at (cx, cy).
+func drawMarker(img *image.RGBA, cx, cy int) {
+	for dy := -8; dy <= 8; dy++ {
+		for dx := -8; dx <= 8; dx++ {
+			switch d := dx*dx + dy*dy; {
+			case d <= 36:
+				img.SetRGBA(cx+dx, cy+dy, color.RGBA{220, 30, 30, 255})
+			case d <= 64:
+				img.SetRGBA(cx+dx, cy+dy, color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+}
+
+// --- Tile cache ---
+// Tiles live at <dir>/<z>/<x>/<y>.png. A hit bumps the file's mtime, and
+// when the cache grows past its cap the least recently used tiles go first.
+var (
+	tileHTTP      = newHTTPClient(15 * time.Second)
+	tilePruneMu   sync.Mutex
+	lastTilePrune time.Time
+)
+
+func tilePath(mc MapConfig, z, x, y int) string {
+	return filepath.Join(mc.TileCacheDir, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+".png")
+}
+
+func loadTile(mc MapConfig, z, x, y int) (image.Image, error) {
+	path := tilePath(mc, z, x, y)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	} else {
+		if mc.Offline || offlineMode() {
+			return nil, fmt.Errorf("tile %d/%d/%d not cached (offline)", z, x, y)
+		}
+		if data, err = fetchTile(mc, z, x, y); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			os.WriteFile(path, data, 0o644)
+		}
+		go pruneTileCache(mc)
+	}
+	tile, _, err := image.Decode(bytes.NewReader(data))
+	return tile, err
+}
+
+func fetchTile(mc MapConfig, z, x, y int) ([]byte, error) {
+	u := strings.NewReplacer("{z}", strconv.Itoa(z), "{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(mc.TileURL)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The OSM tile policy requires an identifying User-Agent
+	req.Header.Set("User-Agent", "flight-ingestor (+https://github.com/mtickle/flight-ingestor)")
+	resp, err := tileHTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile server returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func pruneTileCache(mc MapConfig) {
+	tilePruneMu.Lock()
+	defer tilePruneMu.Unlock()
+	if time.Since(lastTilePrune) < tilePruneInterval {
+		return
+	}
+	lastTilePrune = time.Now()
+
+	type cached struct {
+		path  string
+		size  int64
+		mtime time.Time
+	}
+	var files []cached
+	var total int64
+	filepath.WalkDir(mc.TileCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, cached{path, info.Size(), info.ModTime()})
+			total += info.Size()
+		}
+		return nil
+	})
+	limit := int64(mc.TileCacheMB) << 20
+	if total <= limit {
+		return
+	}
+	// Trim to 90% so we're not pruning on every new tile
+	sort.Slice(files, func(i, j int) bool { return files[i].mtime.Before(files[j].mtime) })
+	removed := 0
+	for _, f := range files {
+		if total <= limit*9/10 {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+			removed++
+		}
+	}
+	fmt.Printf("[MAP] Pruned %d tiles from %s (%d MB cap)\n", removed, mc.TileCacheDir, mc.TileCacheMB)
+}
+
+func handleMapPNG(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, errLat := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, errLon := strconv.ParseFloat(q.Get("lon"), 64)
+	if errLat != nil || errLon != nil || math.Abs(lat) > 85 || math.Abs(lon) > 180 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("lat and lon are required"))
+		return
+	}
+	data, err := mapImage(lat, lon)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(data)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// --- Mastodon notifier ---
+// Toots opted-in alerts with the map and aircraft photo attached.
+type MastodonConfig struct {
+	Server      string   `json:"server"`       // e.g. https://mastodon.social; empty disables
+	AccessToken string   `json:"access_token"` // needs write:statuses and write:media
+	Types       []string `json:"types"`        // alert types (or "rule:<name>"), default watchlist + special_military
+	Hashtags    []string `json:"hashtags"`
+	Visibility  string   `json:"visibility"` // public, unlisted, private; default public
+	NoImages    bool     `json:"no_images"`
+}
+
+func postMastodon(rec AlertRecord, images func() []socialImage) {
+	mc := cfg().Mastodon
+	if mc.Server == "" || mc.AccessToken == "" {
+		return
+	}
+	server := strings.TrimSuffix(mc.Server, "/")
+
+	form := url.Values{}
+	form.Set("status", socialText(rec, mc.Hashtags))
+	visibility := mc.Visibility
+	if visibility == "" {
+		visibility = "public"
+	}
+	form.Set("visibility", visibility)
+	if !mc.NoImages {
+		for _, img := range images() {
+			id, err := uploadMastodonMedia(server, mc.AccessToken, img)
+			if err != nil {
+				fmt.Printf("[MAS] Error uploading %s: %v\n", img.Name, err)
+				continue
+			}
+			form.Add("media_ids[]", id)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server+"/api/v1/statuses", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+mc.AccessToken)
+	req.Header.Set("Idempotency-Key", alertGUID(rec))
+	resp, err := socialHTTP.Do(req)
+	if err != nil {
+		fmt.Printf("[MAS] Error posting status: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Printf("[MAS] Mastodon returned non-2xx status: %s\n", resp.Status)
+		return
+	}
+	fmt.Printf("[MAS] Posted %s alert for %s\n", rec.Type, rec.Hex)
+}
+
+func uploadMastodonMedia(server, token string, img socialImage) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("description", img.Alt)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, img.Name))
+	h.Set("Content-Type", img.ContentType)
+	fw, err := mw.CreatePart(h)
+	if err != nil {
+		return "", err
+	}
+	fw.Write(img.Data)
+	mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server+"/api/v2/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := socialHTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// 202 means the media is still processing, which statuses accept anyway
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("non-2xx status: %s", resp.Status)
+	}
+	var media struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return "", err
+	}
+	return media.ID, nil
+}
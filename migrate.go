@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// --- Config schema versions ---
+// config.json carries a "version". When keys get renamed or restructured,
+// add a migration here rather than breaking old files: readConfig upgrades
+// older files in memory on every load and logs what it changed, and
+// "flight-ingestor migrate" writes the upgraded file back.
+const configSchemaVersion = 2
+
+type configMigration struct {
+	To      int
+	Note    string
+	Renames [][2]string            // dotted paths, old -> new, e.g. {"proximity.radius", "proximity.radius_nm"}
+	Apply   func(m map[string]any) // restructures that aren't plain renames
+	Removes []string               // top-level keys Apply moves elsewhere
+}
+
+var configMigrations = []configMigration{
+	{To: 1, Note: "config files are now stamped with a schema version"},
+	{To: 2, Note: `"source" is now "sources", a list tried in priority order`, Apply: migrateSources, Removes: []string{"source"}},
+}
+
+func migrateSources(m map[string]any) {
+	src, ok := m["source"]
+	if !ok {
+		return
+	}
+	delete(m, "source")
+	if _, exists := m["sources"]; !exists {
+		m["sources"] = []any{src}
+	}
+}
+
+// migrateConfig upgrades raw config JSON to the current schema. Files that
+// are already current come back byte-for-byte, so line numbers still match.
+func migrateConfig(data []byte) ([]byte, []string, error) {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return data, nil, err
+	}
+	version := 0
+	if v, ok := m["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > configSchemaVersion {
+		return data, []string{fmt.Sprintf("written for schema version %d, this build knows %d; newer keys will be ignored", version, configSchemaVersion)}, nil
+	}
+	if version == configSchemaVersion {
+		return data, nil, nil
+	}
+
+	var notes []string
+	for _, mig := range configMigrations {
+		if mig.To <= version {
+			continue
+		}
+		for _, r := range mig.Renames {
+			if note := renameConfigKey(m, r[0], r[1]); note != "" {
+				notes = append(notes, note)
+			}
+		}
+		if mig.Apply != nil {
+			mig.Apply(m)
+		}
+		notes = append(notes, fmt.Sprintf("schema %d: %s", mig.To, mig.Note))
+		version = mig.To
+	}
+	m["version"] = configSchemaVersion
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return data, notes, err
+	}
+	return append(out, '\n'), notes, nil
+}
+
+// renameConfigKey moves a value between dotted paths. When both exist the
+// new one wins and the old one is dropped.
+func renameConfigKey(m map[string]any, from, to string) string {
+	parent, key := configParent(m, from, false)
+	if parent == nil {
+		return ""
+	}
+	value, ok := parent[key]
+	if !ok {
+		return ""
+	}
+	delete(parent, key)
+	newParent, newKey := configParent(m, to, true)
+	if _, exists := newParent[newKey]; exists {
+		return fmt.Sprintf("%q is deprecated and was ignored because %q is also set", from, to)
+	}
+	newParent[newKey] = value
+	return fmt.Sprintf("%q is deprecated, moved to %q", from, to)
+}
+
+func configParent(m map[string]any, path string, create bool) (map[string]any, string) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+			next = make(map[string]any)
+			m[p] = next
+		}
+		m = next
+	}
+	return m, parts[len(parts)-1]
+}
+
+// deprecatedConfigPath reports keys a migration will rename, which aren't
+// unknown even though the current schema doesn't have them.
+func deprecatedConfigPath(path string) bool {
+	for _, mig := range configMigrations {
+		for _, r := range mig.Renames {
+			if r[0] == path {
+				return true
+			}
+		}
+		if slices.Contains(mig.Removes, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownConfigFields lists keys the current schema doesn't read.
+func unknownConfigFields(data []byte) []configProblem {
+	v := &configValidator{data: data, lines: make(map[string]int)}
+	if err := v.walk(json.NewDecoder(bytes.NewReader(data)), reflect.TypeFor[Config](), ""); err != nil {
+		return nil
+	}
+	return v.problems
+}
+
+// --- CLI: migrate ---
+//
+//	flight-ingestor migrate [FILE]
+//
+// Rewrites FILE at the current schema version, keeping the original as
+// FILE.bak. Keys come out sorted.
+func runMigrate(args []string) int {
+	path := configFile
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		fmt.Fprintln(os.Stderr, "usage: migrate [FILE]")
+		return 2
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	out, notes, err := migrateConfig(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	for _, n := range notes {
+		fmt.Printf("%s: %s\n", path, n)
+	}
+	if bytes.Equal(out, data) {
+		fmt.Printf("%s: already at schema version %d\n", path, configSchemaVersion)
+		return 0
+	}
+	if err := os.WriteFile(path+".bak", data, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s: upgraded to schema version %d (original saved as %s.bak)\n", path, configSchemaVersion, path)
+	return 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// --- Military callsign prefixes ---
+// Plenty of military traffic arrives without the mil flag (the feed's
+// database only knows about registered hexes), but the callsign usually
+// gives it away. A callsign matches when it starts with the prefix and the
+// next character is a digit, so "RCH451" matches RCH but "POLONIA1" doesn't
+// match POLO.
+type MilCallsign struct {
+	Prefix string `json:"prefix"`
+	Unit   string `json:"unit"` // what the prefix means, shown on alerts
+}
+
+var defaultMilCallsigns = []MilCallsign{
+	{"RCH", "USAF Air Mobility Command airlift"},
+	{"REACH", "USAF Air Mobility Command airlift"},
+	{"EVAC", "USAF aeromedical evacuation"},
+	{"SAM", "Special Air Mission (89th Airlift Wing VIP)"},
+	{"SPAR", "USAF executive transport"},
+	{"AF1", "Air Force One"},
+	{"AF2", "Air Force Two"},
+	{"DOOM", "USAF B-52 bomber"},
+	{"POLO", "USAF tanker"},
+	{"KING", "USAF HC-130 combat rescue"},
+	{"PEDRO", "USAF HH-60 rescue helicopter"},
+	{"SNTRY", "E-3 Sentry AWACS"},
+	{"NATO", "NATO AWACS"},
+	{"TEAL", "USAF Reserve hurricane hunters"},
+	{"CNV", "US Navy"},
+	{"PAT", "US Army priority air transport"},
+	{"CFC", "Royal Canadian Air Force"},
+	{"RRR", "Royal Air Force"},
+	{"ASCOT", "Royal Air Force"},
+	{"GAF", "German Air Force"},
+	{"IAM", "Italian Air Force"},
+	{"CTM", "French Air and Space Force"},
+	{"FAF", "French Air and Space Force"},
+	{"BAF", "Belgian Air Component"},
+	{"NAF", "Royal Netherlands Air Force"},
+}
+
+// militaryCallsigns merges config.json's military_callsigns over the built-in
+// table. A config entry with an empty unit removes a built-in prefix.
+// Longest prefix first, so a local "SAMU" entry beats the built-in SAM.
+func militaryCallsigns() []MilCallsign {
+	byPrefix := make(map[string]MilCallsign)
+	for _, m := range defaultMilCallsigns {
+		byPrefix[m.Prefix] = m
+	}
+	for _, m := range cfg().MilitaryCallsigns {
+		prefix := strings.ToUpper(strings.TrimSpace(m.Prefix))
+		if prefix == "" {
+			continue
+		}
+		if m.Unit == "" {
+			delete(byPrefix, prefix)
+			continue
+		}
+		byPrefix[prefix] = MilCallsign{prefix, m.Unit}
+	}
+
+	table := make([]MilCallsign, 0, len(byPrefix))
+	for _, m := range byPrefix {
+		table = append(table, m)
+	}
+	sort.Slice(table, func(i, j int) bool {
+		if len(table[i].Prefix) != len(table[j].Prefix) {
+			return len(table[i].Prefix) > len(table[j].Prefix)
+		}
+		return table[i].Prefix < table[j].Prefix
+	})
+	return table
+}
+
+func militaryCallsign(flight string) (MilCallsign, bool) {
+	flight = strings.ToUpper(strings.TrimSpace(flight))
+	for _, m := range militaryCallsigns() {
+		rest, ok := strings.CutPrefix(flight, m.Prefix)
+		if !ok {
+			continue
+		}
+		// Prefixes that already end in a digit (AF1) must match exactly
+		last := m.Prefix[len(m.Prefix)-1]
+		if last >= '0' && last <= '9' {
+			if rest == "" {
+				return m, true
+			}
+			continue
+		}
+		if rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return m, true
+		}
+	}
+	return MilCallsign{}, false
+}
+
+// isMilitary is the mil flag, or failing that a known military callsign.
+func isMilitary(ac Aircraft) bool {
+	if ac.Mil {
+		return true
+	}
+	_, ok := militaryCallsign(ac.Flight)
+	return ok
+}
+
+func milCallsignFields(flight string) []Field {
+	m, ok := militaryCallsign(flight)
+	if !ok {
+		return nil
+	}
+	return []Field{{Name: "Military Callsign", Value: "🎖️ " + m.Prefix + " · " + m.Unit, Inline: false}}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+
+	"main.go/geo"
+)
+
+// --- Extended Mode-S (Comm-B) data ---
+// readsb decodes the autopilot selections and air data some aircraft answer
+// with when interrogated (BDS 4,0 / 5,0 / 6,0) and derives wind from them.
+// The fields are only present when a local receiver or an aggregator that
+// passes them through is the source.
+
+// ModeSData is the subset carried on alert records and shown in embeds.
+type ModeSData struct {
+	SelectedAltFT *float64 `json:"selected_alt_ft,omitempty"` // MCP/FCU selected altitude
+	FMSAltFT      *float64 `json:"fms_alt_ft,omitempty"`
+	SelectedHdg   *float64 `json:"selected_heading,omitempty"`
+	IAS           *float64 `json:"ias_kt,omitempty"`
+	TAS           *float64 `json:"tas_kt,omitempty"`
+	Mach          *float64 `json:"mach,omitempty"`
+	WindDir       *float64 `json:"wind_dir,omitempty"`
+	WindKT        *float64 `json:"wind_kt,omitempty"`
+	OAT           *float64 `json:"oat_c,omitempty"`
+	QNH           *float64 `json:"qnh_hpa,omitempty"`
+}
+
+func modeSData(ac Aircraft) *ModeSData {
+	d := ModeSData{
+		SelectedAltFT: ac.NavAltMCP,
+		FMSAltFT:      ac.NavAltFMS,
+		SelectedHdg:   ac.NavHeading,
+		IAS:           ac.IAS,
+		TAS:           ac.TAS,
+		Mach:          ac.Mach,
+		WindDir:       ac.WindDir,
+		WindKT:        ac.WindSpeed,
+		OAT:           ac.OAT,
+		QNH:           ac.NavQNH,
+	}
+	if d == (ModeSData{}) {
+		return nil
+	}
+	return &d
+}
+
+// modeSFields renders the interesting parts as embed fields.
+func modeSFields(d *ModeSData) []Field {
+	if d == nil {
+		return nil
+	}
+	var fields []Field
+	if d.SelectedAltFT != nil {
+		fields = append(fields, Field{Name: "Selected Alt", Value: fmtAltitude(*d.SelectedAltFT), Inline: true})
+	}
+	if d.IAS != nil {
+		fields = append(fields, Field{Name: "IAS", Value: fmtSpeed(*d.IAS), Inline: true})
+	}
+	if d.Mach != nil && *d.Mach >= 0.4 {
+		fields = append(fields, Field{Name: "Mach", Value: fmt.Sprintf("%.2f", *d.Mach), Inline: true})
+	}
+	if d.WindDir != nil && d.WindKT != nil {
+		fields = append(fields, Field{Name: "Wind", Value: fmt.Sprintf("%03.0f° / %s", *d.WindDir, fmtSpeed(*d.WindKT)), Inline: true})
+	}
+	return fields
+}
+
+// --- Descent rules ---
+// Fires when an aircraft's selected altitude is well below where it is and
+// low enough to mean it's coming down to land nearby, e.g. selected 3000 ft
+// while at 9000 ft within 20 nm of the local field.
+type DescentRule struct {
+	Name             string     `json:"name"`
+	Lat              float64    `json:"lat"` // defaults to the observer
+	Lon              float64    `json:"lon"`
+	RadiusNM         float64    `json:"radius_nm"`
+	MaxSelectedAltFT float64    `json:"max_selected_alt_ft"`
+	MinDropFT        float64    `json:"min_drop_ft"` // selected vs current, default 1000
+	Classes          []string   `json:"classes"`
+	Channel          string     `json:"channel"`
+	Schedule         []Schedule `json:"schedule"`
+	Tags             []string   `json:"tags"`
+	PhotoMode        bool       `json:"photo_mode"`
+}
+
+func (r DescentRule) center(site sourceCenter) (float64, float64) {
+	if r.Lat == 0 && r.Lon == 0 {
+		return site.Lat, site.Lon
+	}
+	return r.Lat, r.Lon
+}
+
+func (r DescentRule) minDrop() float64 {
+	if r.MinDropFT > 0 {
+		return r.MinDropFT
+	}
+	return 1000
+}
+
+// descending reports whether the aircraft currently meets the rule, with the
+// current and selected altitude.
+func (r DescentRule) descending(site sourceCenter, ac Aircraft) (alt, selected float64, ok bool) {
+	lat, lon, hasCoords := getActualCoords(ac)
+	alt, hasAlt := altitudeFeet(ac)
+	if !hasCoords || !hasAlt || ac.NavAltMCP == nil || !matchesClass(ac, r.Classes) {
+		return 0, 0, false
+	}
+	centerLat, centerLon := r.center(site)
+	selected = *ac.NavAltMCP
+	ok = geo.DistanceNM(centerLat, centerLon, lat, lon) <= r.RadiusNM &&
+		selected <= r.MaxSelectedAltFT && alt-selected >= r.minDrop()
+	return alt, selected, ok
+}
+
+func processDescentAlerts(site sourceCenter, ac Aircraft) {
+	now := clock.Now()
+	for _, rule := range cfg().DescentRules {
+		key := ruleStateKey(rule.Name, ac.Hex)
+		alt, selected, ok := rule.descending(site, ac)
+		if !ok {
+			continue
+		}
+		state, seen := globalRuleState[key]
+		if !seen {
+			state.FirstSeen = now
+		}
+		state.LastSeen = now
+		if !state.Alerted && ruleActive(rule.Name, rule.Schedule, now) {
+			fmt.Printf("[Radius] !!! DESCENT: %s selected %.0f ft at %.0f ft, rule '%s'\n", ac.Hex, selected, alt, rule.Name)
+			details, _ := getAircraftDetails(ac.Hex)
+			sendDiscordAlert(resolveChannel(rule.Channel), ac, details, "descent", &AlertContext{
+				Rule:      rule.Name,
+				Tags:      rule.Tags,
+				PhotoMode: rule.PhotoMode,
+				Note:      fmt.Sprintf("**Selected %s** while at %s", fmtAltitude(selected), fmtAltitude(alt)),
+			})
+			state.Alerted = true
+		}
+		globalRuleState[key] = state
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// --- MQTT command channel ---
+// Subscribes to a command topic so Home Assistant / Node-RED can drive the
+// ingestor. Commands are JSON objects; the result of each one is published
+// to <command_topic>/response.
+//
+//	{"command":"mute","match":"hex","value":"a1b2c3","duration":"3h","reason":"medevac laps"}
+//	{"command":"unmute","match":"hex","value":"a1b2c3"}
+//	{"command":"watch_add","hex":"a1b2c3","note":"Neighbour's Cub","category":"Local"}
+//	{"command":"watch_remove","hex":"a1b2c3"}
+//	{"command":"silence_rule","rule":"proximity","duration":"2h"}
+//	{"command":"unsilence_rule","rule":"proximity"}
+//	{"command":"status"}
+type MQTTConfig struct {
+	Broker       string `json:"broker"` // e.g. tcp://homeassistant.local:1883; empty disables
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client_id"`     // default flight-ingestor
+	CommandTopic string `json:"command_topic"` // default flight-ingestor/command
+}
+
+type mqttCommand struct {
+	Command  string   `json:"command"`
+	Match    string   `json:"match"`
+	Value    string   `json:"value"`
+	Reason   string   `json:"reason"`
+	Duration Duration `json:"duration"`
+	Hex      string   `json:"hex"`
+	Note     string   `json:"note"`
+	Category string   `json:"category"`
+	Rule     string   `json:"rule"`
+}
+
+type mqttResponse struct {
+	Command string `json:"command"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Result  any    `json:"result,omitempty"`
+}
+
+func startMQTT() {
+	mc := cfg().MQTT
+	if mc.Broker == "" {
+		return
+	}
+	clientID := mc.ClientID
+	if clientID == "" {
+		clientID = "flight-ingestor"
+	}
+	topic := mc.CommandTopic
+	if topic == "" {
+		topic = "flight-ingestor/command"
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(mc.Broker).
+		SetClientID(clientID).
+		SetUsername(mc.Username).
+		SetPassword(mc.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(30 * time.Second)
+	// Subscribe on every (re)connect so a broker restart doesn't lose the subscription
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		fmt.Printf("[MQ] Connected to %s, listening on %s\n", mc.Broker, topic)
+		c.Subscribe(topic, 1, func(c mqtt.Client, msg mqtt.Message) {
+			resp := runMQTTCommand(msg.Payload())
+			payload, _ := json.Marshal(resp)
+			c.Publish(topic+"/response", 1, false, payload)
+		})
+	})
+	opts.SetConnectionLostHandler(func(c mqtt.Client, err error) {
+		fmt.Printf("[MQ] Connection lost: %v\n", err)
+	})
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		fmt.Printf("[MQ] Error connecting to %s: %v\n", mc.Broker, token.Error())
+	}
+}
+
+func runMQTTCommand(payload []byte) mqttResponse {
+	var cmd mqttCommand
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return mqttResponse{Error: fmt.Sprintf("invalid command: %v", err)}
+	}
+	resp := mqttResponse{Command: cmd.Command}
+	fail := func(err error) mqttResponse {
+		resp.Error = err.Error()
+		fmt.Printf("[MQ] Command %s failed: %v\n", cmd.Command, err)
+		return resp
+	}
+
+	switch strings.ToLower(cmd.Command) {
+	case "mute":
+		m := Mute{Match: cmd.Match, Value: cmd.Value, Reason: cmd.Reason}
+		if cmd.Duration.Duration > 0 {
+			until := clock.Now().Add(cmd.Duration.Duration)
+			m.Until = &until
+		}
+		if err := addMute(m); err != nil {
+			return fail(err)
+		}
+		saveMutes()
+		resp.Result = m
+	case "unmute":
+		if !removeMute(cmd.Match, cmd.Value) {
+			return fail(fmt.Errorf("no mute for %s=%s", cmd.Match, cmd.Value))
+		}
+		saveMutes()
+	case "watch_add":
+		if strings.TrimSpace(cmd.Hex) == "" {
+			return fail(fmt.Errorf("hex is required"))
+		}
+		addWatch(WatchlistEntry{ICAO: cmd.Hex, Note: cmd.Note, Category: cmd.Category})
+		fmt.Printf("[MQ] Added %s to the watchlist\n", cmd.Hex)
+	case "watch_remove":
+		if !removeWatch(cmd.Hex) {
+			return fail(fmt.Errorf("%s is not on the watchlist", cmd.Hex))
+		}
+		fmt.Printf("[MQ] Removed %s from the watchlist\n", cmd.Hex)
+	case "silence_rule":
+		if cmd.Rule == "" {
+			return fail(fmt.Errorf("rule is required"))
+		}
+		d := cmd.Duration.Duration
+		if d <= 0 {
+			d = time.Hour
+		}
+		until := clock.Now().Add(d)
+		silenceRule(cmd.Rule, until)
+		resp.Result = map[string]time.Time{"until": until}
+	case "unsilence_rule":
+		if !unsilenceRule(cmd.Rule) {
+			return fail(fmt.Errorf("rule %s is not silenced", cmd.Rule))
+		}
+	case "status":
+		resp.Result = ingestSnapshot()
+	default:
+		return fail(fmt.Errorf("unknown command %q", cmd.Command))
+	}
+	resp.OK = true
+	return resp
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Multiple sources ---
+// With more than one entry in "sources", every source is polled each cycle
+// and the answers are merged: one aircraft per hex, from whichever source
+// heard its position most recently. The first source that answered with
+// fresh data is the one the poll relies on; when that stops being the first
+// in the list (it errored, or its newest position is older than stale_after)
+// the ops channel hears about the failover, and again when it recovers.
+const defaultSourceStaleAfter = 2 * time.Minute
+
+type multiSource struct {
+	sources []Source
+	configs []SourceConfig
+}
+
+func newMultiSource(sources []Source, configs []SourceConfig) *multiSource {
+	return &multiSource{sources: sources, configs: configs}
+}
+
+func (m *multiSource) Name() string {
+	names := make([]string, len(m.sources))
+	for i, s := range m.sources {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// label tells two sources of the same type apart in logs.
+func (m *multiSource) label(i int) string {
+	return fmt.Sprintf("%s (sources[%d])", m.sources[i].Name(), i)
+}
+
+type sourceResult struct {
+	aircraft []Aircraft
+	err      error
+}
+
+func (m *multiSource) Fetch(ctx context.Context) ([]Aircraft, error) {
+	results := make([]sourceResult, len(m.sources))
+	var wg sync.WaitGroup
+	for i, src := range m.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aircraft, err := src.Fetch(ctx)
+			if err == nil {
+				staleAfter := m.configs[i].StaleAfter.Duration
+				if os, ok := src.(*openskySource); ok && staleAfter <= 0 {
+					// Between its spaced-out polls OpenSky serves aged answers
+					staleAfter = defaultSourceStaleAfter + os.interval
+				}
+				err = staleCheck(aircraft, staleAfter)
+			}
+			results[i] = sourceResult{aircraft, err}
+		}()
+	}
+	wg.Wait()
+
+	inUse := -1
+	var lists [][]Aircraft
+	var problems []string
+	for i, r := range results {
+		if r.err != nil {
+			fmt.Printf("[SRC] %s failed: %v\n", m.label(i), r.err)
+			problems = append(problems, fmt.Sprintf("%s: %v", m.label(i), r.err))
+			continue
+		}
+		if inUse < 0 {
+			inUse = i
+		}
+		lists = append(lists, r.aircraft)
+	}
+	m.noteFailover(inUse, problems)
+	if inUse < 0 {
+		return nil, fmt.Errorf("every source failed: %s", strings.Join(problems, "; "))
+	}
+	return mergeSourceAircraft(lists), nil
+}
+
+// staleCheck fails a source whose aircraft all have old positions, e.g. a
+// receiver whose aircraft.json stopped updating. An empty sky is fine.
+func staleCheck(aircraft []Aircraft, staleAfter time.Duration) error {
+	if staleAfter <= 0 {
+		staleAfter = defaultSourceStaleAfter
+	}
+	newest := -1.0
+	for _, ac := range aircraft {
+		if ac.SeenPos.Valid && (newest < 0 || ac.SeenPos.V < newest) {
+			newest = ac.SeenPos.V
+		}
+	}
+	if age := time.Duration(newest * float64(time.Second)); newest >= 0 && age > staleAfter {
+		return fmt.Errorf("stale data, newest position is %v old", age.Round(time.Second))
+	}
+	return nil
+}
+
+// mergeSourceAircraft keeps one aircraft per hex, the one with the freshest
+// position; on a tie (or no position at all) the higher priority source wins.
+func mergeSourceAircraft(lists [][]Aircraft) []Aircraft {
+	var merged []Aircraft
+	index := make(map[string]int)
+	for _, list := range lists {
+		for _, ac := range list {
+			i, ok := index[ac.Hex]
+			if !ok {
+				index[ac.Hex] = len(merged)
+				merged = append(merged, ac)
+				continue
+			}
+			if ac.SeenPos.Valid && (!merged[i].SeenPos.Valid || ac.SeenPos.V < merged[i].SeenPos.V) {
+				merged[i] = ac
+			}
+		}
+	}
+	return merged
+}
+
+var (
+	sourceInUse      = "" // label of the source the last poll relied on; "" before the first
+	sourceInUseMutex = &sync.Mutex{}
+)
+
+func (m *multiSource) noteFailover(inUse int, problems []string) {
+	label := "none"
+	if inUse >= 0 {
+		label = m.label(inUse)
+	}
+	sourceInUseMutex.Lock()
+	prev := sourceInUse
+	sourceInUse = label
+	sourceInUseMutex.Unlock()
+
+	// Starting on the primary is the normal case and says nothing
+	if label == prev || (prev == "" && inUse == 0) {
+		return
+	}
+	var title, message string
+	var color int
+	switch {
+	case inUse < 0:
+		fmt.Printf("[SRC] Every aircraft source is failing.\n")
+		title, message, color = "⚠️ All Aircraft Sources Down", strings.Join(problems, "\n"), 15548997
+	case inUse == 0:
+		fmt.Printf("[SRC] Back on the primary source, %s.\n", label)
+		title, message, color = "✅ Primary Source Recovered", fmt.Sprintf("Polling %s again.", label), 5763719
+	default:
+		fmt.Printf("[SRC] Failing over to %s.\n", label)
+		title, message, color = "⚠️ Source Failover", fmt.Sprintf("Now relying on %s.\n%s", label, strings.Join(problems, "\n")), 16753920
+	}
+	if !oneShot {
+		notifyOps(title, message, color)
+	}
+}
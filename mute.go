@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Runtime mutes ---
+// Silences alerts for a single aircraft (by hex, callsign or type) without
+// disabling the trigger for everyone else. Mutes come from config.json (and
+// follow it on reload) and can be added/removed at runtime through the HTTP
+// API.
+type Mute struct {
+	Match  string     `json:"match"` // "hex", "callsign" or "type"
+	Value  string     `json:"value"`
+	Until  *time.Time `json:"until,omitempty"` // nil = until removed
+	Reason string     `json:"reason,omitempty"`
+}
+
+var (
+	globalMutes = []Mute{}
+	muteMutex   = &sync.RWMutex{}
+	// Keys of the mutes config.json owns, which a reload replaces. A mute
+	// added at runtime for the same aircraft takes over from the config one.
+	configMuteKeys = map[string]bool{}
+)
+
+func muteKey(match, value string) string {
+	return strings.ToLower(match) + "=" + strings.ToLower(value)
+}
+
+func (m Mute) expired(now time.Time) bool {
+	return m.Until != nil && now.After(*m.Until)
+}
+
+func (m Mute) matches(ac Aircraft) bool {
+	var field string
+	switch strings.ToLower(m.Match) {
+	case "hex":
+		field = ac.Hex
+	case "callsign":
+		field = strings.TrimSpace(ac.Flight)
+	case "type":
+		field = ac.Type
+	default:
+		return false
+	}
+	return field != "" && strings.EqualFold(field, strings.TrimSpace(m.Value))
+}
+
+func validateMute(m Mute) error {
+	switch strings.ToLower(m.Match) {
+	case "hex", "callsign", "type":
+	default:
+		return fmt.Errorf("match must be one of hex, callsign, type (got %q)", m.Match)
+	}
+	if strings.TrimSpace(m.Value) == "" {
+		return fmt.Errorf("value is required")
+	}
+	return nil
+}
+
+func initMutes(mutes []Mute) {
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	globalMutes = globalMutes[:0]
+	clear(configMuteKeys)
+	for _, m := range mutes {
+		if err := validateMute(m); err != nil {
+			fmt.Printf("[MU] Ignoring invalid mute %+v: %v\n", m, err)
+			continue
+		}
+		globalMutes = append(globalMutes, m)
+		configMuteKeys[muteKey(m.Match, m.Value)] = true
+	}
+}
+
+// replaceConfigMutes swaps in the mutes of a reloaded config.json: ones it
+// no longer lists are dropped, mutes added at runtime are left alone.
+func replaceConfigMutes(mutes []Mute) {
+	keys := make(map[string]bool)
+	var valid []Mute
+	for _, m := range mutes {
+		if err := validateMute(m); err != nil {
+			fmt.Printf("[MU] Ignoring invalid mute %+v: %v\n", m, err)
+			continue
+		}
+		valid = append(valid, m)
+		keys[muteKey(m.Match, m.Value)] = true
+	}
+
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	kept := globalMutes[:0]
+	for _, m := range globalMutes {
+		key := muteKey(m.Match, m.Value)
+		if configMuteKeys[key] && !keys[key] {
+			fmt.Printf("[MU] Unmuted %s=%s (removed from config)\n", m.Match, m.Value)
+			continue
+		}
+		kept = append(kept, m)
+	}
+	globalMutes = kept
+	for _, m := range valid {
+		putMute(m)
+	}
+	configMuteKeys = keys
+}
+
+// isMuted returns the first active mute matching the aircraft. Expired mutes are dropped.
+func isMuted(ac Aircraft) (Mute, bool) {
+	now := clock.Now()
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+
+	active := globalMutes[:0]
+	var hit Mute
+	found := false
+	for _, m := range globalMutes {
+		if m.expired(now) {
+			fmt.Printf("[MU] Mute expired: %s=%s\n", m.Match, m.Value)
+			continue
+		}
+		active = append(active, m)
+		if !found && m.matches(ac) {
+			hit, found = m, true
+		}
+	}
+	globalMutes = active
+	return hit, found
+}
+
+// addMute adds or replaces a runtime mute (HTTP API, MQTT).
+func addMute(m Mute) error {
+	if err := validateMute(m); err != nil {
+		return err
+	}
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	putMute(m)
+	delete(configMuteKeys, muteKey(m.Match, m.Value))
+	return nil
+}
+
+// putMute replaces the mute for the same aircraft, or appends. The caller
+// holds muteMutex.
+func putMute(m Mute) {
+	for i, existing := range globalMutes {
+		if strings.EqualFold(existing.Match, m.Match) && strings.EqualFold(existing.Value, m.Value) {
+			globalMutes[i] = m
+			return
+		}
+	}
+	globalMutes = append(globalMutes, m)
+	fmt.Printf("[MU] Muted %s=%s\n", m.Match, m.Value)
+}
+
+func removeMute(match, value string) bool {
+	muteMutex.Lock()
+	defer muteMutex.Unlock()
+	for i, m := range globalMutes {
+		if strings.EqualFold(m.Match, match) && strings.EqualFold(m.Value, value) {
+			globalMutes = append(globalMutes[:i], globalMutes[i+1:]...)
+			delete(configMuteKeys, muteKey(match, value))
+			fmt.Printf("[MU] Unmuted %s=%s\n", match, value)
+			return true
+		}
+	}
+	return false
+}
+
+func listMutes() []Mute {
+	now := clock.Now()
+	muteMutex.RLock()
+	defer muteMutex.RUnlock()
+	out := []Mute{}
+	for _, m := range globalMutes {
+		if !m.expired(now) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
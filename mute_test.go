@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+// Mutes have to reach the alerts that aren't about a single rule match:
+// group alerts count only unmuted aircraft, and an overhead follow-up is
+// skipped for an aircraft muted after its heads-up went out.
+func TestMutesSilenceGroupAlertsAndFollowUps(t *testing.T) {
+	other := testAircraft()
+	other.Hex, other.Flight = "d4e5f6", "N456CD  "
+	rule := AggregateRule{Name: "pair", MinCount: 2, Channel: testWebhook}
+
+	cases := []struct {
+		name      string
+		mutes     []Mute
+		wantGroup int
+		wantOver  int
+	}{
+		{name: "unmuted", wantGroup: 1, wantOver: 1},
+		{name: "hex", mutes: []Mute{{Match: "hex", Value: "A1B2C3"}}, wantGroup: 0, wantOver: 0},
+		{name: "callsign", mutes: []Mute{{Match: "callsign", Value: "n123ab"}}, wantGroup: 0, wantOver: 0},
+		{name: "other aircraft", mutes: []Mute{{Match: "hex", Value: "ffffff"}}, wantGroup: 1, wantOver: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withTestEnv(t)
+			c := cfg()
+			c.AggregateRules = []AggregateRule{rule}
+			initMutes(tc.mutes)
+			clear(globalAggregateEpisodes)
+			t.Cleanup(func() {
+				initMutes(nil)
+				clear(globalAggregateEpisodes)
+			})
+
+			processAggregateAlerts(homeCenter(), []Aircraft{testAircraft(), other})
+			if got := len(stub.posts); got != tc.wantGroup {
+				t.Errorf("group alert: got %d posts, want %d", got, tc.wantGroup)
+			}
+
+			stub.posts = nil
+			sendOverheadFollowUp(OverheadConfig{FollowUp: true}, testWebhook, testAircraft(), "✅ Overhead Now", "", 0)
+			if got := len(stub.posts); got != tc.wantOver {
+				t.Errorf("overhead follow-up: got %d posts, want %d", got, tc.wantOver)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// --- Offline mode ---
+// For air-gapped or bandwidth-starved sites. The radius loop polls the local
+// receiver instead of adsb.lol, enrichment comes from a local aircraft
+// database, the watchlist from a local plane-alert-db CSV, and maps are
+// rendered from cached tiles only. adsb.lol, adsbdb, GitHub, Geoapify and
+// the other lookup services (routes, SIGMETs, feed status, the elevation
+// API) are never called. Notifiers still go wherever they're configured.
+type OfflineConfig struct {
+	Enabled     bool   `json:"enabled"`
+	ReceiverURL string `json:"receiver_url"` // readsb/tar1090 aircraft.json, default feeder.url
+	AircraftDB  string `json:"aircraft_db"`  // CSV with icao24, registration, typecode, owner, operator columns (OpenSky's aircraftDatabase.csv works)
+	Watchlist   string `json:"watchlist"`    // local plane-alert-db CSV, default plane-alert-db-images.csv
+}
+
+var errOffline = errors.New("disabled in offline mode")
+
+func offlineMode() bool {
+	return cfg().Offline.Enabled
+}
+
+// offlineReceiverURL is where the radius loop gets its aircraft from when
+// offline and no source is configured.
+func offlineReceiverURL() string {
+	if u := cfg().Offline.ReceiverURL; u != "" {
+		return u
+	}
+	return cfg().Feeder.URL
+}
+
+func offlineWatchlistPath() string {
+	if p := cfg().Offline.Watchlist; p != "" {
+		return p
+	}
+	return "plane-alert-db-images.csv"
+}
+
+// --- Local aircraft database ---
+var (
+	localDB      map[string]AircraftDetail
+	localDBPath  string
+	localDBMutex = &sync.Mutex{}
+)
+
+func localAircraftDetails(hex string) (AircraftDetail, error) {
+	path := cfg().Offline.AircraftDB
+	if path == "" {
+		return AircraftDetail{Hex: hex}, fmt.Errorf("no offline.aircraft_db configured")
+	}
+
+	localDBMutex.Lock()
+	defer localDBMutex.Unlock()
+	if localDB == nil || localDBPath != path {
+		db, err := loadLocalAircraftDB(path)
+		if err != nil {
+			return AircraftDetail{Hex: hex}, err
+		}
+		localDB, localDBPath = db, path
+		fmt.Printf("[EN] Loaded %d aircraft from %s\n", len(db), path)
+	}
+	detail, ok := localDB[strings.ToLower(hex)]
+	if !ok {
+		return AircraftDetail{Hex: hex}, fmt.Errorf("%s not in %s", hex, path)
+	}
+	return detail, nil
+}
+
+func loadLocalAircraftDB(path string) (map[string]AircraftDetail, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := parseWatchlistHeader(header)
+	first := func(row []string, names ...string) string {
+		for _, n := range names {
+			if v := cols.get(row, n); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	db := make(map[string]AircraftDetail)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		hex := strings.ToLower(first(row, "icao24", "icao", "hex"))
+		if hex == "" {
+			continue
+		}
+		d := AircraftDetail{
+			Hex:          hex,
+			Registration: first(row, "registration", "reg"),
+			AircraftType: first(row, "typecode", "icao type", "type"),
+			Owner:        first(row, "owner", "operator"),
+			Airline:      first(row, "operator", "operatoricao", "owner"),
+			CountryName:  first(row, "country"),
+		}
+		db[hex] = d
+	}
+	return db, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// --- CLI: once ---
+// One radius poll (and optionally one nationwide sweep), then exit. Meant
+// for cron-driven setups and for debugging a live sky without leaving the
+// daemon running.
+//
+//	flight-ingestor once [--nationwide] [--dry-run] [--json]
+//
+// Alerts go out as usual unless --dry-run is given, in which case the rules
+// are only evaluated and reported. Cooldowns and "already alerted" state live
+// in memory, so repeated cron runs only dedupe when state.redis_url is set.
+//
+// Source failover is logged but not sent to the ops channel: with nothing
+// remembered between runs, every cron run would report it again.
+var oneShot bool
+
+func runOnce(args []string) int {
+	oneShot = true
+	fs := flag.NewFlagSet("once", flag.ContinueOnError)
+	nationwide := fs.Bool("nationwide", false, "also run one nationwide special-type sweep")
+	dryRun := fs.Bool("dry-run", false, "evaluate rules without sending alerts")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: once [--nationwide] [--dry-run] [--json]")
+		return 2
+	}
+
+	// Keep stdout clean for the JSON; the usual log lines go to stderr
+	out := os.Stdout
+	if *asJSON {
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = out }()
+	}
+
+	initMutes(cfg().Mutes)
+	initHistoryDB()
+	initSharedState()
+	loadWatchlistFromCSV()
+
+	aircraft, err := fetchRadiusAircraft()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	var result onceResult
+	result.Aircraft = len(aircraft)
+	if *dryRun {
+		for _, ac := range aircraft {
+			if isBlocked(ac) {
+				continue
+			}
+			if matches := matchedRules(evaluateRules(ac)); len(matches) > 0 {
+				result.Matches = append(result.Matches, onceMatch{Hex: ac.Hex, Flight: strings.TrimSpace(ac.Flight), Type: ac.Type, Matches: matches})
+			}
+		}
+		if matches := matchedRules(evaluateAggregateRules(aircraft)); len(matches) > 0 {
+			result.Matches = append(result.Matches, onceMatch{Matches: matches})
+		}
+	} else {
+		startAlertCapture()
+		processRadiusSnapshotLocked(aircraft)
+		result.Alerts = stopAlertCapture()
+	}
+
+	if *nationwide {
+		if offlineMode() {
+			fmt.Println("[SM] Nationwide sweep skipped: not available offline")
+		} else if *dryRun {
+			for _, ac := range runNationwideCycle(true) {
+				result.Matches = append(result.Matches, onceMatch{Hex: ac.Hex, Flight: strings.TrimSpace(ac.Flight), Type: ac.Type,
+					Matches: []RuleMatch{{Rule: "special_military", Matched: true, Reason: "special type seen nationwide"}}})
+			}
+		} else {
+			startAlertCapture()
+			runNationwideCycle(false)
+			result.Alerts = append(result.Alerts, stopAlertCapture()...)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	result.print()
+	return 0
+}
+
+type onceResult struct {
+	Aircraft int           `json:"aircraft"`
+	Matches  []onceMatch   `json:"matches,omitempty"` // --dry-run
+	Alerts   []AlertRecord `json:"alerts,omitempty"`
+}
+
+type onceMatch struct {
+	Hex     string      `json:"hex,omitempty"` // empty for aggregate rules
+	Flight  string      `json:"flight,omitempty"`
+	Type    string      `json:"type,omitempty"`
+	Matches []RuleMatch `json:"matches"`
+}
+
+func matchedRules(matches []RuleMatch) []RuleMatch {
+	var out []RuleMatch
+	for _, m := range matches {
+		if m.Matched {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (r onceResult) print() {
+	fmt.Printf("%d aircraft in the radius\n", r.Aircraft)
+	for _, m := range r.Matches {
+		if m.Hex == "" {
+			fmt.Println("\n=== Aggregate rules ===")
+		} else {
+			fmt.Printf("\n=== %s %s (%s) ===\n", m.Hex, m.Flight, m.Type)
+		}
+		printMatches(m.Matches)
+	}
+	if len(r.Alerts) > 0 {
+		fmt.Println()
+	}
+	for _, a := range r.Alerts {
+		fmt.Printf("%-8s %-16s %-6s %-8s %s\n", a.Outcome, a.Type, a.Hex, a.Flight, a.Rule)
+	}
+	if len(r.Matches) == 0 && len(r.Alerts) == 0 {
+		fmt.Println("Nothing to alert on.")
+	}
+}
+
+// --- Alert capture ---
+// Lets "once" report what it sent without each alert path knowing about it.
+var (
+	capturedAlerts []AlertRecord
+	capturingAlert bool
+	captureMutex   = &sync.Mutex{}
+)
+
+func startAlertCapture() {
+	captureMutex.Lock()
+	capturingAlert, capturedAlerts = true, nil
+	captureMutex.Unlock()
+}
+
+func stopAlertCapture() []AlertRecord {
+	captureMutex.Lock()
+	defer captureMutex.Unlock()
+	capturingAlert = false
+	return capturedAlerts
+}
+
+func captureAlert(rec AlertRecord) {
+	captureMutex.Lock()
+	if capturingAlert {
+		capturedAlerts = append(capturedAlerts, rec)
+	}
+	captureMutex.Unlock()
+}
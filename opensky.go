@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- OpenSky Network ---
+// For sites outside adsb.lol coverage, or with OpenSky feeder credit. The
+// radius becomes a states/all bounding box (cut back to the circle after).
+// OpenSky bills in credits per day by box size: 1 credit up to 25 square
+// degrees, 2 to 100, 3 to 400, 4 beyond. Anonymous callers get 400 a day and
+// OAuth clients 4000 (8000 for active feeders), so polls are spaced to make
+// daily_credits last the day; in between, the last answer is served with its
+// positions aged. A 429 pauses the source for the Retry-After OpenSky sends,
+// which lets a second source take over.
+const (
+	openskyStatesURL = "https://opensky-network.org/api/states/all"
+	openskyTokenURL  = "https://auth.opensky-network.org/auth/realms/opensky-network/protocol/openid-connect/token"
+
+	openskyAnonymousCredits = 400
+	openskyClientCredits    = 4000
+)
+
+type openskyClient struct {
+	mu          sync.Mutex
+	id, secret  string
+	token       string
+	tokenExpiry time.Time
+	pausedUntil time.Time
+	cache       map[string]openskyPoll // by states URL
+}
+
+type openskyPoll struct {
+	at       time.Time
+	aircraft []Aircraft
+}
+
+var (
+	openskyClients      = make(map[string]*openskyClient) // by client_id, "" for anonymous
+	openskyClientsMutex = &sync.Mutex{}
+)
+
+func openskyClientFor(id, secret string) *openskyClient {
+	openskyClientsMutex.Lock()
+	defer openskyClientsMutex.Unlock()
+	c := openskyClients[id]
+	if c == nil || c.secret != secret {
+		c = &openskyClient{id: id, secret: secret, cache: make(map[string]openskyPoll)}
+		openskyClients[id] = c
+	}
+	return c
+}
+
+type openskySource struct {
+	client   *openskyClient
+	center   sourceCenter
+	url      string
+	interval time.Duration // shortest gap between billed requests
+}
+
+func newOpenSkySource(sc SourceConfig, center sourceCenter) *openskySource {
+	dLat := center.RadiusNM / 60
+	dLon := center.RadiusNM / (60 * math.Max(math.Cos(center.Lat*math.Pi/180), 0.01))
+	q := url.Values{}
+	q.Set("lamin", strconv.FormatFloat(center.Lat-dLat, 'f', 4, 64))
+	q.Set("lamax", strconv.FormatFloat(center.Lat+dLat, 'f', 4, 64))
+	q.Set("lomin", strconv.FormatFloat(center.Lon-dLon, 'f', 4, 64))
+	q.Set("lomax", strconv.FormatFloat(center.Lon+dLon, 'f', 4, 64))
+	q.Set("extended", "1")
+
+	credits := sc.DailyCredits
+	if credits <= 0 {
+		credits = openskyAnonymousCredits
+		if sc.ClientID != "" {
+			credits = openskyClientCredits
+		}
+	}
+	cost := openskyCreditCost(4 * dLat * dLon)
+	return &openskySource{
+		client:   openskyClientFor(sc.ClientID, sc.ClientSecret),
+		center:   center,
+		url:      openskyStatesURL + "?" + q.Encode(),
+		interval: 24 * time.Hour * time.Duration(cost) / time.Duration(credits),
+	}
+}
+
+// openskyCreditCost is what one states/all call over a box of this many
+// square degrees costs.
+func openskyCreditCost(sqDeg float64) int {
+	switch {
+	case sqDeg <= 25:
+		return 1
+	case sqDeg <= 100:
+		return 2
+	case sqDeg <= 400:
+		return 3
+	}
+	return 4
+}
+
+func (s *openskySource) Name() string { return "opensky" }
+
+func (s *openskySource) Fetch(ctx context.Context) ([]Aircraft, error) {
+	c := s.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := clock.Now()
+	if now.Before(c.pausedUntil) {
+		return nil, fmt.Errorf("opensky: rate limited for another %v", c.pausedUntil.Sub(now).Round(time.Second))
+	}
+	if last, ok := c.cache[s.url]; ok && now.Sub(last.at) < s.interval {
+		return agedAircraft(last.aircraft, now.Sub(last.at)), nil
+	}
+
+	aircraft, err := s.fetchStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aircraft = filterAround(aircraft, s.center.Lat, s.center.Lon, s.center.RadiusNM)
+	c.cache[s.url] = openskyPoll{at: now, aircraft: aircraft}
+	return aircraft, nil
+}
+
+// agedAircraft is a cached answer as it would look now.
+func agedAircraft(cached []Aircraft, age time.Duration) []Aircraft {
+	list := make([]Aircraft, len(cached))
+	for i, ac := range cached {
+		if ac.SeenPos.Valid {
+			ac.SeenPos.V += age.Seconds()
+		}
+		list[i] = ac
+	}
+	return list
+}
+
+// fetchStates makes the billed request. The caller holds c.mu.
+func (s *openskySource) fetchStates(ctx context.Context) ([]Aircraft, error) {
+	c := s.client
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.id != "" {
+			token, err := c.accessToken(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("opensky: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := sourceHTTP.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("opensky: error fetching states: %v", err)
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			if left := resp.Header.Get("X-Rate-Limit-Remaining"); left != "" {
+				debugf("[SRC] OpenSky credits left today: %s\n", left)
+			}
+			var body openskyStates
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				return nil, fmt.Errorf("opensky: error decoding JSON: %v", err)
+			}
+			return body.aircraft(), nil
+		case http.StatusUnauthorized:
+			// Tokens last 30 minutes; one may have expired early
+			if c.id != "" && attempt == 0 {
+				c.token = ""
+				continue
+			}
+			return nil, fmt.Errorf("opensky: unauthorized (%s); check client_id and client_secret", resp.Status)
+		case http.StatusTooManyRequests:
+			backoff := time.Hour
+			if secs, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Retry-After-Seconds")); err == nil && secs > 0 {
+				backoff = time.Duration(secs) * time.Second
+			}
+			fmt.Printf("[SRC] OpenSky credits used up, pausing for %v\n", backoff.Round(time.Second))
+			c.pausedUntil = clock.Now().Add(backoff)
+			return nil, fmt.Errorf("opensky: rate limited for %v", backoff.Round(time.Second))
+		}
+		return nil, fmt.Errorf("opensky returned non-200 status: %s", resp.Status)
+	}
+}
+
+// accessToken runs the OAuth client credentials flow, reusing the token
+// until shortly before it expires. The caller holds c.mu.
+func (c *openskyClient) accessToken(ctx context.Context) (string, error) {
+	if c.token != "" && clock.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.id)
+	form.Set("client_secret", c.secret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, openskyTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := sourceHTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error fetching token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s; check client_id and client_secret", resp.Status)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("unexpected token response")
+	}
+	c.token = tok.AccessToken
+	c.tokenExpiry = clock.Now().Add(time.Duration(max(tok.ExpiresIn-60, 30)) * time.Second)
+	return c.token, nil
+}
+
+// openskyStates is the states/all response: each state vector is an array,
+// in metres and metres per second.
+type openskyStates struct {
+	Time   int64   `json:"time"`
+	States [][]any `json:"states"`
+}
+
+func (o openskyStates) aircraft() []Aircraft {
+	str := func(v []any, i int) string {
+		if i < len(v) {
+			if s, ok := v[i].(string); ok {
+				return strings.TrimSpace(s)
+			}
+		}
+		return ""
+	}
+	num := func(v []any, i int) (float64, bool) {
+		if i < len(v) {
+			f, ok := v[i].(float64)
+			return f, ok
+		}
+		return 0, false
+	}
+
+	list := make([]Aircraft, 0, len(o.States))
+	for _, v := range o.States {
+		ac := Aircraft{Hex: str(v, 0), Flight: str(v, 1), Squawk: str(v, 14), MsgType: "opensky"}
+		if ac.Hex == "" {
+			continue
+		}
+		if t, ok := num(v, 3); ok && o.Time > 0 {
+			ac.SeenPos = someFloat(max(float64(o.Time)-t, 0))
+		}
+		if lon, ok := num(v, 5); ok {
+			if lat, ok := num(v, 6); ok {
+				ac.Lat, ac.Lon = someFloat(lat), someFloat(lon)
+			}
+		}
+		if alt, ok := num(v, 7); ok {
+			ac.AltBaro = math.Round(alt * 3.28084)
+		}
+		if len(v) > 8 && v[8] == true {
+			ac.AltBaro = "ground"
+		}
+		if gs, ok := num(v, 9); ok {
+			ac.GS = someFloat(math.Round(gs*1.943844*10) / 10)
+		}
+		if trk, ok := num(v, 10); ok {
+			ac.Track = &trk
+		}
+		if vr, ok := num(v, 11); ok {
+			fpm := math.Round(vr * 196.8504)
+			ac.BaroRate = &fpm
+		}
+		if cat, ok := num(v, 17); ok {
+			ac.Category = openskyCategory(int(cat))
+		}
+		list = append(list, ac)
+	}
+	return list
+}
+
+// openskyCategory maps OpenSky's numbered emitter categories onto the A1-C7
+// codes the feeds use.
+func openskyCategory(n int) string {
+	switch {
+	case n >= 2 && n <= 8:
+		return fmt.Sprintf("A%d", n-1)
+	case n >= 9 && n <= 15:
+		return fmt.Sprintf("B%d", n-8)
+	case n >= 16 && n <= 20:
+		return fmt.Sprintf("C%d", n-15)
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"main.go/geo"
+)
+
+// --- Operator-targeted rules ---
+// Matches the enriched owner/operator name, e.g. any "LifeFlight" aircraft
+// within 25nm. Enrichment only runs for aircraft that are already inside the
+// rule's radius and class filter, and is served from the detail cache.
+type OperatorRule struct {
+	Name      string     `json:"name"`
+	Match     []string   `json:"match"` // case-insensitive substrings of owner or airline
+	RadiusNM  float64    `json:"radius_nm"`
+	Classes   []string   `json:"classes"`
+	OnGround  *bool      `json:"on_ground"` // see DwellRule
+	Channel   string     `json:"channel"`
+	Schedule  []Schedule `json:"schedule"`
+	Tags      []string   `json:"tags"`
+	PhotoMode bool       `json:"photo_mode"` // see photomode.go
+}
+
+func (r OperatorRule) matchesOperator(details AircraftDetail) (string, bool) {
+	for _, name := range []string{details.Owner, details.Airline} {
+		lower := strings.ToLower(name)
+		for _, m := range r.Match {
+			if m != "" && strings.Contains(lower, strings.ToLower(m)) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func processOperatorAlerts(site sourceCenter, ac Aircraft) {
+	if len(cfg().OperatorRules) == 0 {
+		return
+	}
+	lat, lon, hasCoords := getActualCoords(ac)
+	if !hasCoords {
+		return
+	}
+	distanceNM := geo.DistanceNM(site.Lat, site.Lon, lat, lon)
+	now := clock.Now()
+
+	for _, rule := range cfg().OperatorRules {
+		if distanceNM > rule.RadiusNM || !matchesClass(ac, rule.Classes) || !groundStateMatches(rule.OnGround, ac) {
+			continue
+		}
+
+		key := ruleStateKey(rule.Name, ac.Hex)
+		state, seen := globalRuleState[key]
+		if !seen {
+			state.FirstSeen = now
+		}
+		state.LastSeen = now
+
+		if !state.Alerted && ruleActive(rule.Name, rule.Schedule, now) {
+			details, err := getAircraftDetails(ac.Hex)
+			if err == nil {
+				if operator, ok := rule.matchesOperator(details); ok {
+					fmt.Printf("[Radius] !!! OPERATOR MATCH: %s (%s) rule '%s'\n", ac.Hex, operator, rule.Name)
+					sendDiscordAlert(resolveChannel(rule.Channel), ac, details, "operator", &AlertContext{
+						Rule:      rule.Name,
+						Tags:      rule.Tags,
+						PhotoMode: rule.PhotoMode,
+						Note:      fmt.Sprintf("**Operator:** %s (%s)", operator, fmtDistance(distanceNM)),
+					})
+					state.Alerted = true
+				}
+			}
+		}
+		globalRuleState[key] = state
+	}
+}
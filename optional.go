@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Optional numeric fields ---
+// The feeds leave fields out, send null, or (some of the JSON exports) send
+// numbers as strings. OptFloat keeps "missing" apart from a genuine zero, so
+// an aircraft on the equator still has a position and a parked one still
+// has a ground speed.
+type OptFloat struct {
+	V     float64
+	Valid bool
+}
+
+func someFloat(v float64) OptFloat {
+	return OptFloat{V: v, Valid: true}
+}
+
+// Or returns the value, or def when it's missing.
+func (o OptFloat) Or(def float64) float64 {
+	if !o.Valid {
+		return def
+	}
+	return o.V
+}
+
+func (o *OptFloat) UnmarshalJSON(b []byte) error {
+	*o = OptFloat{}
+	if string(b) == "null" {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	parsed, err := parseOptFloat(v)
+	if err != nil {
+		// One bad field shouldn't drop the whole snapshot. Report it and
+		// treat the field as missing.
+		fmt.Printf("[AC] Ignoring invalid number %s: %v\n", string(b), err)
+		return nil
+	}
+	*o = parsed
+	return nil
+}
+
+func (o OptFloat) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.V)
+}
+
+// parseOptFloat accepts numbers and numeric strings. nil and "" are missing,
+// anything else is an error.
+func parseOptFloat(val any) (OptFloat, error) {
+	switch v := val.(type) {
+	case nil:
+		return OptFloat{}, nil
+	case float64:
+		return someFloat(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return OptFloat{}, err
+		}
+		return someFloat(f), nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return OptFloat{}, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return OptFloat{}, err
+		}
+		return someFloat(f), nil
+	}
+	return OptFloat{}, fmt.Errorf("unexpected %T", val)
+}
+
+// --- Position status ---
+// An aircraft's position is fresh, stale (readsb's seen_pos says it hasn't
+// been updated for a while, or it only came from /v2/type's lastPosition),
+// or missing altogether.
+type PositionStatus int
+
+const (
+	PositionMissing PositionStatus = iota
+	PositionStale
+	PositionFresh
+)
+
+// Positions older than this are stale
+const positionStaleAfter = 60 * time.Second
+
+func (s PositionStatus) String() string {
+	switch s {
+	case PositionFresh:
+		return "fresh"
+	case PositionStale:
+		return "stale"
+	}
+	return "missing"
+}
+
+func positionStatus(ac Aircraft) PositionStatus {
+	if ac.Lat.Valid && ac.Lon.Valid {
+		if ac.SeenPos.Valid && ac.SeenPos.V > positionStaleAfter.Seconds() {
+			return PositionStale
+		}
+		return PositionFresh
+	}
+	if ac.LastPos.Lat.Valid && ac.LastPos.Lon.Valid {
+		return PositionStale
+	}
+	return PositionMissing
+}
+
+// freshCoords is getActualCoords for rules that shouldn't act on an old fix.
+func freshCoords(ac Aircraft) (lat, lon float64, ok bool) {
+	if positionStatus(ac) != PositionFresh {
+		return 0, 0, false
+	}
+	return ac.Lat.V, ac.Lon.V, true
+}
+
+// positionFields flags alerts raised on a stale or missing position.
+func positionFields(ac Aircraft) []Field {
+	switch positionStatus(ac) {
+	case PositionStale:
+		value := "⚠️ Stale"
+		if ac.SeenPos.Valid {
+			value += fmt.Sprintf(" (last fix %v ago)", (time.Duration(ac.SeenPos.V) * time.Second).Round(time.Second))
+		}
+		return []Field{{Name: "Position", Value: value, Inline: true}}
+	case PositionMissing:
+		return []Field{{Name: "Position", Value: "⚠️ Missing", Inline: true}}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Overhead heads-up ---
+// Projects each aircraft along its track, speed and climb rate and warns a
+// few minutes before it's predicted to enter the innermost proximity tier,
+// which is enough time to get outside. Later polls then confirm the pass
+// when the aircraft arrives, or cancel the heads-up when the prediction
+// falls apart (a turn, a climb, or the entry time passing with no arrival).
+type OverheadConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Lead     Duration `json:"lead"`     // warn this far ahead, default 5m
+	MinLead  Duration `json:"min_lead"` // later than this isn't worth a heads-up, default 30s
+	Channel  string   `json:"channel"`  // default the proximity webhook
+	Mention  string   `json:"mention"`
+	FollowUp bool     `json:"follow_up"` // post the confirm/cancel
+}
+
+// overheadMisses is how many polls in a row the prediction has to fail
+// before the heads-up is cancelled; one bad track sample shouldn't do it.
+const overheadMisses = 2
+
+// OverheadPrediction is a straight-line projection to the innermost tier.
+type OverheadPrediction struct {
+	Tier      ProximityTier
+	EnterIn   time.Duration // until the aircraft crosses the tier radius
+	CPAIn     time.Duration // until closest approach
+	CPANM     float64       // ground distance at closest approach
+	SlantNM   float64       // line of sight from the antenna at closest approach
+	AltFT     float64       // projected altitude at entry, in the proximity altitude reference
+	Direction string        // where to look at closest approach
+}
+
+type overheadWatch struct {
+	Predicted time.Time // expected entry
+	Misses    int
+	LastSeen  time.Time
+	Webhook   string
+}
+
+var (
+	overheadWatches = make(map[string]*overheadWatch)
+	overheadMutex   = &sync.Mutex{}
+)
+
+func (oc OverheadConfig) withDefaults() OverheadConfig {
+	if oc.Lead.Duration <= 0 {
+		oc.Lead.Duration = 5 * time.Minute
+	}
+	if oc.MinLead.Duration <= 0 {
+		oc.MinLead.Duration = 30 * time.Second
+	}
+	return oc
+}
+
+// predictOverhead projects the aircraft on its current track. Nil when the
+// track misses the innermost tier or the aircraft will be too high there.
+func predictOverhead(ac Aircraft) *OverheadPrediction {
+	lat, lon, ok := freshCoords(ac)
+	alt, hasAlt := altitudeFeet(ac)
+	gs := ac.GS.Or(0)
+	if !ok || !hasAlt || onGround(ac) || ac.Track == nil || gs < 30 {
+		return nil
+	}
+	tier := proximityTiers()[0]
+
+	// A point ahead on the track defines the path
+	aheadLat, aheadLon := geo.Destination(lat, lon, *ac.Track, 10)
+	along := geo.AlongTrackNM(lat, lon, aheadLat, aheadLon, apiLat, apiLng)
+	cross := math.Abs(geo.CrossTrackNM(lat, lon, aheadLat, aheadLon, apiLat, apiLng))
+	if along <= 0 || cross > tier.RadiusNM {
+		return nil
+	}
+	enterNM := along - math.Sqrt(tier.RadiusNM*tier.RadiusNM-cross*cross)
+	enterIn := time.Duration(max(0, enterNM) / gs * float64(time.Hour))
+	cpaIn := time.Duration(along / gs * float64(time.Hour))
+
+	var rate float64
+	if ac.BaroRate != nil {
+		rate = *ac.BaroRate
+	}
+	altAtEnter := alt + rate*enterIn.Minutes()
+	altAtCPA := alt + rate*cpaIn.Minutes()
+	switch {
+	case cfg().Proximity.usesAGL():
+		// Terrain under the track near the observer is about the observer's
+		altAtEnter -= observerGroundFT()
+	case cfg().Proximity.usesObserver():
+		altAtEnter -= observerHeightFT()
+	}
+	if altAtEnter <= 0 || altAtEnter > tier.MaxAltFT {
+		return nil
+	}
+
+	cpaLat, cpaLon := geo.Destination(lat, lon, *ac.Track, along)
+	height := (altAtCPA - observerHeightFT()) / feetPerNM
+	return &OverheadPrediction{
+		Tier:      tier,
+		EnterIn:   enterIn,
+		CPAIn:     cpaIn,
+		CPANM:     cross,
+		SlantNM:   math.Hypot(cross, height),
+		AltFT:     altAtEnter,
+		Direction: compassWords(geo.InitialBearing(apiLat, apiLng, cpaLat, cpaLon)),
+	}
+}
+
+func (p OverheadPrediction) describe(now time.Time) string {
+	return fmt.Sprintf("**%s**\n%s",
+		T("Expected overhead at %s (in %s)", discordTime(now.Add(p.EnterIn), "T"), p.EnterIn.Round(time.Second)),
+		T("Closest approach %s %s, %s line of sight, at about %s", fmtDistance(p.CPANM), p.Direction, fmtDistance(p.SlantNM), fmtAltitude(p.AltFT)))
+}
+
+func processOverheadPredictions(aircraft []Aircraft) {
+	oc := cfg().Overhead.withDefaults()
+	if !oc.Enabled {
+		return
+	}
+	now := clock.Now()
+	active := ruleActive("overhead", cfg().Schedules["overhead"], now)
+
+	overheadMutex.Lock()
+	defer overheadMutex.Unlock()
+
+	for _, ac := range aircraft {
+		w, watching := overheadWatches[ac.Hex]
+		if watching {
+			w.LastSeen = now
+			followUpOverhead(oc, ac, w, now)
+			continue
+		}
+		if !active {
+			continue
+		}
+		if _, muted := isMuted(ac); muted {
+			continue
+		}
+		// Already in the zone; the proximity alert has it
+		if _, _, tier, in := proximityZone(homeCenter(), ac); in && tier.Name == proximityTiers()[0].Name {
+			continue
+		}
+		p := predictOverhead(ac)
+		if p == nil || p.EnterIn < oc.MinLead.Duration || p.EnterIn > oc.Lead.Duration {
+			continue
+		}
+
+		webhook := discordHookProximity
+		if oc.Channel != "" {
+			webhook = resolveChannel(oc.Channel)
+		}
+		fmt.Printf("[Radius] !!! OVERHEAD PREDICTED: %s in %s (CPA %.1f nm)\n", ac.Hex, p.EnterIn.Round(time.Second), p.CPANM)
+		details, _ := getAircraftDetails(ac.Hex)
+		outcome := sendDiscordAlert(webhook, ac, details, "overhead", &AlertContext{
+			Rule:    p.Tier.Name,
+			Mention: oc.Mention,
+			Note:    p.describe(now),
+		})
+		// Only a heads-up someone saw (or will, in the quiet-hours summary)
+		// gets a confirm or cancel
+		if outcome == "sent" || outcome == "queued" {
+			overheadWatches[ac.Hex] = &overheadWatch{Predicted: now.Add(p.EnterIn), LastSeen: now, Webhook: webhook}
+		}
+	}
+
+	// Gone from the feed: nothing left to confirm
+	for hex, w := range overheadWatches {
+		if now.Sub(w.LastSeen) > 2*time.Minute {
+			delete(overheadWatches, hex)
+		}
+	}
+}
+
+// followUpOverhead confirms or cancels an outstanding heads-up.
+func followUpOverhead(oc OverheadConfig, ac Aircraft, w *overheadWatch, now time.Time) {
+	name := ac.Hex
+	if ac.Flight != "" {
+		name = ac.Flight
+	}
+	if _, _, tier, in := proximityZone(homeCenter(), ac); in && tier.Name == proximityTiers()[0].Name {
+		delete(overheadWatches, ac.Hex)
+		late := now.Sub(w.Predicted).Round(time.Second)
+		sendOverheadFollowUp(oc, w.Webhook, ac, "✅ "+T("Overhead Now"),
+			T("`%s` arrived overhead (%s off the prediction).", name, late), 5763719) // Green
+		return
+	}
+
+	p := predictOverhead(ac)
+	switch {
+	case now.Sub(w.Predicted) > 2*time.Minute:
+		w.Misses = overheadMisses
+	case p == nil || p.EnterIn > oc.Lead.Duration+time.Minute:
+		w.Misses++
+	default:
+		w.Misses = 0
+		w.Predicted = now.Add(p.EnterIn)
+	}
+	if w.Misses >= overheadMisses {
+		delete(overheadWatches, ac.Hex)
+		sendOverheadFollowUp(oc, w.Webhook, ac, "❌ "+T("No Longer Expected Overhead"),
+			T("`%s` changed course or altitude and won't pass overhead.", name), 9807270) // Grey
+	}
+}
+
+// sendOverheadFollowUp skips an aircraft muted since its heads-up.
+func sendOverheadFollowUp(oc OverheadConfig, webhookURL string, ac Aircraft, title, message string, color int) {
+	hex := ac.Hex
+	fmt.Printf("[Radius] Overhead follow-up: %s - %s\n", hex, title)
+	if !oc.FollowUp || webhookURL == "" {
+		return
+	}
+	if m, muted := isMuted(ac); muted {
+		fmt.Printf("[Radius] %s is muted (%s=%s). Skipping overhead follow-up.\n", hex, m.Match, m.Value)
+		return
+	}
+	embed := Embed{
+		Title:       title,
+		Description: message,
+		Color:       color,
+		URL:         fmt.Sprintf("https://globe.adsb.lol/?icao=%s", hex),
+		Fields:      []Field{},
+		Footer:      Footer{Text: "ADSB.lol Alerter"},
+	}
+	if quietHold(webhookURL, "overhead", heldAlert{Title: title, Hex: hex}) != "" {
+		return
+	}
+	if err := postDiscordWebhook(webhookURL, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[Discord] Error sending overhead follow-up: %v\n", err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// --- Parquet sighting output ---
+// Sightings are buffered and flushed to Hive-style date partitions
+// (<dir>/date=2026-10-16/sightings-153000.parquet) so DuckDB/Athena can query
+// the whole tree with read_parquet('<dir>/*/*.parquet', hive_partitioning=1).
+type ParquetConfig struct {
+	Dir           string   `json:"dir"`            // empty disables Parquet output
+	FlushInterval Duration `json:"flush_interval"` // default 1h
+	MaxRows       int      `json:"max_rows"`       // flush early when the buffer reaches this, default 50000
+}
+
+type ParquetSighting struct {
+	Time       time.Time `parquet:"time,timestamp(millisecond)"`
+	Hex        string    `parquet:"hex,dict"`
+	Flight     string    `parquet:"flight,dict"`
+	Reg        string    `parquet:"reg,dict"`
+	Type       string    `parquet:"type,dict"`
+	Squawk     string    `parquet:"squawk,dict"`
+	Mil        bool      `parquet:"mil"`
+	AltBaro    string    `parquet:"alt_baro"`
+	GS         float64   `parquet:"gs"`
+	Lat        *float64  `parquet:"lat,optional"`
+	Lon        *float64  `parquet:"lon,optional"`
+	DistanceNM *float64  `parquet:"distance_nm,optional"`
+}
+
+var (
+	parquetBuffer []ParquetSighting
+	parquetDay    string
+	parquetMutex  = &sync.Mutex{}
+)
+
+func bufferParquetSighting(rec SightingRecord) {
+	pc := cfg().Parquet
+	if pc.Dir == "" {
+		return
+	}
+	parquetMutex.Lock()
+	defer parquetMutex.Unlock()
+
+	day := rec.Time.Format("2006-01-02")
+	if parquetDay != "" && day != parquetDay {
+		flushParquetLocked(pc.Dir) // keep partitions to a single day
+	}
+	parquetDay = day
+	parquetBuffer = append(parquetBuffer, ParquetSighting{
+		Time: rec.Time, Hex: rec.Hex, Flight: rec.Flight, Reg: rec.Reg, Type: rec.Type, Squawk: rec.Squawk,
+		Mil: rec.Mil, AltBaro: rec.AltBaro, GS: rec.GS, Lat: rec.Lat, Lon: rec.Lon, DistanceNM: rec.Distance,
+	})
+
+	maxRows := pc.MaxRows
+	if maxRows <= 0 {
+		maxRows = 50000
+	}
+	if len(parquetBuffer) >= maxRows {
+		flushParquetLocked(pc.Dir)
+	}
+}
+
+// manageParquet flushes the buffer on a timer.
+func manageParquet() {
+	interval := cfg().Parquet.FlushInterval.Duration
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if dir := cfg().Parquet.Dir; dir != "" {
+			parquetMutex.Lock()
+			flushParquetLocked(dir)
+			parquetMutex.Unlock()
+		}
+	}
+}
+
+// flushParquetLocked must be called with parquetMutex held.
+func flushParquetLocked(dir string) {
+	if len(parquetBuffer) == 0 {
+		return
+	}
+	rows := parquetBuffer
+	parquetBuffer = nil
+
+	partition := filepath.Join(dir, "date="+rows[0].Time.Format("2006-01-02"))
+	if err := os.MkdirAll(partition, 0o755); err != nil {
+		fmt.Printf("[PQ] Error creating %s: %v\n", partition, err)
+		return
+	}
+	path := filepath.Join(partition, fmt.Sprintf("sightings-%s.parquet", time.Now().Format("150405")))
+
+	// Write to a temp name first so readers never see half a file
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		fmt.Printf("[PQ] Error creating %s: %v\n", tmp, err)
+		return
+	}
+	w := parquet.NewGenericWriter[ParquetSighting](f, parquet.Compression(&parquet.Snappy))
+	if _, err := w.Write(rows); err != nil {
+		fmt.Printf("[PQ] Error writing %s: %v\n", path, err)
+	}
+	if err := w.Close(); err != nil {
+		fmt.Printf("[PQ] Error closing writer for %s: %v\n", path, err)
+	}
+	f.Close()
+	if err := os.Rename(tmp, path); err != nil {
+		fmt.Printf("[PQ] Error finalising %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("[PQ] Wrote %d sightings to %s\n", len(rows), path)
+}
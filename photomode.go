@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Golden-hour photo mode ---
+// Plane photographers care about what passes close by while the light is
+// good. Alerts from rules with "photo_mode": true (or from the built-in
+// triggers listed here) get a camera badge, a "golden-hour" tag and an
+// optional mention when the aircraft is within photo range and the sun is
+// low.
+type PhotoModeConfig struct {
+	Triggers        []string `json:"triggers"`          // built-in alert types, e.g. "military", "watchlist"
+	RangeNM         float64  `json:"range_nm"`          // default 5
+	MinSunElevation *float64 `json:"min_sun_elevation"` // degrees, default -4
+	MaxSunElevation *float64 `json:"max_sun_elevation"` // degrees, default 6
+	Mention         string   `json:"mention"`           // e.g. "<@&photographers-role-id>"
+}
+
+func (pc PhotoModeConfig) sunWindow() (lo, hi float64) {
+	lo, hi = -4, 6
+	if pc.MinSunElevation != nil {
+		lo = *pc.MinSunElevation
+	}
+	if pc.MaxSunElevation != nil {
+		hi = *pc.MaxSunElevation
+	}
+	return lo, hi
+}
+
+func goldenHour(site sourceCenter, t time.Time) (bool, float64) {
+	lo, hi := cfg().PhotoMode.sunWindow()
+	elevation, _ := sunPosition(t, site.Lat, site.Lon)
+	return elevation >= lo && elevation <= hi, elevation
+}
+
+// photoOpportunity reports whether an alert qualifies for photo mode, and
+// the note to add if so. Distance, bearing and light are from the alert's site.
+func photoOpportunity(site sourceCenter, alertType string, ac Aircraft, actx *AlertContext) (string, bool) {
+	pc := cfg().PhotoMode
+	if !slices.Contains(pc.Triggers, alertType) && (actx == nil || !actx.PhotoMode) {
+		return "", false
+	}
+	lat, lon, ok := getActualCoords(ac)
+	if !ok {
+		return "", false
+	}
+	rangeNM := pc.RangeNM
+	if rangeNM <= 0 {
+		rangeNM = 5
+	}
+	dist := geo.DistanceNM(site.Lat, site.Lon, lat, lon)
+	if dist > rangeNM {
+		return "", false
+	}
+	golden, sunElev := goldenHour(site, clock.Now())
+	if !golden {
+		return "", false
+	}
+	bearing := geo.InitialBearing(site.Lat, site.Lon, lat, lon)
+	_, sunAz := sunPosition(clock.Now(), site.Lat, site.Lon)
+	light := "side-lit"
+	switch diff := geo.AngleDiff(bearing, sunAz); {
+	case diff > 120:
+		light = "front-lit"
+	case diff < 60:
+		light = "backlit"
+	}
+	return fmt.Sprintf("📷 **Golden hour:** %s at %03.0f°, sun %.0f° up (%s)", fmtDistance(dist), bearing, sunElev, light), true
+}
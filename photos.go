@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Local photo archive ---
+// Downloads the adsbdb/planespotters images attached to sent alerts so the
+// alert history stays viewable after the remote hotlinks rot. Files are named
+// by a hash of their URL, so the same photo is only stored once.
+type PhotoArchiveConfig struct {
+	Dir        string  `json:"dir"` // empty disables the archive
+	MaxSizeMB  float64 `json:"max_size_mb"`
+	Thumbnails bool    `json:"thumbnails"` // also keep thumbnails, not just the full image
+}
+
+const photoDownloadTimeout = 30 * time.Second
+
+var (
+	photoClient = newHTTPClient(photoDownloadTimeout)
+	photoMutex  = &sync.Mutex{}
+)
+
+// archivePhotos stores the alert images and returns their archive file names.
+func archivePhotos(details AircraftDetail) []string {
+	pc := cfg().PhotoArchive
+	if pc.Dir == "" {
+		return nil
+	}
+	urls := []string{details.FullImageURL}
+	if pc.Thumbnails {
+		urls = append(urls, details.ThumbnailURL)
+	}
+
+	var names []string
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		name, err := archivePhoto(pc, u)
+		if err != nil {
+			fmt.Printf("[PH] Error archiving %s: %v\n", u, err)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func archivePhoto(pc PhotoArchiveConfig, photoURL string) (string, error) {
+	sum := sha256.Sum256([]byte(photoURL))
+	base := hex.EncodeToString(sum[:16])
+
+	photoMutex.Lock()
+	defer photoMutex.Unlock()
+
+	if matches, _ := filepath.Glob(filepath.Join(pc.Dir, base+".*")); len(matches) > 0 {
+		return filepath.Base(matches[0]), nil
+	}
+
+	resp, err := photoClient.Get(photoURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("non-200 status: %s", resp.Status)
+	}
+
+	maxBytes := int64(10 << 20)
+	if pc.MaxSizeMB > 0 {
+		maxBytes = int64(pc.MaxSizeMB * (1 << 20))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxBytes {
+		return "", fmt.Errorf("image larger than %d bytes", maxBytes)
+	}
+
+	name := base + photoExtension(photoURL, resp.Header.Get("Content-Type"))
+	if err := os.MkdirAll(pc.Dir, 0o755); err != nil {
+		return "", err
+	}
+	// Write to a temp file first so a crash never leaves a truncated photo behind
+	tmp := filepath.Join(pc.Dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(pc.Dir, name)); err != nil {
+		return "", err
+	}
+	fmt.Printf("[PH] Archived %s as %s\n", photoURL, name)
+	return name, nil
+}
+
+func photoExtension(photoURL, contentType string) string {
+	if u, err := url.Parse(photoURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp" || ext == ".gif" {
+			return ext
+		}
+	}
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".jpg"
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Per-poll ingest statistics ---
+// Every radius poll records how many aircraft came back, how many were new,
+// and how many were missing key fields. Exposed at GET /status (JSON) and
+// GET /metrics (Prometheus text format). When a poll returns no aircraft for
+// ops.zero_poll_threshold cycles in a row the ops channel is told, since that
+// usually means the upstream API or the config broke.
+type OpsConfig struct {
+	Channel           string `json:"channel"`
+	ZeroPollThreshold int    `json:"zero_poll_threshold"` // default 5
+}
+
+type PollStats struct {
+	Time            time.Time `json:"time"`
+	Aircraft        int       `json:"aircraft"`
+	New             int       `json:"new"`
+	Known           int       `json:"known"`
+	Blocked         int       `json:"blocked"`
+	MissingPosition int       `json:"missing_position"`
+	MissingAltitude int       `json:"missing_altitude"`
+	MissingCallsign int       `json:"missing_callsign"`
+	MissingType     int       `json:"missing_type"`
+	MissingSquawk   int       `json:"missing_squawk"`
+}
+
+type ingestStatus struct {
+	Started       time.Time  `json:"started"`
+	Polls         int64      `json:"polls"`
+	PollErrors    int64      `json:"poll_errors"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	ZeroPolls     int        `json:"consecutive_zero_polls"`
+	OpsAlerted    bool       `json:"ops_alerted"`
+	LastPoll      *PollStats `json:"last_poll,omitempty"`
+	AlertsSent    int64      `json:"alerts_sent"`
+	AlertsDropped int64      `json:"alerts_dropped"`
+
+	Feeder *FeederHealth `json:"feeder,omitempty"`
+	Feeds  []FeedStatus  `json:"feeds,omitempty"`
+}
+
+var (
+	ingest      = ingestStatus{Started: time.Now()}
+	ingestMutex = &sync.Mutex{}
+)
+
+// collectPollStats must run before the snapshot updates globalRadiusState.
+func collectPollStats(aircraft []Aircraft) PollStats {
+	stats := PollStats{Time: time.Now(), Aircraft: len(aircraft)}
+	for _, ac := range aircraft {
+		if _, known := globalRadiusState[ac.Hex]; known {
+			stats.Known++
+		} else {
+			stats.New++
+		}
+		if isBlocked(ac) {
+			stats.Blocked++
+		}
+		if _, _, ok := getActualCoords(ac); !ok {
+			stats.MissingPosition++
+		}
+		if _, ok := altitudeFeet(ac); !ok && !onGround(ac) {
+			stats.MissingAltitude++
+		}
+		if strings.TrimSpace(ac.Flight) == "" {
+			stats.MissingCallsign++
+		}
+		if ac.Type == "" {
+			stats.MissingType++
+		}
+		if ac.Squawk == "" {
+			stats.MissingSquawk++
+		}
+	}
+	return stats
+}
+
+func recordPollStats(stats PollStats) {
+	threshold := cfg().Ops.ZeroPollThreshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+
+	ingestMutex.Lock()
+	ingest.Polls++
+	ingest.LastPoll = &stats
+	var notify, recovered bool
+	if stats.Aircraft == 0 {
+		ingest.ZeroPolls++
+		if ingest.ZeroPolls >= threshold && !ingest.OpsAlerted {
+			ingest.OpsAlerted, notify = true, true
+		}
+	} else {
+		recovered = ingest.OpsAlerted
+		ingest.ZeroPolls, ingest.OpsAlerted = 0, false
+	}
+	zeroPolls := ingest.ZeroPolls
+	ingestMutex.Unlock()
+
+	if notify {
+		fmt.Printf("[OPS] %d consecutive polls returned no aircraft.\n", zeroPolls)
+		notifyOps("⚠️ Ingest Stalled", fmt.Sprintf("The last %d radius polls returned **0 aircraft**. Check the upstream API and the configuration.", zeroPolls), 16753920)
+	}
+	if recovered {
+		fmt.Println("[OPS] Polls are returning aircraft again.")
+		notifyOps("✅ Ingest Recovered", fmt.Sprintf("Radius polls are returning aircraft again (%d this poll).", stats.Aircraft), 5763719)
+	}
+}
+
+func recordPollError(err error) {
+	now := time.Now()
+	ingestMutex.Lock()
+	ingest.PollErrors++
+	ingest.LastError, ingest.LastErrorAt = err.Error(), &now
+	ingestMutex.Unlock()
+}
+
+func countAlertOutcome(rec AlertRecord) {
+	ingestMutex.Lock()
+	if rec.Outcome == "sent" {
+		ingest.AlertsSent++
+	} else {
+		ingest.AlertsDropped++
+	}
+	ingestMutex.Unlock()
+}
+
+func notifyOps(title, message string, color int) {
+	webhook := resolveChannel(cfg().Ops.Channel)
+	if webhook == "" {
+		return
+	}
+	embed := Embed{Title: title, Description: message, Color: color}
+	if err := postDiscordWebhook(webhook, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[OPS] Error notifying ops channel: %v\n", err)
+	}
+}
+
+func ingestSnapshot() ingestStatus {
+	ingestMutex.Lock()
+	defer ingestMutex.Unlock()
+	snap := ingest
+	if ingest.LastPoll != nil {
+		last := *ingest.LastPoll
+		snap.LastPoll = &last
+	}
+	return snap
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := ingestSnapshot()
+	status.Feeder = feederSnapshot()
+	status.Feeds = feedStatusSnapshot()
+	writeJSON(w, http.StatusOK, status)
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s := ingestSnapshot()
+	var b strings.Builder
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+	}
+	metric("flight_ingestor_polls_total", "counter", "Radius polls processed.", float64(s.Polls))
+	metric("flight_ingestor_poll_errors_total", "counter", "Radius polls that failed.", float64(s.PollErrors))
+	metric("flight_ingestor_zero_polls", "gauge", "Consecutive polls that returned no aircraft.", float64(s.ZeroPolls))
+	metric("flight_ingestor_alerts_sent_total", "counter", "Alerts posted.", float64(s.AlertsSent))
+	metric("flight_ingestor_alerts_dropped_total", "counter", "Alerts muted, unrouted or failed.", float64(s.AlertsDropped))
+	metric("flight_ingestor_uptime_seconds", "gauge", "Seconds since start.", time.Since(s.Started).Seconds())
+	if p := s.LastPoll; p != nil {
+		metric("flight_ingestor_last_poll_timestamp_seconds", "gauge", "Time of the last poll.", float64(p.Time.Unix()))
+		metric("flight_ingestor_poll_aircraft", "gauge", "Aircraft in the last poll.", float64(p.Aircraft))
+		metric("flight_ingestor_poll_new_aircraft", "gauge", "Aircraft in the last poll not seen recently.", float64(p.New))
+		metric("flight_ingestor_poll_known_aircraft", "gauge", "Aircraft in the last poll already being tracked.", float64(p.Known))
+		metric("flight_ingestor_poll_blocked_aircraft", "gauge", "Aircraft in the last poll dropped by the blocklist.", float64(p.Blocked))
+		fmt.Fprintf(&b, "# HELP flight_ingestor_poll_missing_field Aircraft in the last poll missing a field.\n# TYPE flight_ingestor_poll_missing_field gauge\n")
+		for _, f := range []struct {
+			field string
+			n     int
+		}{{"position", p.MissingPosition}, {"altitude", p.MissingAltitude}, {"callsign", p.MissingCallsign}, {"type", p.MissingType}, {"squawk", p.MissingSquawk}} {
+			fmt.Fprintf(&b, "flight_ingestor_poll_missing_field{field=%q} %d\n", f.field, f.n)
+		}
+	}
+	if f := feederSnapshot(); f != nil {
+		metric("flight_ingestor_feeder_message_rate", "gauge", "Messages/s decoded by the local receiver.", f.MessageRate)
+		metric("flight_ingestor_feeder_aircraft", "gauge", "Aircraft seen by the local receiver.", float64(f.Aircraft))
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// --- Profiles ---
+// Several people sharing one instance, each with their own site, zones,
+// watchlist, rules and notifier credentials. Every profile has a directory
+// holding its config.json (and .env), which is also where its state files,
+// caches and archives end up.
+//
+// The config, the alert state and the caches are all process-wide, so each
+// profile runs as a child process of this one rather than a goroutine; that
+// is what keeps one profile's cooldowns and credentials out of another's.
+// The parent restarts a profile that exits, tags its log lines with the
+// profile name and, with metrics_listen, serves every profile's /metrics as
+// one scrape with a profile label (each profile needs http_listen for that).
+//
+//	flight-ingestor profiles [FILE]    default profiles.json
+type ProfilesConfig struct {
+	Profiles      []Profile `json:"profiles"`
+	MetricsListen string    `json:"metrics_listen"` // e.g. ":9100"
+}
+
+type Profile struct {
+	Name     string `json:"name"`
+	Dir      string `json:"dir"`
+	Disabled bool   `json:"disabled"`
+}
+
+const profilesFile = "profiles.json"
+
+var (
+	profileProcs    = make(map[string]*os.Process)
+	profileMetrics  = make(map[string]string) // profile name -> host:port of its /metrics
+	profileStopping bool
+	profileMutex    = &sync.Mutex{}
+)
+
+// A hung profile fails its own scrape instead of holding up the merged one
+const profileScrapeTimeout = 5 * time.Second
+
+var profileScrapeHTTP = newHTTPClient(profileScrapeTimeout)
+
+func runProfiles(args []string) int {
+	path := profilesFile
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		fmt.Fprintln(os.Stderr, "usage: profiles [FILE]")
+		return 2
+	}
+	pc, err := readProfiles(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	var running []Profile
+	for _, p := range pc.Profiles {
+		if p.Disabled {
+			continue
+		}
+		running = append(running, p)
+		go runProfile(p, exe)
+	}
+	fmt.Printf("[PF] Running %d profile(s) from %s\n", len(running), path)
+	if pc.MetricsListen != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) { handleProfileMetrics(w, running) })
+			fmt.Printf("[PF] Serving profile metrics on %s\n", pc.MetricsListen)
+			if err := http.ListenAndServe(pc.MetricsListen, mux); err != nil {
+				fmt.Printf("[PF] Metrics server stopped: %v\n", err)
+			}
+		}()
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+	stopProfiles()
+	return 0
+}
+
+// readProfiles loads the profile list and checks the profiles can't tread
+// on each other.
+func readProfiles(path string) (*ProfilesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pc ProfilesConfig
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	base := filepath.Dir(path)
+	names := make(map[string]bool)
+	listens := make(map[string]string)
+	redis := make(map[string]string)
+	for i := range pc.Profiles {
+		p := &pc.Profiles[i]
+		if p.Name == "" || names[p.Name] {
+			return nil, fmt.Errorf("profiles[%d]: every profile needs a unique name", i)
+		}
+		names[p.Name] = true
+		if p.Dir == "" {
+			p.Dir = p.Name
+		}
+		if !filepath.IsAbs(p.Dir) {
+			p.Dir = filepath.Join(base, p.Dir)
+		}
+		if p.Disabled {
+			continue
+		}
+		c, err := readConfig(filepath.Join(p.Dir, defaultConfigFile))
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %v", p.Name, err)
+		}
+		if l := c.HTTPListen; l != "" {
+			if other, ok := listens[l]; ok {
+				return nil, fmt.Errorf("profiles %s and %s both listen on %s", other, p.Name, l)
+			}
+			listens[l] = p.Name
+		}
+		if u := c.State.RedisURL; u != "" {
+			key := u + " " + c.State.Prefix
+			if other, ok := redis[key]; ok {
+				return nil, fmt.Errorf("profiles %s and %s share Redis state; give each its own state.prefix", other, p.Name)
+			}
+			redis[key] = p.Name
+		}
+	}
+	return &pc, nil
+}
+
+// runProfile keeps one profile's process running, backing off like
+// supervise when it keeps dying.
+func runProfile(p Profile, exe string) {
+	backoff := superviseMinBackoff
+	for {
+		r, w := io.Pipe()
+		go prefixLines(p.Name, r)
+		cmd := exec.Command(exe, "--log-level", activeLogLevel.String())
+		cmd.Dir = p.Dir
+		cmd.Stdout, cmd.Stderr = w, w
+
+		// Read once per start; the child only picks up a new http_listen
+		// when it restarts anyway.
+		addr, addrErr := profileMetricsAddr(p)
+		if addrErr != nil {
+			fmt.Printf("[PF] Profile %s metrics won't be scraped: %v\n", p.Name, addrErr)
+		}
+
+		started := time.Now()
+		err := cmd.Start()
+		if err == nil {
+			profileMutex.Lock()
+			profileProcs[p.Name] = cmd.Process
+			profileMetrics[p.Name] = addr
+			profileMutex.Unlock()
+			err = cmd.Wait()
+		}
+		w.Close()
+
+		profileMutex.Lock()
+		delete(profileProcs, p.Name)
+		delete(profileMetrics, p.Name)
+		stopping := profileStopping
+		profileMutex.Unlock()
+		if stopping {
+			return
+		}
+		if time.Since(started) > superviseHealthyRun {
+			backoff = superviseMinBackoff
+		}
+		fmt.Printf("[PF] Profile %s exited (%v), restarting in %s\n", p.Name, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, superviseMaxBackoff)
+	}
+}
+
+func prefixLines(name string, r io.Reader) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fmt.Printf("[%s] %s\n", name, sc.Text())
+	}
+}
+
+func stopProfiles() {
+	profileMutex.Lock()
+	profileStopping = true
+	procs := make([]*os.Process, 0, len(profileProcs))
+	for _, proc := range profileProcs {
+		procs = append(procs, proc)
+	}
+	profileMutex.Unlock()
+	fmt.Printf("[PF] Stopping %d profile(s)\n", len(procs))
+	for _, proc := range procs {
+		proc.Signal(os.Interrupt)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		profileMutex.Lock()
+		left := len(profileProcs)
+		profileMutex.Unlock()
+		if left == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, proc := range procs {
+		proc.Kill()
+	}
+}
+
+// --- Profile metrics ---
+// Each profile's samples get a profile label; HELP and TYPE lines are
+// written once per metric so the merged scrape is still valid.
+type metricFamily struct {
+	meta    []string
+	samples []string
+}
+
+func handleProfileMetrics(w http.ResponseWriter, profiles []Profile) {
+	families := make(map[string]*metricFamily)
+	var order []string
+	family := func(name string) *metricFamily {
+		f, ok := families[name]
+		if !ok {
+			f = &metricFamily{}
+			families[name] = f
+			order = append(order, name)
+		}
+		return f
+	}
+
+	up := family("flight_ingestor_profile_up")
+	up.meta = []string{"# HELP flight_ingestor_profile_up Whether the profile's metrics could be scraped.", "# TYPE flight_ingestor_profile_up gauge"}
+	for _, p := range profiles {
+		body, err := scrapeProfile(p)
+		if err != nil {
+			up.samples = append(up.samples, fmt.Sprintf("flight_ingestor_profile_up{profile=%q} 0", p.Name))
+			continue
+		}
+		up.samples = append(up.samples, fmt.Sprintf("flight_ingestor_profile_up{profile=%q} 1", p.Name))
+		seenMeta := make(map[string]bool)
+		for _, line := range strings.Split(body, "\n") {
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE "):
+				fields := strings.Fields(line)
+				if len(fields) < 3 {
+					continue
+				}
+				f := family(fields[2])
+				if len(f.samples) == 0 || seenMeta[fields[2]] {
+					f.meta = append(f.meta, line)
+					seenMeta[fields[2]] = true
+				}
+			case strings.HasPrefix(line, "#"):
+			default:
+				name := line[:strings.IndexAny(line+" ", "{ ")]
+				f := family(name)
+				f.samples = append(f.samples, withProfileLabel(line, name, p.Name))
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range order {
+		f := families[name]
+		for _, l := range f.meta {
+			b.WriteString(l + "\n")
+		}
+		for _, l := range f.samples {
+			b.WriteString(l + "\n")
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+func withProfileLabel(line, name, profile string) string {
+	rest := line[len(name):]
+	if strings.HasPrefix(rest, "{") {
+		return fmt.Sprintf("%s{profile=%q,%s", name, profile, rest[1:])
+	}
+	return fmt.Sprintf("%s{profile=%q}%s", name, profile, rest)
+}
+
+// profileMetricsAddr is where a profile serves /metrics, from its
+// http_listen.
+func profileMetricsAddr(p Profile) (string, error) {
+	c, err := readConfig(filepath.Join(p.Dir, defaultConfigFile))
+	if err != nil {
+		return "", err
+	}
+	if c.HTTPListen == "" {
+		return "", fmt.Errorf("no http_listen")
+	}
+	host, port, err := net.SplitHostPort(c.HTTPListen)
+	if err != nil {
+		return "", err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func scrapeProfile(p Profile) (string, error) {
+	profileMutex.Lock()
+	addr := profileMetrics[p.Name]
+	profileMutex.Unlock()
+	if addr == "" {
+		return "", fmt.Errorf("not running or no http_listen")
+	}
+	resp, err := profileScrapeHTTP.Get("http://" + addr + "/metrics")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metrics returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	return string(data), err
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Proximity trigger ---
+// The zone is distance <= RadiusNM and 0 < altitude <= MaxAltFT. The optional
+// compound conditions cut down on high overflights that clip the circle.
+// With altitude_reference "agl" MaxAltFT is measured above the terrain under
+// the aircraft instead of barometric altitude, and with "observer" above the
+// receiver's antenna (see ObserverConfig).
+type ProximityConfig struct {
+	RadiusNM           float64 `json:"radius_nm"`
+	RadiusKM           float64 `json:"radius_km"` // instead of radius_nm
+	RadiusMI           float64 `json:"radius_mi"` // instead of radius_nm
+	MaxAltFT           float64 `json:"max_alt_ft"`
+	MaxAltM            float64 `json:"max_alt_m"`            // instead of max_alt_ft
+	AltitudeReference  string  `json:"altitude_reference"`   // "baro" (default), "agl" or "observer"
+	RequireDescending  bool    `json:"require_descending"`   // baro_rate must be negative
+	RequireApproaching bool    `json:"require_approaching"`  // track must point at the observer
+	MaxTrackOffsetDeg  float64 `json:"max_track_offset_deg"` // tolerance for "approaching"
+	MinDescentFPM      float64 `json:"min_descent_fpm"`      // descent rate counted as descending
+	IncludeGround      bool    `json:"include_ground"`       // aircraft on the ground inside the radius count as 0 ft
+
+	Tiers []ProximityTier `json:"tiers"` // replaces radius_nm/max_alt_ft when set
+}
+
+// ProximityTier is one zone of a tiered setup, e.g. "nearby" at 5 nm/5000 ft
+// and "overhead" at 1 nm/2500 ft. An aircraft alerts for the tightest tier
+// it's in, and again if it moves into a tighter one during the same visit.
+// Cooldown stops a tier re-alerting for the same aircraft on later visits
+// (a circling helicopter); 0 alerts once per visit. Title and Message are
+// text/templates over ProximityData.
+type ProximityTier struct {
+	Name     string   `json:"name"`
+	RadiusNM float64  `json:"radius_nm"`
+	RadiusKM float64  `json:"radius_km"`
+	RadiusMI float64  `json:"radius_mi"`
+	MaxAltFT float64  `json:"max_alt_ft"`
+	MaxAltM  float64  `json:"max_alt_m"`
+	Cooldown Duration `json:"cooldown"`
+	Channel  string   `json:"channel"` // default the proximity webhook
+	Mention  string   `json:"mention"`
+	Color    int      `json:"color"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+}
+
+// ProximityData is what tier templates see. Values are display strings.
+type ProximityData struct {
+	Tier      string
+	Flight    string
+	Airline   string
+	Type      string
+	Distance  string
+	Direction string
+	Altitude  string // in the configured altitude_reference
+	AltBaro   string
+}
+
+// applyUnits turns metric or statute thresholds into the nm/ft the zone
+// checks use. The km/mi/m keys win over nm/ft when both are set.
+func (p *ProximityConfig) applyUnits() {
+	p.RadiusNM = toNM(p.RadiusNM, p.RadiusKM, p.RadiusMI)
+	p.MaxAltFT = toFeet(p.MaxAltFT, p.MaxAltM)
+	for i := range p.Tiers {
+		t := &p.Tiers[i]
+		t.RadiusNM = toNM(t.RadiusNM, t.RadiusKM, t.RadiusMI)
+		t.MaxAltFT = toFeet(t.MaxAltFT, t.MaxAltM)
+	}
+}
+
+// proximityTiers lists the configured tiers tightest first, or the single
+// unnamed zone from radius_nm/max_alt_ft.
+func proximityTiers() []ProximityTier {
+	pc := cfg().Proximity
+	if len(pc.Tiers) == 0 {
+		return []ProximityTier{{RadiusNM: pc.RadiusNM, MaxAltFT: pc.MaxAltFT}}
+	}
+	tiers := append([]ProximityTier(nil), pc.Tiers...)
+	sort.SliceStable(tiers, func(i, j int) bool { return tiers[i].RadiusNM < tiers[j].RadiusNM })
+	return tiers
+}
+
+// proximityWebhook is where a tier's alerts go, and its "cleared" after
+// them. Tiers are looked up by name, which is what the radius state keeps.
+func proximityWebhook(tierName string) string {
+	for _, t := range proximityTiers() {
+		if t.Name == tierName && t.Channel != "" {
+			return resolveChannel(t.Channel)
+		}
+	}
+	return discordHookProximity
+}
+
+// proximityRank orders tiers by tightness; unknown names rank loosest.
+func proximityRank(name string) int {
+	tiers := proximityTiers()
+	for i, t := range tiers {
+		if t.Name == name {
+			return i
+		}
+	}
+	return len(tiers)
+}
+
+// proximityZone finds the tightest tier the aircraft is in around the site.
+func proximityZone(site sourceCenter, ac Aircraft) (distanceNM, altitudeFT float64, tier ProximityTier, inZone bool) {
+	lat, lon, hasCoords := getActualCoords(ac)
+	if !hasCoords {
+		return 0, 0, tier, false
+	}
+	tiers := proximityTiers()
+	outer := tiers[len(tiers)-1].RadiusNM
+	distanceNM = geo.DistanceNM(site.Lat, site.Lon, lat, lon)
+	if onGround(ac) {
+		if !cfg().Proximity.IncludeGround {
+			return distanceNM, 0, tier, false
+		}
+	} else {
+		var ok bool
+		altitudeFT, ok = altitudeFeet(ac)
+		if !ok || altitudeFT <= 0 || distanceNM > outer {
+			return distanceNM, altitudeFT, tier, false
+		}
+		switch {
+		case cfg().Proximity.usesAGL():
+			if altitudeFT, ok = altitudeAGL(ac); !ok {
+				return distanceNM, altitudeFT, tier, false
+			}
+		case cfg().Proximity.usesObserver():
+			if altitudeFT, ok = altitudeAboveObserver(ac); !ok || altitudeFT <= 0 {
+				return distanceNM, altitudeFT, tier, false
+			}
+		}
+	}
+	for _, t := range tiers {
+		if distanceNM <= t.RadiusNM && altitudeFT <= t.MaxAltFT {
+			return distanceNM, altitudeFT, t, true
+		}
+	}
+	return distanceNM, altitudeFT, tier, false
+}
+
+// --- Tier cooldowns ---
+var (
+	proximityAlertedAt    = make(map[string]time.Time) // hex|tier -> last alert
+	proximityAlertedMutex = &sync.Mutex{}
+)
+
+func proximityCooledDown(hex string, tier ProximityTier, now time.Time) bool {
+	proximityAlertedMutex.Lock()
+	defer proximityAlertedMutex.Unlock()
+	last, ok := proximityAlertedAt[hex+"|"+tier.Name]
+	return !ok || now.Sub(last) >= tier.Cooldown.Duration
+}
+
+func markProximityAlert(hex string, tier ProximityTier, now time.Time) {
+	proximityAlertedMutex.Lock()
+	defer proximityAlertedMutex.Unlock()
+	proximityAlertedAt[hex+"|"+tier.Name] = now
+	for k, t := range proximityAlertedAt {
+		if now.Sub(t) > 24*time.Hour {
+			delete(proximityAlertedAt, k)
+		}
+	}
+}
+
+// proximityContext builds the alert for a tier, rendering its templates.
+func proximityContext(site sourceCenter, ac Aircraft, tier ProximityTier, distanceNM, altitudeFT float64) *AlertContext {
+	actx := &AlertContext{
+		Rule:    tier.Name,
+		Mention: tier.Mention,
+		Color:   tier.Color,
+		Note:    proximityDescription(ac, tier.RadiusNM, altitudeFT),
+	}
+	lat, lon, _ := getActualCoords(ac)
+	data := ProximityData{
+		Tier:      tier.Name,
+		Flight:    ac.Flight,
+		Type:      ac.Type,
+		Distance:  fmtDistance(distanceNM),
+		Direction: compassWords(geo.InitialBearing(site.Lat, site.Lon, lat, lon)),
+		Altitude:  fmtAltitude(altitudeFT),
+		AltBaro:   fmtAltBaro(ac.AltBaro),
+	}
+	if a, _, ok := airlineFor(ac.Flight); ok {
+		data.Airline = a.Name
+	}
+	for _, t := range []struct {
+		tmpl string
+		out  *string
+	}{{tier.Title, &actx.Title}, {tier.Message, &actx.Note}} {
+		if t.tmpl == "" {
+			continue
+		}
+		text, err := renderProximityTemplate(t.tmpl, data)
+		if err != nil {
+			fmt.Printf("[Radius] Proximity tier '%s' template error: %v\n", tier.Name, err)
+			continue
+		}
+		*t.out = text
+	}
+	return actx
+}
+
+func renderProximityTemplate(tmpl string, data ProximityData) (string, error) {
+	t, err := template.New("proximity").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// proximityDescription is the default message, in the altitude reference
+// the zone is measured in.
+func proximityDescription(ac Aircraft, radiusNM, altitudeFT float64) string {
+	switch {
+	case cfg().Proximity.usesAGL():
+		return "**" + T("Aircraft is %s above ground (%s baro) within %s", fmtAltitude(altitudeFT), fmtAltBaro(ac.AltBaro), fmtRadius(radiusNM)) + "**"
+	case cfg().Proximity.usesObserver():
+		return "**" + T("Aircraft is %s above the observer (%s baro) within %s", fmtAltitude(altitudeFT), fmtAltBaro(ac.AltBaro), fmtRadius(radiusNM)) + "**"
+	}
+	return "**" + T("Aircraft is at %s within %s", fmtAltBaro(ac.AltBaro), fmtRadius(radiusNM)) + "**"
+}
+
+func (pc ProximityConfig) usesAGL() bool {
+	return strings.EqualFold(pc.AltitudeReference, "agl")
+}
+
+func (pc ProximityConfig) usesObserver() bool {
+	return strings.EqualFold(pc.AltitudeReference, "observer")
+}
+
+func proximityConditionsMet(site sourceCenter, ac Aircraft) bool {
+	pc := cfg().Proximity
+	if pc.RequireDescending {
+		if ac.BaroRate == nil || *ac.BaroRate > -pc.MinDescentFPM {
+			return false
+		}
+	}
+	if pc.RequireApproaching {
+		lat, lon, hasCoords := getActualCoords(ac)
+		if ac.Track == nil || !hasCoords {
+			return false
+		}
+		toObserver := geo.InitialBearing(lat, lon, site.Lat, site.Lon)
+		if geo.AngleDiff(*ac.Track, toObserver) > pc.MaxTrackOffsetDeg {
+			return false
+		}
+	}
+	return true
+}
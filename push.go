@@ -0,0 +1,93 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// --- Push receiver ---
+// POST /ingest accepts aircraft batches pushed by a remote receiver instead
+// of (or as well as) polling adsb.lol: readsb/tar1090 aircraft.json
+// ({"aircraft": [...]}), the adsb.lol shape ({"ac": [...]}) or a bare array
+// of the same objects. Bodies may be gzipped. Each batch runs through the
+// same pipeline as a poll.
+//
+//	curl -H "Authorization: Bearer $TOKEN" --data-binary @/run/readsb/aircraft.json http://ingestor:8080/ingest
+type PushConfig struct {
+	Token          string  `json:"token"`           // required; empty disables the endpoint
+	RadiusNM       float64 `json:"radius_nm"`       // drop positions further out, default: the poll radius
+	DisablePolling bool    `json:"disable_polling"` // rely on pushes only
+}
+
+const maxPushBytes = 32 << 20
+
+// radiusMutex serialises polls and pushes; the radius state isn't shared-safe.
+var radiusMutex = &sync.Mutex{}
+
+// filterToRadius drops aircraft placed outside radius of the observer and
+// normalises readsb's conventions (non-ICAO "~" hexes, the dbFlags mil bit).
+func filterToRadius(aircraft []Aircraft, radius float64) []Aircraft {
+	return filterAround(aircraft, apiLat, apiLng, radius)
+}
+
+func handlePush(w http.ResponseWriter, r *http.Request) {
+	pc := cfg().Push
+	if pc.Token == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("push receiver is disabled"))
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(pc.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxPushBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer gz.Close()
+		// Bound the decompressed stream too, so a small gzip bomb can't
+		// expand past the limit in memory.
+		body = io.LimitReader(gz, maxPushBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || len(data) > maxPushBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("push body exceeds %d bytes", maxPushBytes))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	aircraft, err := decodeAircraftList(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var counters feederCounters
+	if json.Unmarshal(data, &counters) == nil {
+		noteFeederSample(counters)
+	}
+
+	radius := pc.RadiusNM
+	if radius <= 0 {
+		radius = apiRadiusNM
+	}
+	inRange := mergeUAT(filterToRadius(aircraft, radius))
+	archiveRawPoll(data, clock.Now())
+	processRadiusSnapshotLocked(inRange)
+
+	writeJSON(w, http.StatusAccepted, map[string]int{"received": len(aircraft), "processed": len(inRange)})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Quiet hours ---
+// Do-not-disturb windows per alert channel, keyed by channel name (or raw
+// webhook URL). During a window alerts for that channel are either dropped
+// ("suppress") or held back ("queue") and posted as one summary when the
+// window ends. Follow-ups, group alerts, recaps, tracks and heatmaps obey
+// the same window; a held file is only listed in the summary. Alert types
+// in "except" always go straight through, so an emergency still wakes you
+// up. The queue lives in memory; alerts held when the process restarts are
+// lost.
+type QuietHours struct {
+	Windows  []Schedule `json:"windows"`  // same format as rule schedules
+	Timezone string     `json:"timezone"` // IANA name, default display.timezone
+	Mode     string     `json:"mode"`     // "suppress" (default) or "queue"
+	Except   []string   `json:"except"`   // alert types that ignore quiet hours, e.g. "emergency"; also "recap", "track", "heatmap"
+}
+
+const quietHoursCheckInterval = time.Minute
+
+// At most this many alerts are listed in a quiet-hours summary
+const quietSummaryMax = 25
+
+type heldAlert struct {
+	Time  time.Time
+	Title string
+	Hex   string
+	Label string // callsign, or the hex when there isn't one
+}
+
+var (
+	quietQueue      = make(map[string][]heldAlert) // by webhook URL
+	quietQueueMutex = &sync.Mutex{}
+)
+
+func (q QuietHours) queues() bool {
+	return strings.EqualFold(q.Mode, "queue")
+}
+
+func (q QuietHours) active(alertType string, t time.Time) bool {
+	if slices.Contains(q.Except, alertType) {
+		return false
+	}
+	loc := displayLocation()
+	if q.Timezone != "" {
+		loc = cachedLocation(q.Timezone)
+	}
+	for _, w := range q.Windows {
+		if w.active(t.In(loc)) {
+			return true
+		}
+	}
+	return false
+}
+
+// quietHoursFor finds the quiet-hours entry for the channel a webhook URL
+// belongs to.
+func quietHoursFor(webhookURL string) (QuietHours, bool) {
+	if webhookURL == "" {
+		// An unset channel name resolves to "" too
+		return QuietHours{}, false
+	}
+	for name, q := range cfg().QuietHours {
+		if resolveChannel(name) == webhookURL {
+			return q, true
+		}
+	}
+	return QuietHours{}, false
+}
+
+// quietHold applies the channel's quiet hours to a post that doesn't go
+// through sendDiscordAlert: group alerts, follow-ups, recaps, tracks and
+// heatmaps. It returns "quiet" when the post is dropped, "queued" when a
+// line for it was added to the summary, and "" when it may go out now.
+func quietHold(webhookURL, alertType string, held heldAlert) string {
+	q, ok := quietHoursFor(webhookURL)
+	if !ok || !q.active(alertType, clock.Now()) {
+		return ""
+	}
+	if !q.queues() {
+		fmt.Printf("[QH] Quiet hours, dropping %s\n", held.Title)
+		return "quiet"
+	}
+	fmt.Printf("[QH] Quiet hours, holding %s\n", held.Title)
+	if held.Time.IsZero() {
+		held.Time = clock.Now()
+	}
+	if held.Label == "" {
+		held.Label = held.Hex
+	}
+	quietQueueMutex.Lock()
+	quietQueue[webhookURL] = append(quietQueue[webhookURL], held)
+	quietQueueMutex.Unlock()
+	return "queued"
+}
+
+// holdAlert queues an alert for the end of the channel's quiet window.
+func holdAlert(webhookURL string, rec AlertRecord, title string) {
+	label := strings.TrimSpace(rec.Flight)
+	if label == "" {
+		label = rec.Hex
+	}
+	quietQueueMutex.Lock()
+	quietQueue[webhookURL] = append(quietQueue[webhookURL], heldAlert{Time: rec.Time, Title: title, Hex: rec.Hex, Label: label})
+	quietQueueMutex.Unlock()
+}
+
+func manageQuietHours() {
+	ticker := time.NewTicker(quietHoursCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		flushQuietQueues(clock.Now())
+	}
+}
+
+// flushQuietQueues posts the summary for every channel whose window has
+// ended. A channel that was removed from quiet_hours is flushed too. A
+// summary that fails to post stays queued for the next check.
+func flushQuietQueues(now time.Time) {
+	quietQueueMutex.Lock()
+	due := make(map[string][]heldAlert)
+	for webhook, held := range quietQueue {
+		if q, ok := quietHoursFor(webhook); ok && q.active("", now) {
+			continue
+		}
+		due[webhook] = held
+		delete(quietQueue, webhook)
+	}
+	quietQueueMutex.Unlock()
+
+	for webhook, held := range due {
+		embed := quietSummary(held)
+		if err := postDiscordWebhook(webhook, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+			fmt.Printf("[QH] Error posting quiet-hours summary, retrying next check: %v\n", err)
+			// Back in the queue ahead of anything held since
+			quietQueueMutex.Lock()
+			quietQueue[webhook] = append(held, quietQueue[webhook]...)
+			quietQueueMutex.Unlock()
+			continue
+		}
+		fmt.Printf("[QH] Posted %d held alerts\n", len(held))
+	}
+}
+
+func quietSummary(held []heldAlert) Embed {
+	var lines []string
+	for i, h := range held {
+		if i == quietSummaryMax {
+			lines = append(lines, T("…and %d more", len(held)-i))
+			break
+		}
+		line := fmt.Sprintf("%s · %s", discordTime(h.Time, "t"), h.Title)
+		if h.Hex != "" {
+			line += fmt.Sprintf(" · [%s](https://globe.adsb.lol/?icao=%s)", h.Label, h.Hex)
+		}
+		lines = append(lines, line)
+	}
+	return Embed{
+		Title:       T("🌙 Quiet hours: %d alerts held", len(held)),
+		Description: strings.Join(lines, "\n"),
+		Color:       3426654, // Navy
+		Footer:      Footer{Text: formatTime(clock.Now())},
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// --- adsbdb rate limiting ---
+// Every adsbdb call (aircraft details and callsign routes) takes a token from
+// one shared bucket. Callers queue for a token rather than fail, up to
+// max_wait. A 429 from adsbdb pauses the bucket for its Retry-After. On top
+// of that, concurrent detail lookups for the same hex share a single request.
+type AdsbdbConfig struct {
+	RatePerSecond float64  `json:"rate_per_second"` // default 2
+	Burst         int      `json:"burst"`           // default 5
+	MaxWait       Duration `json:"max_wait"`        // longest a caller queues, default 30s
+}
+
+func (ac AdsbdbConfig) withDefaults() AdsbdbConfig {
+	if ac.RatePerSecond <= 0 {
+		ac.RatePerSecond = 2
+	}
+	if ac.Burst <= 0 {
+		ac.Burst = 5
+	}
+	if ac.MaxWait.Duration <= 0 {
+		ac.MaxWait.Duration = 30 * time.Second
+	}
+	return ac
+}
+
+var errRateLimited = errors.New("adsbdb rate limit queue is full")
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	paused time.Time // no tokens before this
+}
+
+var adsbdbLimiter = &tokenBucket{}
+
+// wait blocks until a token is free. Tokens can go negative, which is the
+// queue: each waiter takes the next slot and sleeps until it comes round.
+func (b *tokenBucket) wait(rate float64, burst int, maxWait time.Duration) error {
+	b.mu.Lock()
+	now := time.Now()
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+
+	delay := time.Duration(0)
+	if b.tokens < 1 {
+		delay = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	if b.paused.After(now) {
+		delay = max(delay, b.paused.Sub(now))
+	}
+	if delay > maxWait {
+		b.mu.Unlock()
+		return errRateLimited
+	}
+	b.tokens--
+	b.mu.Unlock()
+
+	time.Sleep(delay)
+	return nil
+}
+
+// pause stops handing out tokens for d, e.g. after a 429.
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	if until := time.Now().Add(d); until.After(b.paused) {
+		b.paused = until
+	}
+	b.mu.Unlock()
+}
+
+func waitForAdsbdb() error {
+	ac := cfg().Adsbdb.withDefaults()
+	return adsbdbLimiter.wait(ac.RatePerSecond, ac.Burst, ac.MaxWait.Duration)
+}
+
+// noteAdsbdbStatus backs off when adsbdb says we're going too fast.
+func noteAdsbdbStatus(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	backoff := time.Minute
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		backoff = time.Duration(secs) * time.Second
+	}
+	fmt.Printf("[EN] adsbdb rate limited us, pausing lookups for %s\n", backoff)
+	adsbdbLimiter.pause(backoff)
+}
+
+// --- Per-hex coalescing ---
+type detailCall struct {
+	done   chan struct{}
+	detail AircraftDetail
+	err    error
+}
+
+var (
+	detailCalls      = make(map[string]*detailCall)
+	detailCallsMutex = &sync.Mutex{}
+)
+
+// coalescedDetails runs fetchAircraftDetails once for any number of
+// concurrent callers asking about the same hex.
+func coalescedDetails(hex string) (AircraftDetail, error) {
+	detailCallsMutex.Lock()
+	if call, ok := detailCalls[hex]; ok {
+		detailCallsMutex.Unlock()
+		<-call.done
+		return call.detail, call.err
+	}
+	call := &detailCall{done: make(chan struct{})}
+	detailCalls[hex] = call
+	detailCallsMutex.Unlock()
+
+	call.detail, call.err = fetchAircraftDetails(hex)
+
+	detailCallsMutex.Lock()
+	delete(detailCalls, hex)
+	detailCallsMutex.Unlock()
+	close(call.done)
+	return call.detail, call.err
+}
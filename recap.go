@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+	"sync"
+	"time"
+)
+
+// --- Track recaps ---
+// When an alerted flight ends (it lands, or its track session closes because
+// it left) its stored track is drawn as a short animated GIF over the map
+// tiles and posted after the alert: to recap.channel, else to wherever the
+// alert went. With recap.digest the GIFs are held for the daily digest
+// instead. The base map always comes from map.tile_url (and its cache), as
+// the static map APIs can only centre on one point.
+type TrackRecapConfig struct {
+	Enabled bool   `json:"enabled"`
+	Channel string `json:"channel"`  // default: the channel of the flight's alert
+	Digest  bool   `json:"digest"`   // hold recaps for the daily digest
+	Frames  int    `json:"frames"`   // default 24
+	FrameMS int    `json:"frame_ms"` // default 150
+}
+
+const (
+	recapWidth, recapHeight = 480, 320
+	recapMargin             = 30 // px kept clear around the track
+	recapMaxZoom            = 13
+	maxDigestRecaps         = 10 // Discord's attachment limit per message
+)
+
+var (
+	trailColor = color.RGBA{220, 30, 30, 255}
+	startColor = color.RGBA{30, 140, 30, 255}
+)
+
+var (
+	digestRecaps      []discordFile
+	digestRecapsMutex = &sync.Mutex{}
+)
+
+// maybeRecapTrack starts a recap for a session that deserves one. Callers
+// hold tracksMutex.
+func maybeRecapTrack(session *TrackSession) {
+	rc := cfg().Tracks.Recap
+	if !rc.Enabled || !session.Alerted || session.Recapped || len(session.Points) < 2 {
+		return
+	}
+	session.Recapped = true
+	copied := *session
+	copied.Points = append([]TrackPoint(nil), session.Points...)
+	go postTrackRecap(copied)
+}
+
+func postTrackRecap(s TrackSession) {
+	rc := cfg().Tracks.Recap
+	data, err := renderTrackGIF(s, rc)
+	if err != nil {
+		fmt.Printf("[TRK] Recap for %s failed: %v\n", s.label(), err)
+		return
+	}
+	file := discordFile{Name: s.fileName("gif"), Data: data}
+
+	if rc.Digest {
+		digestRecapsMutex.Lock()
+		digestRecaps = append(digestRecaps, file)
+		if len(digestRecaps) > maxDigestRecaps {
+			digestRecaps = digestRecaps[len(digestRecaps)-maxDigestRecaps:]
+		}
+		digestRecapsMutex.Unlock()
+		return
+	}
+
+	webhook := resolveChannel(rc.Channel)
+	if webhook == "" {
+		webhook = s.AlertWebhook
+	}
+	if webhook == "" {
+		return
+	}
+	if quietHold(webhook, "recap", heldAlert{Title: "Recap", Hex: s.Hex, Label: s.label()}) != "" {
+		return
+	}
+	content := fmt.Sprintf("Recap: %s, %v · Map © OpenStreetMap contributors", s.label(), s.Last.Sub(s.Start).Round(time.Minute))
+	if err := postDiscordFiles(webhook, DiscordWebhook{Content: content}, []discordFile{file}); err != nil {
+		fmt.Printf("[TRK] Error posting recap for %s: %v\n", s.label(), err)
+		return
+	}
+	fmt.Printf("[TRK] Posted recap for %s.\n", s.label())
+}
+
+// postDigestRecaps sends the recaps held since the last digest.
+func postDigestRecaps(webhook string) {
+	digestRecapsMutex.Lock()
+	files := digestRecaps
+	digestRecaps = nil
+	digestRecapsMutex.Unlock()
+	if len(files) == 0 {
+		return
+	}
+	if quietHold(webhook, "recap", heldAlert{Title: fmt.Sprintf("Flight recaps (%d)", len(files))}) != "" {
+		return
+	}
+	content := fmt.Sprintf("Flight recaps (%d) · Map © OpenStreetMap contributors", len(files))
+	if err := postDiscordFiles(webhook, DiscordWebhook{Content: content}, files); err != nil {
+		fmt.Printf("[DG] Error posting recaps: %v\n", err)
+	}
+}
+
+// recapZoom is the closest zoom at which the whole track fits the frame.
+func recapZoom(points []TrackPoint) int {
+	for z := recapMaxZoom; z > 1; z-- {
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, p := range points {
+			x, y := tileXY(p.Lat, p.Lon, z)
+			minX, maxX = min(minX, x), max(maxX, x)
+			minY, maxY = min(minY, y), max(maxY, y)
+		}
+		if (maxX-minX)*tileSize <= recapWidth-2*recapMargin && (maxY-minY)*tileSize <= recapHeight-2*recapMargin {
+			return z
+		}
+	}
+	return 1
+}
+
+func renderTrackGIF(s TrackSession, rc TrackRecapConfig) ([]byte, error) {
+	mc := cfg().Map.withDefaults()
+	frames := rc.Frames
+	if frames <= 0 {
+		frames = 24
+	}
+	frames = min(frames, len(s.Points))
+	delay := rc.FrameMS
+	if delay <= 0 {
+		delay = 150
+	}
+
+	zoom := recapZoom(s.Points)
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	px := make([]image.Point, len(s.Points))
+	for _, p := range s.Points {
+		x, y := tileXY(p.Lat, p.Lon, zoom)
+		minX, maxX = min(minX, x), max(maxX, x)
+		minY, maxY = min(minY, y), max(maxY, y)
+	}
+	left := (minX+maxX)/2*tileSize - recapWidth/2
+	top := (minY+maxY)/2*tileSize - recapHeight/2
+	for i, p := range s.Points {
+		x, y := tileXY(p.Lat, p.Lon, zoom)
+		px[i] = image.Pt(int(math.Round(x*tileSize-left)), int(math.Round(y*tileSize-top)))
+	}
+
+	base := image.NewRGBA(image.Rect(0, 0, recapWidth, recapHeight))
+	draw.Draw(base, base.Bounds(), &image.Uniform{color.RGBA{221, 221, 221, 255}}, image.Point{}, draw.Src)
+	if missing := drawTiles(base, mc, zoom, left, top); missing > 0 {
+		fmt.Printf("[TRK] %d tile(s) unavailable for the %s recap\n", missing, s.Hex)
+	}
+	// Quantise the map once; each frame copies it and draws over the copy
+	basePal := image.NewPaletted(base.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(basePal, base.Bounds(), base, image.Point{})
+
+	anim := &gif.GIF{}
+	for f := 1; f <= frames; f++ {
+		upto := len(px) * f / frames
+		frame := image.NewPaletted(basePal.Bounds(), palette.Plan9)
+		copy(frame.Pix, basePal.Pix)
+		fillDot(frame, px[0], 4, startColor)
+		for i := 1; i < upto; i++ {
+			drawLine(frame, px[i-1], px[i], trailColor)
+		}
+		fillDot(frame, px[upto-1], 5, trailColor)
+
+		d := delay / 10
+		if f == frames {
+			d = 200 // hold the finished track for two seconds
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, d)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine is Bresenham's, two pixels wide.
+func drawLine(img draw.Image, a, b image.Point, c color.Color) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(a.X, a.Y, c)
+		img.Set(a.X+1, a.Y, c)
+		img.Set(a.X, a.Y+1, c)
+		if a == b {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			a.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			a.Y += sy
+		}
+	}
+}
+
+func fillDot(img draw.Image, at image.Point, r int, c color.Color) {
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if dx*dx+dy*dy <= r*r {
+				img.Set(at.X+dx, at.Y+dy, c)
+			}
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
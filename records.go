@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Sighting and alert records ---
+// Every processed sighting and every alert decision passes through here on
+// its way to whichever outputs are enabled.
+type SightingRecord struct {
+	Time     time.Time `json:"time"`
+	Hex      string    `json:"hex"`
+	Flight   string    `json:"flight,omitempty"`
+	Reg      string    `json:"reg,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	Squawk   string    `json:"squawk,omitempty"`
+	Mil      bool      `json:"mil"`
+	AltBaro  string    `json:"alt_baro"`
+	GS       float64   `json:"gs"`
+	Lat      *float64  `json:"lat,omitempty"`
+	Lon      *float64  `json:"lon,omitempty"`
+	Distance *float64  `json:"distance_nm,omitempty"`
+}
+
+type AlertRecord struct {
+	Time         time.Time  `json:"time"`
+	Type         string     `json:"type"`
+	Rule         string     `json:"rule,omitempty"`
+	Hex          string     `json:"hex,omitempty"`
+	Flight       string     `json:"flight,omitempty"`
+	Registration string     `json:"registration,omitempty"`
+	AircraftType string     `json:"aircraft_type,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	Route        string     `json:"route,omitempty"` // "KJFK → EGLL", when a route provider knows it
+	Squawk       string     `json:"squawk,omitempty"`
+	AltBaro      string     `json:"alt_baro,omitempty"`
+	Lat          *float64   `json:"lat,omitempty"`
+	Lon          *float64   `json:"lon,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	ModeS        *ModeSData `json:"mode_s,omitempty"`
+	Members      []string   `json:"members,omitempty"` // aggregate alerts
+	Photos       []string   `json:"photos,omitempty"`  // files in the photo archive
+	Embed        []byte     `json:"-"`                 // the Discord message as posted, kept in the history DB
+	Webhook      string     `json:"-"`                 // where it was posted
+	Site         string     `json:"site,omitempty"`    // see sites.go
+	Outcome      string     `json:"outcome"`           // sent, muted, quiet, queued, no_webhook, error
+}
+
+func newSightingRecord(ac Aircraft) SightingRecord {
+	rec := SightingRecord{
+		Time:    clock.Now(),
+		Hex:     ac.Hex,
+		Flight:  strings.TrimSpace(ac.Flight),
+		Reg:     ac.NNumber,
+		Type:    ac.Type,
+		Squawk:  ac.Squawk,
+		Mil:     isMilitary(ac),
+		AltBaro: formatAltitudeString(ac.AltBaro),
+		GS:      ac.GS.Or(0),
+	}
+	if lat, lon, ok := getActualCoords(ac); ok {
+		dist := geo.DistanceNM(apiLat, apiLng, lat, lon)
+		rec.Lat, rec.Lon, rec.Distance = &lat, &lon, &dist
+	}
+	return rec
+}
+
+func newAlertRecord(ac Aircraft, details AircraftDetail, alertType string, actx *AlertContext, tags []string) AlertRecord {
+	rec := AlertRecord{
+		Time:         clock.Now(),
+		Site:         siteName(alertType),
+		Type:         alertType,
+		Hex:          ac.Hex,
+		Flight:       strings.TrimSpace(ac.Flight),
+		Registration: details.Registration,
+		AircraftType: details.AircraftType,
+		Owner:        details.Owner,
+		Squawk:       ac.Squawk,
+		AltBaro:      formatAltitudeString(ac.AltBaro),
+		ModeS:        modeSData(ac),
+		Tags:         tags,
+	}
+	if rec.Registration == "" {
+		rec.Registration = ac.NNumber
+	}
+	if rec.AircraftType == "" {
+		rec.AircraftType = ac.Type
+	}
+	if actx != nil {
+		rec.Rule, rec.Note = actx.Rule, actx.Note
+	}
+	if lat, lon, ok := getActualCoords(ac); ok {
+		rec.Lat, rec.Lon = &lat, &lon
+	}
+	return rec
+}
+
+func recordSighting(ac Aircraft) {
+	rec := newSightingRecord(ac)
+	noteSighting(ac.Hex, rec.Time)
+	writeSightingLog("sighting", rec)
+	exportSightingCSV(rec)
+	bufferParquetSighting(rec)
+	bufferInfluxSighting(rec)
+	recordTrackPoint(ac)
+	recordHistorySighting(ac, rec)
+	recordCoverage(rec)
+}
+
+func recordAlert(rec AlertRecord) {
+	writeSightingLog("alert", rec)
+	exportAlertCSV(rec)
+	writeInfluxAlert(rec)
+	trackRemoteWrite(rec)
+	markTrackAlerted(rec)
+	recordHistoryAlert(rec)
+	rememberAlert(rec)
+	countAlertOutcome(rec)
+	captureAlert(rec)
+}
@@ -0,0 +1,19 @@
+package main
+
+import "main.go/geo"
+
+// --- Geographic regions ---
+// Polygons are lists of [lat, lon] vertices, implicitly closed. They can be
+// written inline on a rule or defined once under "regions" and referenced by
+// name (e.g. "west-of-mississippi").
+type LatLon = geo.Point
+
+type Polygon = geo.Polygon
+
+// lookupRegion resolves a named region from config. Unknown names yield nil.
+func lookupRegion(name string) Polygon {
+	if name == "" {
+		return nil
+	}
+	return cfg().Regions[name]
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+
+	"main.go/geo"
+)
+
+// --- Prometheus remote-write export ---
+// Pushes per-aircraft series (distance to observer, altitude, ground speed)
+// for aircraft that recently triggered an alert, so interesting flights can
+// be graphed after the fact. Works with Prometheus, VictoriaMetrics
+// (/api/v1/write), Mimir, etc.
+type RemoteWriteConfig struct {
+	URL         string   `json:"url"`          // empty disables remote write
+	Username    string   `json:"username"`     // optional basic auth
+	Password    string   `json:"password"`     //
+	BearerToken string   `json:"bearer_token"` // optional bearer auth
+	TrackFor    Duration `json:"track_for"`    // keep pushing an aircraft this long after its last alert, default 30m
+}
+
+type remoteWriteTarget struct {
+	until     time.Time
+	alertType string
+}
+
+var (
+	remoteWriteTargets = make(map[string]remoteWriteTarget)
+	remoteWriteMutex   = &sync.Mutex{}
+)
+
+func trackRemoteWrite(rec AlertRecord) {
+	rc := cfg().RemoteWrite
+	if rc.URL == "" || rec.Hex == "" || rec.Outcome != "sent" {
+		return
+	}
+	trackFor := rc.TrackFor.Duration
+	if trackFor <= 0 {
+		trackFor = 30 * time.Minute
+	}
+	remoteWriteMutex.Lock()
+	remoteWriteTargets[rec.Hex] = remoteWriteTarget{until: rec.Time.Add(trackFor), alertType: rec.Type}
+	remoteWriteMutex.Unlock()
+}
+
+type promSeries struct {
+	labels [][2]string
+	value  float64
+}
+
+// pushRemoteWrite sends one sample per series for every tracked aircraft in the snapshot.
+func pushRemoteWrite(snapshot []Aircraft) {
+	rc := cfg().RemoteWrite
+	if rc.URL == "" {
+		return
+	}
+
+	now := time.Now()
+	remoteWriteMutex.Lock()
+	targets := make(map[string]remoteWriteTarget)
+	for hex, t := range remoteWriteTargets {
+		if now.After(t.until) {
+			delete(remoteWriteTargets, hex)
+			continue
+		}
+		targets[hex] = t
+	}
+	remoteWriteMutex.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	var series []promSeries
+	for _, ac := range snapshot {
+		target, ok := targets[ac.Hex]
+		if !ok {
+			continue
+		}
+		base := [][2]string{{"hex", ac.Hex}, {"flight", strings.TrimSpace(ac.Flight)}, {"alert_type", target.alertType}}
+		add := func(name string, value float64) {
+			series = append(series, promSeries{labels: append([][2]string{{"__name__", name}}, base...), value: value})
+		}
+		if ac.GS.Valid {
+			add("adsb_aircraft_ground_speed_knots", ac.GS.V)
+		}
+		if altitudeFT, ok := altitudeFeet(ac); ok {
+			add("adsb_aircraft_altitude_feet", altitudeFT)
+		}
+		if lat, lon, ok := getActualCoords(ac); ok {
+			add("adsb_aircraft_distance_nm", geo.DistanceNM(apiLat, apiLng, lat, lon))
+		}
+	}
+	if len(series) == 0 {
+		return
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series, now.UnixMilli()))
+	req, err := http.NewRequest(http.MethodPost, rc.URL, bytes.NewReader(body))
+	if err != nil {
+		fmt.Printf("[RW] Error building request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if rc.Username != "" {
+		req.SetBasicAuth(rc.Username, rc.Password)
+	}
+	if rc.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+rc.BearerToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("[RW] Error pushing %d series: %v\n", len(series), err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Printf("[RW] Remote write returned non-2xx status: %s\n", resp.Status)
+	}
+}
+
+// encodeWriteRequest hand-encodes a prometheus.WriteRequest protobuf:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []promSeries, timestampMs int64) []byte {
+	var req []byte
+	for _, s := range series {
+		sort.Slice(s.labels, func(i, j int) bool { return s.labels[i][0] < s.labels[j][0] })
+
+		var ts []byte
+		for _, l := range s.labels {
+			if l[1] == "" {
+				continue
+			}
+			var label []byte
+			label = appendProtoBytes(label, 1, []byte(l[0]))
+			label = appendProtoBytes(label, 2, []byte(l[1]))
+			ts = appendProtoBytes(ts, 1, label)
+		}
+
+		var sample []byte
+		sample = binary.AppendUvarint(sample, 1<<3|1) // field 1, fixed64
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.value))
+		sample = binary.AppendUvarint(sample, 2<<3|0) // field 2, varint
+		sample = binary.AppendUvarint(sample, uint64(timestampMs))
+		ts = appendProtoBytes(ts, 2, sample)
+
+		req = appendProtoBytes(req, 1, ts)
+	}
+	return req
+}
+
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2) // length-delimited
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
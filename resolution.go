@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- "All clear" resolution notifications ---
+// Short follow-up messages so a channel shows how an event ended.
+type ResolutionConfig struct {
+	Emergency     bool     `json:"emergency"`      // squawk reverted to normal
+	Watchlist     bool     `json:"watchlist"`      // watchlist aircraft left the radius
+	Proximity     bool     `json:"proximity"`      // proximity aircraft climbed or flew away
+	DepartedAfter Duration `json:"departed_after"` // how long unseen before "left the radius"
+}
+
+// sendResolution posts the follow-up for an alert of alertType, routed the
+// way the alert was: webhookURL is the alert's webhook before the location
+// override, and the channel's quiet hours apply to it. A muted aircraft
+// doesn't get one, or it would post "cleared" on every lap of the zone it
+// was muted for.
+func sendResolution(enabled bool, alertType, webhookURL string, ac Aircraft, title, message string) {
+	if !enabled {
+		return
+	}
+	webhookURL = siteWebhook(alertType, webhookURL)
+	if webhookURL == "" {
+		return
+	}
+	hex := ac.Hex
+	if m, muted := isMuted(ac); muted {
+		fmt.Printf("[Radius] %s is muted (%s=%s). Skipping '%s' resolution.\n", hex, m.Match, m.Value, title)
+		return
+	}
+	if quietHold(webhookURL, alertType, heldAlert{Title: "✅ " + title, Hex: hex, Label: strings.TrimSpace(ac.Flight)}) != "" {
+		return
+	}
+	fmt.Printf("[Radius] Resolved: %s - %s\n", hex, title)
+	embed := Embed{
+		Title:       "✅ " + title,
+		Description: message,
+		Color:       5763719, // Green
+		URL:         fmt.Sprintf("https://globe.adsb.lol/?icao=%s", hex),
+		Fields:      []Field{},
+		Footer:      Footer{Text: "ADSB.lol Alerter"},
+	}
+	if err := postDiscordWebhook(webhookURL, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[Discord] Error sending resolution: %v\n", err)
+	}
+}
+
+func notifyWatchlistDeparted(hex string, state RadiusAircraftState) {
+	watchlistMutex.RLock()
+	entry := globalWatchlist[hex]
+	watchlistMutex.RUnlock()
+
+	name := hex
+	if entry.Registration != "" {
+		name = fmt.Sprintf("%s (%s)", entry.Registration, hex)
+	}
+	// The aircraft has left the feed; the entry's type stands in for type mutes
+	ac := Aircraft{Hex: hex, Type: entry.PlaneType}
+	sendResolution(cfg().Resolutions.Watchlist, "watchlist", watchlistWebhook(entry), ac, "Watchlist Aircraft Departed",
+		fmt.Sprintf("`%s` has left the area. Last seen %v ago.", name, since(state.LastSeen).Round(time.Minute)))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- Retention ---
+// Prunes the history database in the background so it doesn't grow without
+// bound. A zero duration keeps that table forever.
+type RetentionConfig struct {
+	Sightings Duration `json:"sightings"` // raw aircraft_sightings rows, e.g. "720h"
+	Flights   Duration `json:"flights"`
+	Alerts    Duration `json:"alerts"`
+	Interval  Duration `json:"interval"` // how often to prune, default 6h
+}
+
+const retentionBatchSize = 10000
+
+var retentionTables = []struct {
+	table, column string
+	keep          func(RetentionConfig) time.Duration
+}{
+	{"aircraft_sightings", "seen_at", func(rc RetentionConfig) time.Duration { return rc.Sightings.Duration }},
+	// alerts before flights, so pruned alerts don't first have their flight_id nulled
+	{"alerts", "alerted_at", func(rc RetentionConfig) time.Duration { return rc.Alerts.Duration }},
+	{"flights", "last_seen", func(rc RetentionConfig) time.Duration { return rc.Flights.Duration }},
+}
+
+func manageRetention() {
+	for {
+		interval := cfg().Retention.Interval.Duration
+		if interval <= 0 {
+			interval = 6 * time.Hour
+		}
+		if historyDB != nil {
+			pruneHistory()
+		}
+		time.Sleep(interval)
+	}
+}
+
+func pruneHistory() {
+	rc := cfg().Retention
+	for _, t := range retentionTables {
+		keep := t.keep(rc)
+		if keep <= 0 {
+			continue
+		}
+		cutoff := time.Now().Add(-keep)
+
+		// Delete in batches so a big backlog doesn't hold locks for minutes
+		var total int64
+		query := fmt.Sprintf(`DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s < $1 LIMIT %[3]d)`, t.table, t.column, retentionBatchSize)
+		for {
+			res, err := historyDB.Exec(query, cutoff)
+			if err != nil {
+				fmt.Printf("[RET] Error pruning %s: %v\n", t.table, err)
+				break
+			}
+			n, _ := res.RowsAffected()
+			total += n
+			if n < retentionBatchSize {
+				break
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		fmt.Printf("[RET] Pruned %d rows older than %v from %s.\n", total, keep, t.table)
+		if _, err := historyDB.Exec("VACUUM ANALYZE " + t.table); err != nil {
+			fmt.Printf("[RET] Error vacuuming %s: %v\n", t.table, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Route lookup chain ---
+// No free source knows every callsign, so route lookups walk a list of
+// providers and take the first answer. Answers (including "nobody knows")
+// are cached per callsign.
+type RouteConfig struct {
+	Providers []string `json:"providers"` // "aeroapi", "adsbdb", "hexdb", "fr24"; default: aeroapi, adsbdb, hexdb
+	CacheTTL  Duration `json:"cache_ttl"` // default 30m
+}
+
+var routeProviders = map[string]func(callsign string) (*FlightRoute, error){
+	"aeroapi": fetchAeroAPI,
+	"adsbdb":  fetchAdsbdbRoute,
+	"hexdb":   fetchHexdbRoute,
+	"fr24":    fetchFR24Route,
+}
+
+var defaultRouteProviders = []string{"aeroapi", "adsbdb", "hexdb"}
+
+const (
+	adsbdbCallsignURL = "https://api.adsbdb.com/v0/callsign/"
+	hexdbRouteURL     = "https://hexdb.io/api/v1/route/icao/"
+	fr24FlightURL     = "https://api.flightradar24.com/common/v1/flight/list.json"
+)
+
+var routeHTTP = newHTTPClient(10 * time.Second)
+
+type cachedRoute struct {
+	route     *FlightRoute
+	fetchedAt time.Time
+}
+
+var (
+	routeCache      = make(map[string]cachedRoute)
+	routeCacheMutex = &sync.Mutex{}
+)
+
+// lookupRoute returns nil when no provider knows the callsign. The error is
+// only set when every provider that was asked failed outright.
+func lookupRoute(callsign string) (*FlightRoute, error) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if callsign == "" || offlineMode() {
+		return nil, nil
+	}
+	rc := cfg().Routes
+	ttl := rc.CacheTTL.Duration
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+
+	routeCacheMutex.Lock()
+	cached, ok := routeCache[callsign]
+	routeCacheMutex.Unlock()
+	if ok && time.Since(cached.fetchedAt) < ttl {
+		return cached.route, nil
+	}
+
+	providers := rc.Providers
+	if len(providers) == 0 {
+		providers = defaultRouteProviders
+	}
+	var route *FlightRoute
+	var errs []string
+	for _, name := range providers {
+		fetch, ok := routeProviders[strings.ToLower(name)]
+		if !ok {
+			fmt.Printf("[EN] Unknown route provider '%s'\n", name)
+			continue
+		}
+		r, err := fetch(callsign)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if r != nil && r.String() != "" {
+			route = r
+			break
+		}
+	}
+	if route == nil && len(errs) > 0 && len(errs) == len(providers) {
+		// Don't cache an outage as "unknown"
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	routeCacheMutex.Lock()
+	routeCache[callsign] = cachedRoute{route: route, fetchedAt: time.Now()}
+	for key, entry := range routeCache {
+		if time.Since(entry.fetchedAt) > ttl {
+			delete(routeCache, key)
+		}
+	}
+	routeCacheMutex.Unlock()
+	return route, nil
+}
+
+func getRouteJSON(apiURL string, out any) (found bool, err error) {
+	req, _ := http.NewRequest("GET", apiURL, nil)
+	req.Header.Set("User-Agent", "flight-ingestor")
+	resp, err := routeHTTP.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("non-200 status: %s", resp.Status)
+	}
+	return true, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func fetchAdsbdbRoute(callsign string) (*FlightRoute, error) {
+	type airport struct {
+		ICAO         string `json:"icao_code"`
+		Name         string `json:"name"`
+		Municipality string `json:"municipality"`
+	}
+	var body struct {
+		Response struct {
+			FlightRoute *struct {
+				Callsign    string  `json:"callsign"`
+				Origin      airport `json:"origin"`
+				Destination airport `json:"destination"`
+			} `json:"flightroute"`
+		} `json:"response"`
+	}
+	if err := waitForAdsbdb(); err != nil {
+		return nil, err
+	}
+	found, err := getRouteJSON(adsbdbCallsignURL+url.PathEscape(callsign), &body)
+	if err != nil || !found || body.Response.FlightRoute == nil {
+		return nil, err
+	}
+	fr := body.Response.FlightRoute
+	return &FlightRoute{
+		Ident:           fr.Callsign,
+		Origin:          fr.Origin.ICAO,
+		OriginName:      fr.Origin.Name,
+		Destination:     fr.Destination.ICAO,
+		DestinationName: fr.Destination.Name,
+		Source:          "adsbdb",
+	}, nil
+}
+
+// hexdb only has the airport codes: {"flight": "BAW1", "route": "EGLL-KJFK"}.
+// Multi-leg routes list every stop; we keep the ends.
+func fetchHexdbRoute(callsign string) (*FlightRoute, error) {
+	var body struct {
+		Flight string `json:"flight"`
+		Route  string `json:"route"`
+	}
+	found, err := getRouteJSON(hexdbRouteURL+url.PathEscape(callsign), &body)
+	if err != nil || !found {
+		return nil, err
+	}
+	stops := strings.Split(body.Route, "-")
+	if len(stops) < 2 {
+		return nil, nil
+	}
+	return &FlightRoute{
+		Ident:       body.Flight,
+		Origin:      stops[0],
+		Destination: stops[len(stops)-1],
+		Source:      "hexdb",
+	}, nil
+}
+
+// FlightRadar24's unofficial flight list. It isn't a supported API and may
+// change or block us without notice, so it's off unless listed.
+func fetchFR24Route(callsign string) (*FlightRoute, error) {
+	type airport struct {
+		Name string `json:"name"`
+		Code struct {
+			ICAO string `json:"icao"`
+		} `json:"code"`
+	}
+	var body struct {
+		Result struct {
+			Response struct {
+				Data []struct {
+					Identification struct {
+						Callsign string `json:"callsign"`
+					} `json:"identification"`
+					Status struct {
+						Live bool `json:"live"`
+					} `json:"status"`
+					Airport struct {
+						Origin      *airport `json:"origin"`
+						Destination *airport `json:"destination"`
+					} `json:"airport"`
+				} `json:"data"`
+			} `json:"response"`
+		} `json:"result"`
+	}
+	q := url.Values{"query": {callsign}, "fetchBy": {"flight"}, "limit": {"10"}}
+	found, err := getRouteJSON(fr24FlightURL+"?"+q.Encode(), &body)
+	if err != nil || !found {
+		return nil, err
+	}
+	for _, f := range body.Result.Response.Data {
+		if !f.Status.Live || f.Airport.Origin == nil || f.Airport.Destination == nil {
+			continue
+		}
+		return &FlightRoute{
+			Ident:           f.Identification.Callsign,
+			Origin:          f.Airport.Origin.Code.ICAO,
+			OriginName:      f.Airport.Origin.Name,
+			Destination:     f.Airport.Destination.Code.ICAO,
+			DestinationName: f.Airport.Destination.Name,
+			Source:          "fr24",
+		}, nil
+	}
+	return nil, nil
+}
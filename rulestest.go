@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"main.go/geo"
+)
+
+// --- CLI: rules test ---
+// Explains, for a set of sample aircraft, which rules would match, where the
+// alerts would go, and why. Nothing is sent and no state is kept.
+//
+//	flight-ingestor rules test samples.json
+//	flight-ingestor rules test --hex a1b2c3
+type RuleMatch struct {
+	Rule    string
+	Matched bool
+	Reason  string
+	Channel string // webhook URL or channel name the alert would go to
+}
+
+func runRulesTest(args []string) int {
+	fs := flag.NewFlagSet("rules test", flag.ContinueOnError)
+	hex := fs.String("hex", "", "fetch this aircraft live from adsb.lol instead of reading a file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var aircraft []Aircraft
+	var err error
+	switch {
+	case *hex != "":
+		aircraft, err = fetchLiveAircraft(*hex)
+	case fs.NArg() == 1:
+		aircraft, err = readSampleAircraft(fs.Arg(0))
+	default:
+		fmt.Fprintln(os.Stderr, "usage: rules test [--hex HEX | FILE]")
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(aircraft) == 0 {
+		fmt.Println("No aircraft to test.")
+		return 0
+	}
+
+	loadWatchlistFromCSV()
+	initMutes(cfg().Mutes)
+
+	for _, ac := range aircraft {
+		fmt.Printf("\n=== %s %s (%s) ===\n", ac.Hex, strings.TrimSpace(ac.Flight), ac.Type)
+		if isBlocked(ac) {
+			fmt.Println("  dropped by blocklist - no rules evaluated")
+			continue
+		}
+		printMatches(evaluateRules(ac))
+		if m, muted := isMuted(ac); muted {
+			fmt.Printf("  note: currently muted (%s=%s), alerts would be skipped\n", m.Match, m.Value)
+		}
+	}
+
+	fmt.Println("\n=== Aggregate rules (whole sample) ===")
+	printMatches(evaluateAggregateRules(aircraft))
+	return 0
+}
+
+func printMatches(matches []RuleMatch) {
+	for _, m := range matches {
+		mark := "✗"
+		if m.Matched {
+			mark = "✓"
+		}
+		line := fmt.Sprintf("  %s %-28s %s", mark, m.Rule, m.Reason)
+		if m.Matched {
+			line += " -> " + describeChannel(m.Channel)
+		}
+		fmt.Println(line)
+	}
+}
+
+func describeChannel(webhook string) string {
+	if webhook == "" {
+		return "discord (webhook not set!)"
+	}
+	for name, url := range cfg().Channels {
+		if url == webhook {
+			return "discord #" + name
+		}
+	}
+	return "discord (custom webhook)"
+}
+
+func readSampleAircraft(path string) ([]Aircraft, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAircraftList(data)
+}
+
+// decodeAircraftList accepts either an adsb.lol response ({"ac": [...]}) or a bare array.
+func decodeAircraftList(data []byte) ([]Aircraft, error) {
+	var list []Aircraft
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var resp struct {
+		ADSBResponse
+		Tar1090 []Aircraft `json:"aircraft"` // readsb/tar1090 aircraft.json
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of aircraft, {\"ac\": [...]} or {\"aircraft\": [...]}: %v", err)
+	}
+	if len(resp.Aircraft) == 0 {
+		return resp.Tar1090, nil
+	}
+	return resp.Aircraft, nil
+}
+
+func fetchLiveAircraft(hex string) ([]Aircraft, error) {
+	return fetchADSBLol("hex/" + strings.ToLower(hex))
+}
+
+// fetchADSBLol queries one of the adsb.lol v2 lookup endpoints, e.g. "reg/N123AB".
+func fetchADSBLol(path string) ([]Aircraft, error) {
+	if offlineMode() {
+		return nil, fmt.Errorf("adsb.lol: %w", errOffline)
+	}
+	resp, err := httpClient.Get("https://api.adsb.lol/v2/" + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adsb.lol returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAircraftList(body)
+}
+
+// evaluateRules is the stateless twin of processRadiusAlerts and friends.
+func evaluateRules(ac Aircraft) []RuleMatch {
+	var matches []RuleMatch
+	now := clock.Now()
+	scheduleNote := func(windows []Schedule) string {
+		if !scheduleActive(windows, now) {
+			return " (outside schedule, would wait)"
+		}
+		return ""
+	}
+
+	// Built-in triggers are exclusive, in this order
+	claimed := ""
+	watchlistMutex.RLock()
+	entry, onWatchlist := globalWatchlist[ac.Hex]
+	watchlistMutex.RUnlock()
+	if onWatchlist {
+		claimed = "watchlist"
+		matches = append(matches, RuleMatch{"watchlist", true,
+			fmt.Sprintf("on plane-alert-db (%s: %s)%s", entry.Category, entry.Note, scheduleNote(cfg().Schedules["watchlist"])),
+			watchlistWebhook(entry)})
+	} else {
+		matches = append(matches, RuleMatch{"watchlist", false, "not on the watchlist", ""})
+	}
+
+	builtin := func(name string, hit bool, yes, no, webhook string) {
+		switch {
+		case hit && claimed != "":
+			matches = append(matches, RuleMatch{name, false, yes + ", but suppressed by " + claimed, ""})
+		case hit:
+			claimed = name
+			matches = append(matches, RuleMatch{name, true, yes + scheduleNote(cfg().Schedules[name]), webhook})
+		default:
+			matches = append(matches, RuleMatch{name, false, no, ""})
+		}
+	}
+	builtin("emergency", isEmergencySquawk(ac.Squawk), "squawking "+ac.Squawk, fmt.Sprintf("squawk %q is not an emergency code", ac.Squawk), emergencyWebhook(ac.Squawk))
+	milReason := "mil flag set"
+	if m, ok := militaryCallsign(ac.Flight); ok && !ac.Mil {
+		milReason = fmt.Sprintf("callsign prefix %s (%s)", m.Prefix, m.Unit)
+	}
+	builtin("military", isMilitary(ac), milReason, "mil flag not set and no military callsign", discordHookWatchlist)
+
+	distanceNM, altitudeFT, tier, inZone := proximityZone(homeCenter(), ac)
+	var zones []string
+	for _, t := range proximityTiers() {
+		zones = append(zones, fmt.Sprintf("%gnm / %gft", t.RadiusNM, t.MaxAltFT))
+	}
+	proxReason := fmt.Sprintf("%.1f nm / %s ft is outside %s", distanceNM, formatAltitudeString(ac.AltBaro), strings.Join(zones, ", "))
+	if onGround(ac) && !cfg().Proximity.IncludeGround {
+		proxReason = "on the ground (proximity.include_ground is off)"
+	}
+	switch positionStatus(ac) {
+	case PositionMissing:
+		proxReason = "no position"
+	case PositionStale:
+		inZone, proxReason = false, "position is stale"
+	}
+	if inZone && !proximityConditionsMet(homeCenter(), ac) {
+		inZone = false
+		proxReason = fmt.Sprintf("%.1f nm / %.0f ft is in the zone, but descending/approaching conditions not met", distanceNM, altitudeFT)
+	}
+	proxMatch := fmt.Sprintf("%.1f nm at %.0f ft", distanceNM, altitudeFT)
+	proxHook := discordHookProximity
+	if tier.Name != "" {
+		proxMatch += fmt.Sprintf(", tier '%s'", tier.Name)
+	}
+	if tier.Channel != "" {
+		proxHook = resolveChannel(tier.Channel)
+	}
+	builtin("proximity", inZone, proxMatch, proxReason, proxHook)
+
+	// Configurable rules
+	lat, lon, hasCoords := getActualCoords(ac)
+	for _, rule := range cfg().DwellRules {
+		name := "dwell:" + rule.Name
+		centerLat, centerLon := rule.Lat, rule.Lon
+		if centerLat == 0 && centerLon == 0 {
+			centerLat, centerLon = apiLat, apiLng
+		}
+		switch {
+		case !matchesClass(ac, rule.Classes):
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("not in classes %v", rule.Classes), ""})
+		case !groundStateMatches(rule.OnGround, ac):
+			matches = append(matches, RuleMatch{name, false, groundStateReason(rule.OnGround), ""})
+		case !hasCoords:
+			matches = append(matches, RuleMatch{name, false, "no position; dwell timer held", ""})
+		case positionStatus(ac) == PositionStale:
+			matches = append(matches, RuleMatch{name, false, "position is stale; dwell timer held", ""})
+		case geo.DistanceNM(centerLat, centerLon, lat, lon) > rule.RadiusNM:
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("outside the %gnm zone", rule.RadiusNM), ""})
+		default:
+			matches = append(matches, RuleMatch{name, true,
+				fmt.Sprintf("in zone; alerts after %v of dwell%s", rule.MinDuration.Duration, scheduleNote(rule.Schedule)),
+				resolveChannel(rule.Channel)})
+		}
+	}
+
+	for _, rule := range cfg().OperatorRules {
+		name := "operator:" + rule.Name
+		switch {
+		case !hasCoords:
+			matches = append(matches, RuleMatch{name, false, "no position", ""})
+		case geo.DistanceNM(apiLat, apiLng, lat, lon) > rule.RadiusNM:
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("outside %gnm", rule.RadiusNM), ""})
+		case !matchesClass(ac, rule.Classes):
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("not in classes %v", rule.Classes), ""})
+		case !groundStateMatches(rule.OnGround, ac):
+			matches = append(matches, RuleMatch{name, false, groundStateReason(rule.OnGround), ""})
+		default:
+			details, err := getAircraftDetails(ac.Hex)
+			if err != nil {
+				matches = append(matches, RuleMatch{name, false, fmt.Sprintf("enrichment failed: %v", err), ""})
+			} else if operator, ok := rule.matchesOperator(details); ok {
+				matches = append(matches, RuleMatch{name, true, fmt.Sprintf("operator %q%s", operator, scheduleNote(rule.Schedule)), resolveChannel(rule.Channel)})
+			} else {
+				matches = append(matches, RuleMatch{name, false, fmt.Sprintf("owner %q / airline %q don't match %v", details.Owner, details.Airline, rule.Match), ""})
+			}
+		}
+	}
+
+	for _, rule := range cfg().DescentRules {
+		name := "descent:" + rule.Name
+		if alt, selected, ok := rule.descending(homeCenter(), ac); ok {
+			matches = append(matches, RuleMatch{name, true, fmt.Sprintf("selected %.0f ft at %.0f ft%s", selected, alt, scheduleNote(rule.Schedule)), resolveChannel(rule.Channel)})
+		} else if ac.NavAltMCP == nil {
+			matches = append(matches, RuleMatch{name, false, "no selected altitude (needs Comm-B data)", ""})
+		} else {
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("selected %.0f ft doesn't meet the rule", *ac.NavAltMCP), ""})
+		}
+	}
+
+	for _, rule := range cfg().SquawkRules {
+		name := "squawk:" + rule.Name
+		sr, ok := rule.match(ac.Squawk)
+		switch {
+		case !ok:
+			matches = append(matches, RuleMatch{name, false, fmt.Sprintf("squawk %q is outside the rule's ranges", ac.Squawk), ""})
+		case !matchesClass(ac, rule.Classes):
+			matches = append(matches, RuleMatch{name, false, "class doesn't match", ""})
+		default:
+			matches = append(matches, RuleMatch{name, true, squawkRangeLabel(sr) + scheduleNote(rule.Schedule), resolveChannel(rule.Channel)})
+		}
+	}
+
+	for _, special := range loadSpecialTypes() {
+		if !strings.EqualFold(special.Type, ac.Type) {
+			continue
+		}
+		name := "special:" + special.Type
+		if special.inRegion(ac) {
+			matches = append(matches, RuleMatch{name, true, "nationwide type match" + scheduleNote(cfg().Schedules["special_military"]), special.webhook()})
+		} else {
+			matches = append(matches, RuleMatch{name, false, "type matches but aircraft is outside the configured region", ""})
+		}
+	}
+	return matches
+}
+
+func evaluateAggregateRules(aircraft []Aircraft) []RuleMatch {
+	var matches []RuleMatch
+	for _, rule := range cfg().AggregateRules {
+		count := 0
+		for _, ac := range aircraft {
+			if _, muted := isMuted(ac); !muted && !isBlocked(ac) && rule.matches(homeCenter(), ac) {
+				count++
+			}
+		}
+		matches = append(matches, RuleMatch{"aggregate:" + rule.Name, count >= rule.minCount(),
+			fmt.Sprintf("%d matching aircraft, needs %d", count, rule.minCount()), resolveChannel(rule.Channel)})
+	}
+	return matches
+}
+
+func groundStateReason(want *bool) string {
+	if want != nil && *want {
+		return "rule wants aircraft on the ground"
+	}
+	return "rule wants airborne aircraft"
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- S3 / object storage archival ---
+// Uploads raw poll captures (gzipped JSONL, one object per hour) and any
+// finished files under the configured export directories to an S3-compatible
+// bucket (AWS, MinIO, R2, B2, ...). Requests are signed with SigV4 by hand to
+// avoid pulling in an SDK.
+type S3Config struct {
+	Endpoint  string   `json:"endpoint"` // default https://s3.<region>.amazonaws.com
+	Region    string   `json:"region"`
+	Bucket    string   `json:"bucket"` // empty disables archival
+	AccessKey string   `json:"access_key"`
+	SecretKey string   `json:"secret_key"`
+	Prefix    string   `json:"prefix"`
+	PathStyle bool     `json:"path_style"` // bucket in the path instead of the host (MinIO)
+	RawPolls  bool     `json:"raw_polls"`
+	Dirs      []string `json:"dirs"`     // e.g. the csv_export, parquet and tracks dirs
+	Interval  Duration `json:"interval"` // how often Dirs are synced, default 1h
+	SettleFor Duration `json:"settle_for"`
+	Manifest  string   `json:"manifest"` // local record of uploaded files, default s3-manifest.json
+}
+
+var (
+	rawPollBuffer bytes.Buffer
+	rawPollWriter *gzip.Writer
+	rawPollHour   time.Time
+	rawPollMutex  = &sync.Mutex{}
+)
+
+// archiveRawPoll buffers one raw API response; each hour is uploaded as one object.
+func archiveRawPoll(body []byte, t time.Time) {
+	sc := cfg().S3
+	if sc.Bucket == "" || !sc.RawPolls {
+		return
+	}
+	line, err := json.Marshal(struct {
+		Time     time.Time       `json:"time"`
+		Response json.RawMessage `json:"response"`
+	}{t, body})
+	if err != nil {
+		return
+	}
+
+	rawPollMutex.Lock()
+	defer rawPollMutex.Unlock()
+
+	hour := t.UTC().Truncate(time.Hour)
+	if rawPollWriter != nil && !hour.Equal(rawPollHour) {
+		rawPollWriter.Close()
+		data := append([]byte(nil), rawPollBuffer.Bytes()...)
+		key := path.Join(sc.Prefix, "raw", rawPollHour.Format("2006/01/02/15")+".jsonl.gz")
+		go func() {
+			if err := s3Put(sc, key, data, "application/gzip"); err != nil {
+				fmt.Printf("[S3] Error uploading %s: %v\n", key, err)
+			}
+		}()
+		rawPollWriter = nil
+	}
+	if rawPollWriter == nil {
+		rawPollBuffer.Reset()
+		rawPollWriter = gzip.NewWriter(&rawPollBuffer)
+		rawPollHour = hour
+	}
+	rawPollWriter.Write(append(line, '\n'))
+}
+
+// manageS3 syncs the export directories on a timer.
+func manageS3() {
+	for {
+		sc := cfg().S3
+		interval := sc.Interval.Duration
+		if interval <= 0 {
+			interval = time.Hour
+		}
+		if sc.Bucket != "" && len(sc.Dirs) > 0 {
+			syncS3Dirs(sc)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// syncS3Dirs uploads new or changed files that haven't been written to for
+// SettleFor (default 1h), so files still being appended to are left alone.
+func syncS3Dirs(sc S3Config) {
+	manifestPath := sc.Manifest
+	if manifestPath == "" {
+		manifestPath = "s3-manifest.json"
+	}
+	settle := sc.SettleFor.Duration
+	if settle <= 0 {
+		settle = time.Hour
+	}
+
+	manifest := make(map[string]string)
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		json.Unmarshal(data, &manifest)
+	}
+
+	uploaded := 0
+	for _, dir := range sc.Dirs {
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || strings.HasSuffix(p, ".tmp") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || time.Since(info.ModTime()) < settle {
+				return nil
+			}
+			stamp := fmt.Sprintf("%d-%d", info.Size(), info.ModTime().Unix())
+			if manifest[p] == stamp {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			key := path.Join(sc.Prefix, filepath.ToSlash(filepath.Clean(p)))
+			if err := s3Put(sc, key, data, ""); err != nil {
+				fmt.Printf("[S3] Error uploading %s: %v\n", p, err)
+				return nil
+			}
+			manifest[p] = stamp
+			uploaded++
+			return nil
+		})
+	}
+	if uploaded == 0 {
+		return
+	}
+	if data, err := json.MarshalIndent(manifest, "", "  "); err == nil {
+		os.WriteFile(manifestPath, data, 0o644)
+	}
+	fmt.Printf("[S3] Uploaded %d files to %s.\n", uploaded, sc.Bucket)
+}
+
+func s3Put(sc S3Config, key string, data []byte, contentType string) error {
+	endpoint := sc.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", sc.Region)
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	host, objectPath := base.Host, "/"+s3EscapePath(key)
+	if sc.PathStyle {
+		objectPath = "/" + sc.Bucket + objectPath
+	} else {
+		host = sc.Bucket + "." + host
+	}
+
+	req, err := http.NewRequest(http.MethodPut, base.Scheme+"://"+host+objectPath, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	signS3Request(req, sc, objectPath, data, time.Now().UTC())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("S3 returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
+
+// signS3Request adds an AWS Signature Version 4 Authorization header.
+func signS3Request(req *http.Request, sc S3Config, canonicalURI string, payload []byte, now time.Time) {
+	region := sc.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate)
+	canonicalRequest := strings.Join([]string{req.Method, canonicalURI, "", canonicalHeaders, signedHeaders, payloadHash}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+sc.SecretKey), day)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sc.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath URI-encodes each segment of an object key the way SigV4 expects.
+func s3EscapePath(key string) string {
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
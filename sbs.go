@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// --- SBS-1 (BaseStation) stream ---
+// dump1090, readsb and most feeder images serve BaseStation CSV on port
+// 30003, one line per message:
+//
+//	MSG,3,1,1,A1B2C3,1,2024/05/01,12:00:00.000,2024/05/01,12:00:00.000,,3500,,,35.74,-78.49,,,0,0,0,0
+//
+// Each transmission type fills in a few of the columns (1 callsign, 3
+// airborne position, 4 velocity, 6 squawk and so on), so every non-empty
+// column is applied to the aircraft as it arrives.
+const defaultSBSAddress = "localhost:30003"
+
+// SBS column indexes
+const (
+	sbsHex      = 4
+	sbsCallsign = 10
+	sbsAltitude = 11
+	sbsSpeed    = 12
+	sbsTrack    = 13
+	sbsLat      = 14
+	sbsLon      = 15
+	sbsVertRate = 16
+	sbsSquawk   = 17
+	sbsOnGround = 21
+)
+
+func readSBS(r io.Reader, t *liveTable) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		applySBSLine(sc.Text(), t)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func applySBSLine(line string, t *liveTable) {
+	f := strings.Split(strings.TrimSpace(line), ",")
+	if len(f) <= sbsCallsign || f[0] != "MSG" {
+		return
+	}
+	hex := strings.ToLower(strings.TrimSpace(f[sbsHex]))
+	if len(hex) != 6 {
+		return
+	}
+	col := func(i int) string {
+		if i < len(f) {
+			return strings.TrimSpace(f[i])
+		}
+		return ""
+	}
+	num := func(i int) (float64, bool) {
+		v, err := strconv.ParseFloat(col(i), 64)
+		return v, err == nil
+	}
+
+	t.update(hex, func(ac *Aircraft) bool {
+		if cs := col(sbsCallsign); cs != "" {
+			ac.Flight = cs
+		}
+		if alt, ok := num(sbsAltitude); ok {
+			ac.AltBaro = alt
+		}
+		if g := col(sbsOnGround); g == "-1" || g == "1" {
+			ac.AltBaro = "ground"
+		}
+		if gs, ok := num(sbsSpeed); ok {
+			ac.GS = someFloat(gs)
+		}
+		if trk, ok := num(sbsTrack); ok {
+			ac.Track = &trk
+		}
+		if vr, ok := num(sbsVertRate); ok {
+			ac.BaroRate = &vr
+		}
+		if sq := col(sbsSquawk); sq != "" {
+			ac.Squawk = sq
+		}
+		lat, okLat := num(sbsLat)
+		lon, okLon := num(sbsLon)
+		if okLat && okLon {
+			ac.Lat, ac.Lon = someFloat(lat), someFloat(lon)
+			return true
+		}
+		return false
+	})
+}
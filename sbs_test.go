@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sbsMsg builds a BaseStation line; rest is columns 10 (callsign) to 21
+// (on ground).
+func sbsMsg(typ, hex, rest string) string {
+	return "MSG," + typ + ",1,1," + hex + ",1,2024/05/01,12:00:00.000,2024/05/01,12:00:00.000," + rest
+}
+
+// sbsSummary lists the fields the SBS decoder sets, for comparing.
+func sbsSummary(ac Aircraft) string {
+	var parts []string
+	if ac.Flight != "" {
+		parts = append(parts, "flight="+ac.Flight)
+	}
+	if ac.AltBaro != nil {
+		parts = append(parts, fmt.Sprintf("alt=%v", ac.AltBaro))
+	}
+	if ac.GS.Valid {
+		parts = append(parts, fmt.Sprintf("gs=%g", ac.GS.V))
+	}
+	if ac.Track != nil {
+		parts = append(parts, fmt.Sprintf("track=%g", *ac.Track))
+	}
+	if ac.BaroRate != nil {
+		parts = append(parts, fmt.Sprintf("rate=%g", *ac.BaroRate))
+	}
+	if ac.Squawk != "" {
+		parts = append(parts, "squawk="+ac.Squawk)
+	}
+	if ac.Lat.Valid || ac.Lon.Valid {
+		parts = append(parts, fmt.Sprintf("pos=%g,%g", ac.Lat.V, ac.Lon.V))
+	}
+	if ac.SeenPos.Valid {
+		parts = append(parts, "fresh")
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestReadSBS(t *testing.T) {
+	withTestEnv(t)
+
+	ident := sbsMsg("1", "A1B2C3", "N123AB  ,,,,,,,,,,,")
+	position := sbsMsg("3", "A1B2C3", ",3500,,,35.74,-78.49,,,0,0,0,0")
+	velocity := sbsMsg("4", "A1B2C3", ",,110,270,,,-640,,,,,")
+	surface := sbsMsg("5", "A1B2C3", ",0,,,,,,,0,,0,-1")
+	squawk := sbsMsg("6", "A1B2C3", ",,,,,,,7700,0,1,0,0")
+
+	cases := []struct {
+		name  string
+		lines []string
+		want  map[string]string // hex -> sbsSummary; unlisted hexes must be absent
+	}{
+		{"identification", []string{ident}, map[string]string{"a1b2c3": "flight=N123AB"}},
+		{"airborne position", []string{position}, map[string]string{"a1b2c3": "alt=3500 pos=35.74,-78.49 fresh"}},
+		{"velocity", []string{velocity}, map[string]string{"a1b2c3": "gs=110 track=270 rate=-640"}},
+		{"on the ground", []string{surface}, map[string]string{"a1b2c3": "alt=ground"}},
+		{"squawk", []string{squawk}, map[string]string{"a1b2c3": "squawk=7700"}},
+		{"messages accumulate", []string{ident, position, velocity, squawk}, map[string]string{
+			"a1b2c3": "flight=N123AB alt=3500 gs=110 track=270 rate=-640 squawk=7700 pos=35.74,-78.49 fresh",
+		}},
+		{"empty columns keep what's known", []string{ident, position, sbsMsg("3", "A1B2C3", ",,,,,,,,,,,")}, map[string]string{
+			"a1b2c3": "flight=N123AB alt=3500 pos=35.74,-78.49 fresh",
+		}},
+		{"half a position is none", []string{sbsMsg("3", "A1B2C3", ",3500,,,35.74,,,,0,0,0,0")}, map[string]string{
+			"a1b2c3": "alt=3500",
+		}},
+		{"unparseable numbers are skipped", []string{sbsMsg("3", "A1B2C3", ",FL350,abc,,35.74,x,,,0,0,0,0")}, map[string]string{
+			"a1b2c3": "",
+		}},
+		{"CRLF and padding", []string{" " + sbsMsg("1", "a1b2c3", "N123AB,,,,,,,,,,,") + "\r"}, map[string]string{
+			"a1b2c3": "flight=N123AB",
+		}},
+		{"trailing columns missing", []string{sbsMsg("1", "A1B2C3", "N123AB")}, map[string]string{
+			"a1b2c3": "flight=N123AB",
+		}},
+		{"short and garbage lines", []string{
+			"",
+			"garbage",
+			"MSG,3,1,1,A1B2C3",
+			"MSG,3,1,1,A1B2C3,1,2024/05/01,12:00:00.000,2024/05/01,12:00:00.000",
+			"STA,,5,179,400AE7,10103,2008/11/28,14:58:51.153,2008/11/28,14:58:51.153,RM",
+			"SEL,,1,1,400AE7,1,2008/11/28,14:58:51.153,2008/11/28,14:58:51.153,N123AB",
+			sbsMsg("3", "", ",3500,,,35.74,-78.49,,,0,0,0,0"),
+			sbsMsg("3", "A1B2", ",3500,,,35.74,-78.49,,,0,0,0,0"),
+			sbsMsg("3", "A1B2C3D4", ",3500,,,35.74,-78.49,,,0,0,0,0"),
+		}, map[string]string{}},
+		{"several aircraft", []string{ident, sbsMsg("6", "ABCDEF", ",,,,,,,1200,0,0,0,0")}, map[string]string{
+			"a1b2c3": "flight=N123AB",
+			"abcdef": "squawk=1200",
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := newLiveTable()
+			if err := readSBS(strings.NewReader(strings.Join(tc.lines, "\n")), table); err != io.EOF {
+				t.Fatalf("readSBS: %v", err)
+			}
+			got := make(map[string]string)
+			for _, ac := range table.snapshot() {
+				got[ac.Hex] = sbsSummary(ac)
+			}
+			for hex, want := range tc.want {
+				if s, ok := got[hex]; !ok {
+					t.Errorf("%s not in the table", hex)
+				} else if s != want {
+					t.Errorf("%s: got %q, want %q", hex, s, want)
+				}
+			}
+			for hex := range got {
+				if _, ok := tc.want[hex]; !ok {
+					t.Errorf("unexpected aircraft %s in the table", hex)
+				}
+			}
+		})
+	}
+}
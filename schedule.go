@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Rule schedules ---
+// A schedule is a list of weekday/time windows. A rule with no windows is
+// always active. Windows may wrap past midnight ("22:00"-"06:00"), in which
+// case the early-morning part belongs to the previous day's window. Local
+// time is display.timezone when that's set.
+type Schedule struct {
+	Days  []string `json:"days"`  // "mon".."sun", "weekdays", "weekends"; empty = every day
+	Start string   `json:"start"` // "HH:MM" local time; empty = midnight
+	End   string   `json:"end"`   // "HH:MM" local time; empty = end of day
+}
+
+func parseClock(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		fmt.Printf("[CF] Invalid schedule time %q, expected HH:MM\n", s)
+		return fallback
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+func (s Schedule) includesDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	short := strings.ToLower(day.String()[:3])
+	for _, d := range s.Days {
+		switch d = strings.ToLower(strings.TrimSpace(d)); d {
+		case "weekdays":
+			if day != time.Saturday && day != time.Sunday {
+				return true
+			}
+		case "weekends":
+			if day == time.Saturday || day == time.Sunday {
+				return true
+			}
+		default:
+			if strings.HasPrefix(d, short) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Schedule) active(t time.Time) bool {
+	start := parseClock(s.Start, 0)
+	end := parseClock(s.End, 24*60)
+	minute := t.Hour()*60 + t.Minute()
+
+	if start <= end {
+		return s.includesDay(t.Weekday()) && minute >= start && minute < end
+	}
+	// Wraps past midnight
+	if minute >= start {
+		return s.includesDay(t.Weekday())
+	}
+	if minute < end {
+		return s.includesDay(t.AddDate(0, 0, -1).Weekday())
+	}
+	return false
+}
+
+func scheduleActive(windows []Schedule, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	t = t.In(displayLocation())
+	for _, w := range windows {
+		if w.active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// triggerActive checks the schedule of one of the built-in triggers
+// ("watchlist", "emergency", "military", "proximity", "special_military").
+func triggerActive(trigger string) bool {
+	return ruleActive(trigger, cfg().Schedules[trigger], clock.Now())
+}
+
+// --- Silenced rules ---
+// A rule or built-in trigger can be silenced for a while at runtime without
+// touching config.json.
+var (
+	silencedRules = make(map[string]time.Time)
+	silenceMutex  = &sync.Mutex{}
+)
+
+func silenceRule(name string, until time.Time) {
+	silenceMutex.Lock()
+	silencedRules[strings.ToLower(name)] = until
+	silenceMutex.Unlock()
+	fmt.Printf("[SC] Silenced '%s' until %s\n", name, formatTime(until))
+}
+
+func unsilenceRule(name string) bool {
+	silenceMutex.Lock()
+	defer silenceMutex.Unlock()
+	_, ok := silencedRules[strings.ToLower(name)]
+	delete(silencedRules, strings.ToLower(name))
+	return ok
+}
+
+func ruleSilenced(name string) bool {
+	silenceMutex.Lock()
+	defer silenceMutex.Unlock()
+	until, ok := silencedRules[strings.ToLower(name)]
+	if ok && clock.Now().After(until) {
+		delete(silencedRules, strings.ToLower(name))
+		return false
+	}
+	return ok
+}
+
+// ruleActive is scheduleActive for a named configurable rule.
+func ruleActive(name string, windows []Schedule, t time.Time) bool {
+	return !ruleSilenced(name) && scheduleActive(windows, t)
+}
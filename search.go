@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- Full-text search ---
+// Searches owners, notes, callsigns and registrations across the alert and
+// flight history (Postgres full-text, see the search indexes in setup.sql)
+// plus the in-memory watchlist. Backs GET /search and the Discord /search
+// command.
+type SearchResult struct {
+	Source       string     `json:"source"` // alert, flight, watchlist
+	Time         *time.Time `json:"time,omitempty"`
+	Hex          string     `json:"hex"`
+	Registration string     `json:"registration,omitempty"`
+	Flight       string     `json:"flight,omitempty"`
+	AircraftType string     `json:"aircraft_type,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	AlertType    string     `json:"alert_type,omitempty"`
+}
+
+// Must match the expressions of the GIN indexes in setup.sql
+const (
+	alertSearchDocument  = `to_tsvector('simple', coalesce(owner, '') || ' ' || coalesce(note, '') || ' ' || coalesce(flight, '') || ' ' || coalesce(registration, '') || ' ' || coalesce(aircraft_type, ''))`
+	flightSearchDocument = `to_tsvector('simple', coalesce(flight, '') || ' ' || coalesce(registration, '') || ' ' || coalesce(aircraft_type, ''))`
+)
+
+const defaultSearchLimit = 25
+
+func searchHistory(query string, limit int) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	results := searchWatchlist(query, limit)
+	if historyDB == nil {
+		return results, nil
+	}
+
+	rows, err := historyDB.Query(`SELECT alerted_at, coalesce(hex, ''), coalesce(registration, ''), coalesce(flight, ''),
+			coalesce(aircraft_type, ''), coalesce(owner, ''), coalesce(note, ''), type
+		FROM alerts
+		WHERE outcome = 'sent' AND `+alertSearchDocument+` @@ websearch_to_tsquery('simple', $1)
+		ORDER BY alerted_at DESC LIMIT $2`, query, limit)
+	if err != nil {
+		return results, err
+	}
+	for rows.Next() {
+		r := SearchResult{Source: "alert"}
+		var t time.Time
+		if err := rows.Scan(&t, &r.Hex, &r.Registration, &r.Flight, &r.AircraftType, &r.Owner, &r.Note, &r.AlertType); err != nil {
+			rows.Close()
+			return results, err
+		}
+		r.Time = &t
+		results = append(results, r)
+	}
+	rows.Close()
+
+	rows, err = historyDB.Query(`SELECT first_seen, hex, coalesce(registration, ''), coalesce(flight, ''), coalesce(aircraft_type, '')
+		FROM flights
+		WHERE `+flightSearchDocument+` @@ websearch_to_tsquery('simple', $1)
+		ORDER BY first_seen DESC LIMIT $2`, query, limit)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := SearchResult{Source: "flight"}
+		var t time.Time
+		if err := rows.Scan(&t, &r.Hex, &r.Registration, &r.Flight, &r.AircraftType); err != nil {
+			return results, err
+		}
+		r.Time = &t
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// searchWatchlist matches every query word against the watchlist entries.
+func searchWatchlist(query string, limit int) []SearchResult {
+	words := strings.Fields(strings.ToLower(query))
+
+	watchlistMutex.RLock()
+	defer watchlistMutex.RUnlock()
+
+	var results []SearchResult
+	for _, entry := range globalWatchlist {
+		haystack := strings.ToLower(strings.Join(append([]string{entry.ICAO, entry.Registration, entry.PlaneType, entry.TypeName, entry.Operator, entry.Note, entry.Category}, entry.Tags...), " "))
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(haystack, w) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, SearchResult{
+				Source: "watchlist", Hex: entry.ICAO, Registration: entry.Registration,
+				AircraftType: entry.PlaneType, Note: entry.Note,
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Hex < results[j].Hex })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// formatSearchResults renders results as a short Discord message.
+func formatSearchResults(query string, results []SearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No matches for **%s**.", query)
+	}
+	lines := []string{fmt.Sprintf("**%d matches for %s:**", len(results), query)}
+	for _, r := range results {
+		label := r.Registration
+		if label == "" {
+			label = r.Hex
+		}
+		line := fmt.Sprintf("• [%s] `%s`", r.Source, label)
+		if r.Flight != "" {
+			line += " " + r.Flight
+		}
+		if r.AircraftType != "" {
+			line += " (" + r.AircraftType + ")"
+		}
+		if r.Owner != "" {
+			line += " — " + r.Owner
+		} else if r.Note != "" {
+			line += " — " + r.Note
+		}
+		if r.Time != nil {
+			line += fmt.Sprintf(" <t:%d:R>", r.Time.Unix())
+		}
+		lines = append(lines, line)
+	}
+	// Discord messages are capped at 2000 characters
+	msg := strings.Join(lines, "\n")
+	if len(msg) > 1900 {
+		msg = strings.ToValidUTF8(msg[:1900], "") + "\n…"
+	}
+	return msg
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// --- CLI: service ---
+// Runs the ingestor as a managed background service, started at boot and
+// restarted if it dies, from wherever the binary and config.json are now.
+//
+//	flight-ingestor service unit       print the systemd unit / launchd plist
+//	flight-ingestor service install    install and enable it
+//	flight-ingestor service start|stop|uninstall
+//
+// Linux gets a systemd unit (logs in the journal), macOS a launchd job
+// (logs in ~/Library/Logs, or /var/log when installed as root) and Windows
+// a native service (logs in flight-ingestor.log next to config.json).
+const (
+	serviceName  = "flight-ingestor"
+	serviceLabel = "com.github.mtickle.flight-ingestor"
+	serviceUsage = "usage: service unit|install|uninstall|start|stop"
+)
+
+func runService(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+	if args[0] == "run" {
+		// How the Windows service manager starts us
+		if err := runWindowsService(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: finding the binary: %v\n", err)
+		return 1
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "unit":
+		_, unit, err := serviceUnit(exe, dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Print(unit)
+	case "install", "uninstall", "start", "stop":
+		if err := controlService(args[0], exe, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+	return 0
+}
+
+// serviceUnit returns where the service definition goes and what's in it.
+func serviceUnit(exe, dir string) (string, string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		var user string
+		if os.Geteuid() != 0 {
+			if name := os.Getenv("USER"); name != "" {
+				user = "User=" + name + "\n"
+			}
+		}
+		return "/etc/systemd/system/" + serviceName + ".service", fmt.Sprintf(`[Unit]
+Description=flight-ingestor ADS-B alerting
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+ExecStart=%s
+WorkingDirectory=%s
+%sRestart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`, systemdQuote(exe), systemdQuote(dir), user), nil
+	case "darwin":
+		path, logPath := launchdPaths()
+		return path, fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+	</array>
+	<key>WorkingDirectory</key>
+	<string>%s</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, serviceLabel, xmlEscape(exe), xmlEscape(dir), xmlEscape(logPath), xmlEscape(logPath)), nil
+	case "windows":
+		return "", "", fmt.Errorf("windows services have no unit file; use \"service install\"")
+	}
+	return "", "", fmt.Errorf("services aren't supported on %s", runtime.GOOS)
+}
+
+// launchdPaths picks a per-user agent, or a system daemon when run as root.
+func launchdPaths() (string, string) {
+	if os.Geteuid() == 0 {
+		return "/Library/LaunchDaemons/" + serviceLabel + ".plist", "/var/log/" + serviceName + ".log"
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents", serviceLabel+".plist"),
+		filepath.Join(home, "Library", "Logs", serviceName+".log")
+}
+
+func controlService(action, exe, dir string) error {
+	if runtime.GOOS == "windows" {
+		return controlWindowsService(action, exe, dir)
+	}
+	path, unit, err := serviceUnit(exe, dir)
+	if err != nil {
+		return err
+	}
+
+	var cmds [][]string
+	switch runtime.GOOS + " " + action {
+	case "linux install":
+		if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+			return fmt.Errorf("%v (installing needs root)", err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		cmds = [][]string{{"systemctl", "daemon-reload"}, {"systemctl", "enable", serviceName}}
+	case "linux uninstall":
+		exec.Command("systemctl", "disable", "--now", serviceName).Run()
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		cmds = [][]string{{"systemctl", "daemon-reload"}}
+	case "linux start", "linux stop":
+		cmds = [][]string{{"systemctl", action, serviceName}}
+	case "darwin install":
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", path)
+		// RunAtLoad starts it straight away
+		cmds = [][]string{{"launchctl", "load", "-w", path}}
+	case "darwin uninstall":
+		exec.Command("launchctl", "unload", "-w", path).Run()
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	case "darwin start", "darwin stop":
+		cmds = [][]string{{"launchctl", action, serviceLabel}}
+	}
+
+	for _, c := range cmds {
+		if out, err := exec.Command(c[0], c[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v: %s", strings.Join(c, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	fmt.Printf("%s: %s done\n", serviceName, action)
+	if action == "install" && runtime.GOOS == "linux" {
+		fmt.Printf("Start it with \"%s service start\"; logs: journalctl -u %s\n", os.Args[0], serviceName)
+	}
+	return nil
+}
+
+// systemdQuote quotes a path for ExecStart= and friends when it has spaces.
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
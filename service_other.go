@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "fmt"
+
+func controlWindowsService(action, exe, dir string) error {
+	return fmt.Errorf("not on windows")
+}
+
+func runWindowsService(args []string) error {
+	return fmt.Errorf("\"service run\" is only used by the Windows service manager")
+}
@@ -0,0 +1,100 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func controlWindowsService(action, exe, dir string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager (run as Administrator): %v", err)
+	}
+	defer m.Disconnect()
+
+	if action == "install" {
+		s, err := m.CreateService(serviceName, exe, mgr.Config{
+			DisplayName: "flight-ingestor",
+			Description: "ADS-B alerting for nearby and notable aircraft",
+			StartType:   mgr.StartAutomatic,
+		}, "service", "run", dir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		// Restart after a crash, like Restart=on-failure
+		s.SetRecoveryActions([]mgr.RecoveryAction{
+			{Type: mgr.ServiceRestart, Delay: 10 * time.Second},
+		}, 24*60*60)
+		fmt.Printf("%s: installed; logs go to %s\n", serviceName, filepath.Join(dir, serviceName+".log"))
+		return nil
+	}
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	switch action {
+	case "uninstall":
+		s.Control(svc.Stop)
+		err = s.Delete()
+	case "start":
+		err = s.Start()
+	case "stop":
+		_, err = s.Control(svc.Stop)
+	}
+	if err == nil {
+		fmt.Printf("%s: %s done\n", serviceName, action)
+	}
+	return err
+}
+
+// runWindowsService is the service's entry point. The service manager
+// starts us in System32 with nowhere to print, so move to the install
+// directory, send the log to a file and reload config.json from there.
+func runWindowsService(args []string) error {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return fmt.Errorf("\"service run\" is for the Windows service manager; use \"service start\"")
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: service run DIR")
+	}
+	if err := os.Chdir(args[0]); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(serviceName+".log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	os.Stdout, os.Stderr = f, f
+	activeConfig.Store(loadConfig())
+	applyObserverLocation(cfg())
+	return svc.Run(serviceName, windowsService{})
+}
+
+type windowsService struct{}
+
+func (windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	startDaemon()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for req := range requests {
+		switch req.Cmd {
+		case svc.Interrogate:
+			status <- req.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			fmt.Println("[SV] Service stopping")
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		}
+	}
+	return false, 0
+}
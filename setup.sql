@@ -50,14 +50,97 @@ COMMENT ON COLUMN aircraft_sightings.alt_baro IS 'Altitude (can be "ground" or a
 -- This will make it much faster to search for all sightings of a specific aircraft.
 --
 CREATE INDEX IF NOT EXISTS idx_sightings_hex ON aircraft_sightings (hex);
+CREATE INDEX IF NOT EXISTS idx_sightings_seen_at ON aircraft_sightings (seen_at);
+
+
+--
+-- Table 3: flights (The Sessions)
+-- Consecutive sightings of the same hex collapsed into one row. A hex that
+-- hasn't been seen for history.flight_gap (default 10m) starts a new flight.
+--
+CREATE TABLE IF NOT EXISTS flights (
+    id BIGSERIAL PRIMARY KEY,
+    hex TEXT NOT NULL,
+    flight TEXT,
+    registration TEXT,
+    aircraft_type TEXT,
+    mil BOOLEAN,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    sightings INTEGER DEFAULT 0,
+    min_alt_ft INTEGER,
+    max_alt_ft INTEGER,
+    max_gs NUMERIC(6, 1),
+    closest_nm NUMERIC(7, 2),
+    closest_lat NUMERIC(9, 6),
+    closest_lon NUMERIC(9, 6),
+    alerts TEXT[] DEFAULT '{}'
+);
+
+COMMENT ON TABLE flights IS 'One row per visit of an aircraft to the coverage area.';
+COMMENT ON COLUMN flights.closest_nm IS 'Closest approach to the observer, in nautical miles.';
+COMMENT ON COLUMN flights.alerts IS 'Alert types that were sent during this flight.';
+
+CREATE INDEX IF NOT EXISTS idx_flights_hex ON flights (hex);
+CREATE INDEX IF NOT EXISTS idx_flights_first_seen ON flights (first_seen);
+
+
+--
+-- Table 4: alerts (The Decisions)
+-- Every alert decision, including muted and failed ones (see outcome).
+--
+CREATE TABLE IF NOT EXISTS alerts (
+    id BIGSERIAL PRIMARY KEY,
+    alerted_at TIMESTAMPTZ DEFAULT NOW(),
+    flight_id BIGINT REFERENCES flights (id) ON DELETE SET NULL,
+    type TEXT NOT NULL,
+    rule TEXT,
+    hex TEXT,
+    flight TEXT,
+    registration TEXT,
+    aircraft_type TEXT,
+    owner TEXT,
+    squawk TEXT,
+    alt_baro TEXT,
+    lat NUMERIC(9, 6),
+    lon NUMERIC(9, 6),
+    note TEXT,
+    tags TEXT[],
+    outcome TEXT,
+    embed JSONB
+);
+
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS embed JSONB;
+
+COMMENT ON TABLE alerts IS 'Log of every alert decision (sent, muted, no_webhook, error).';
+COMMENT ON COLUMN alerts.embed IS 'The Discord message as it was posted.';
+
+CREATE INDEX IF NOT EXISTS idx_alerts_alerted_at ON alerts (alerted_at);
+CREATE INDEX IF NOT EXISTS idx_alerts_flight_id ON alerts (flight_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_hex ON alerts (hex);
+
+--
+-- Full-text search indexes (GET /search, Discord /search).
+-- The expressions must match the ones in search.go.
+--
+CREATE INDEX IF NOT EXISTS idx_alerts_search ON alerts USING GIN (
+    to_tsvector('simple', coalesce(owner, '') || ' ' || coalesce(note, '') || ' ' || coalesce(flight, '') || ' ' || coalesce(registration, '') || ' ' || coalesce(aircraft_type, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_flights_search ON flights USING GIN (
+    to_tsvector('simple', coalesce(flight, '') || ' ' || coalesce(registration, '') || ' ' || coalesce(aircraft_type, ''))
+);
 
 --
 -- Grant Permissions (Optional but Recommended)
 -- Replace 'YOUR_USER' with the username your Go app uses.
 --
 GRANT SELECT, INSERT, UPDATE, DELETE ON aircraft_details TO avnadmin;
-GRANT SELECT, INSERT ON aircraft_sightings TO avnadmin;
+GRANT SELECT, INSERT, DELETE ON aircraft_sightings TO avnadmin;
 GRANT USAGE, SELECT ON SEQUENCE aircraft_sightings_id_seq TO avnadmin;
+GRANT SELECT, INSERT, UPDATE, DELETE ON flights TO avnadmin;
+GRANT USAGE, SELECT ON SEQUENCE flights_id_seq TO avnadmin;
+GRANT SELECT, INSERT, DELETE ON alerts TO avnadmin;
+GRANT USAGE, SELECT ON SEQUENCE alerts_id_seq TO avnadmin;
 
 --
 -- End of script
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// --- Append-only JSONL log ---
+// One JSON object per line ({"kind":"sighting"|"alert", ...}), rotated by
+// size: sightings.jsonl -> sightings.jsonl.1 -> ... -> sightings.jsonl.N.
+type SightingLogConfig struct {
+	Path      string  `json:"path"` // empty disables the log
+	MaxSizeMB float64 `json:"max_size_mb"`
+	MaxFiles  int     `json:"max_files"`
+}
+
+var (
+	sightingLogFile  *os.File
+	sightingLogPath  string
+	sightingLogMutex = &sync.Mutex{}
+)
+
+func writeSightingLog(kind string, record any) {
+	lc := cfg().SightingLog
+	if lc.Path == "" {
+		return
+	}
+
+	line, err := json.Marshal(struct {
+		Kind string `json:"kind"`
+		Data any    `json:"data"`
+	}{kind, record})
+	if err != nil {
+		return
+	}
+
+	sightingLogMutex.Lock()
+	defer sightingLogMutex.Unlock()
+
+	if sightingLogFile == nil || sightingLogPath != lc.Path {
+		if sightingLogFile != nil {
+			sightingLogFile.Close()
+		}
+		sightingLogFile, err = os.OpenFile(lc.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Printf("[LOG] Error opening %s: %v\n", lc.Path, err)
+			sightingLogFile = nil
+			return
+		}
+		sightingLogPath = lc.Path
+	}
+
+	if _, err := sightingLogFile.Write(append(line, '\n')); err != nil {
+		fmt.Printf("[LOG] Error writing %s: %v\n", lc.Path, err)
+		return
+	}
+
+	if lc.MaxSizeMB > 0 {
+		if info, err := sightingLogFile.Stat(); err == nil && float64(info.Size()) > lc.MaxSizeMB*1024*1024 {
+			rotateSightingLog(lc)
+		}
+	}
+}
+
+// rotateSightingLog must be called with sightingLogMutex held.
+func rotateSightingLog(lc SightingLogConfig) {
+	sightingLogFile.Close()
+	sightingLogFile = nil
+
+	maxFiles := lc.MaxFiles
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	os.Remove(fmt.Sprintf("%s.%d", lc.Path, maxFiles))
+	for i := maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", lc.Path, i), fmt.Sprintf("%s.%d", lc.Path, i+1))
+	}
+	if err := os.Rename(lc.Path, lc.Path+".1"); err != nil {
+		fmt.Printf("[LOG] Error rotating %s: %v\n", lc.Path, err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- SIGMET/AIRMET awareness ---
+// Polls aviationweather.gov for active advisories near us and tags alerts for
+// aircraft inside one, e.g. a proximity alert for a helicopter flying low
+// through a convective SIGMET.
+type SigmetConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Hazards  []string `json:"hazards"`   // "CONVECTIVE", "TURB", "ICE", "IFR", "MTN OBSCN"; default CONVECTIVE
+	Types    []string `json:"types"`     // alert types to check, default proximity and dwell
+	RadiusNM float64  `json:"radius_nm"` // ignore advisories with no vertex this close, default 250
+	Interval Duration `json:"interval"`  // default 10m
+}
+
+const sigmetAPIURL = "https://aviationweather.gov/api/data/airsigmet?format=json"
+
+type Advisory struct {
+	Kind      string // SIGMET, AIRMET
+	Hazard    string
+	Series    string
+	ValidFrom time.Time
+	ValidTo   time.Time
+	TopFT     float64 // 0 when unbounded
+	Area      Polygon
+}
+
+var (
+	activeAdvisories []Advisory
+	advisoryMutex    = &sync.Mutex{}
+)
+
+func manageSigmets() {
+	for {
+		sc := cfg().Sigmet
+		interval := sc.Interval.Duration
+		if interval <= 0 {
+			interval = 10 * time.Minute
+		}
+		if sc.Enabled && !offlineMode() {
+			if advisories, err := fetchAdvisories(sc); err != nil {
+				fmt.Printf("[WX] Error fetching SIGMETs: %v\n", err)
+			} else {
+				advisoryMutex.Lock()
+				activeAdvisories = advisories
+				advisoryMutex.Unlock()
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+func fetchAdvisories(sc SigmetConfig) ([]Advisory, error) {
+	resp, err := httpClient.Get(sigmetAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aviationweather.gov returned non-200 status: %s", resp.Status)
+	}
+	var raw []struct {
+		Type     string   `json:"airSigmetType"`
+		Hazard   string   `json:"hazard"`
+		Series   string   `json:"seriesId"`
+		From     int64    `json:"validTimeFrom"`
+		To       int64    `json:"validTimeTo"`
+		AltHigh1 OptFloat `json:"altitudeHi1"`
+		AltHigh2 OptFloat `json:"altitudeHi2"`
+		Coords   []struct {
+			Lat OptFloat `json:"lat"`
+			Lon OptFloat `json:"lon"`
+		} `json:"coords"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	hazards := sc.Hazards
+	if len(hazards) == 0 {
+		hazards = []string{"CONVECTIVE"}
+	}
+	radius := sc.RadiusNM
+	if radius <= 0 {
+		radius = 250
+	}
+
+	var advisories []Advisory
+	for _, r := range raw {
+		if !slices.ContainsFunc(hazards, func(h string) bool { return strings.EqualFold(h, r.Hazard) }) {
+			continue
+		}
+		a := Advisory{
+			Kind:      r.Type,
+			Hazard:    r.Hazard,
+			Series:    r.Series,
+			ValidFrom: time.Unix(r.From, 0),
+			ValidTo:   time.Unix(r.To, 0),
+			TopFT:     max(r.AltHigh1.Or(0), r.AltHigh2.Or(0)),
+		}
+		near := false
+		for _, c := range r.Coords {
+			if !c.Lat.Valid || !c.Lon.Valid {
+				continue
+			}
+			lat, lon := c.Lat.V, c.Lon.V
+			a.Area = append(a.Area, LatLon{lat, lon})
+			near = near || geo.DistanceNM(apiLat, apiLng, lat, lon) <= radius
+		}
+		if near && len(a.Area) >= 3 {
+			advisories = append(advisories, a)
+		}
+	}
+	fmt.Printf("[WX] %d active advisories within %gnm.\n", len(advisories), radius)
+	return advisories, nil
+}
+
+// advisoryFor returns the advisory the aircraft is flying through, if the
+// alert type is one we check.
+func advisoryFor(alertType string, ac Aircraft) *Advisory {
+	sc := cfg().Sigmet
+	types := sc.Types
+	if len(types) == 0 {
+		types = []string{"proximity", "dwell"}
+	}
+	if !sc.Enabled || !slices.Contains(types, alertType) {
+		return nil
+	}
+	lat, lon, ok := getActualCoords(ac)
+	if !ok {
+		return nil
+	}
+	alt, hasAlt := altitudeFeet(ac)
+	now := clock.Now()
+
+	advisoryMutex.Lock()
+	defer advisoryMutex.Unlock()
+	for _, a := range activeAdvisories {
+		if now.Before(a.ValidFrom) || now.After(a.ValidTo) {
+			continue
+		}
+		if a.TopFT > 0 && hasAlt && alt > a.TopFT {
+			continue
+		}
+		if a.Area.Contains(lat, lon) {
+			return &a
+		}
+	}
+	return nil
+}
+
+func (a Advisory) describe() string {
+	name := strings.TrimSpace(fmt.Sprintf("%s %s", a.Kind, a.Series))
+	hazard := strings.ToLower(a.Hazard)
+	if a.Hazard == "CONVECTIVE" {
+		return fmt.Sprintf("⛈️ Inside convective %s (thunderstorms), valid until %s", name, a.ValidTo.UTC().Format("1504Z"))
+	}
+	return fmt.Sprintf("⚠️ Inside %s %s, valid until %s", hazard, name, a.ValidTo.UTC().Format("1504Z"))
+}
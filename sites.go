@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// --- Locations ---
+// Extra receiver sites watched from the same process. Each location gets its
+// own radius poll and its own alert state (radius, rule, aggregate,
+// proximity and transit), so an aircraft between two houses alerts at each.
+// Alerts from a location carry its name and can go to their own channels.
+//
+// The radius rules take the site's center as an argument. Their state lives
+// in package globals, so a location's poll is processed by swapping its
+// state in under radiusMutex and swapping the home site's back afterwards.
+// The observer globals (apiLat, apiLng, apiRadiusNM) always hold the home
+// site. Airport movements, overhead heads-ups, UAT, the live view (Telegram,
+// the TUI), sightings (logs, history, tracks, coverage) and poll statistics
+// stay with the home site.
+type Location struct {
+	Name     string            `json:"name"`
+	Lat      float64           `json:"lat"`
+	Lon      float64           `json:"lon"`
+	RadiusNM float64           `json:"radius_nm"` // default 50
+	FeedURL  string            `json:"feed_url"`  // the site's receiver aircraft.json; default an adsb.lol point query
+	Webhook  string            `json:"webhook"`   // channel name or URL for every alert from this site
+	Channels map[string]string `json:"channels"`  // per alert type, e.g. {"proximity": "garage"}; wins over webhook
+}
+
+func (l Location) radius() float64 {
+	if l.RadiusNM > 0 {
+		return l.RadiusNM
+	}
+	return 50
+}
+
+func (l Location) center() sourceCenter {
+	return sourceCenter{l.Lat, l.Lon, l.radius()}
+}
+
+type siteState struct {
+	loc       *Location
+	radius    map[string]RadiusAircraftState
+	rules     map[string]RuleHitState
+	aggregate map[string]bool
+	proximity map[string]time.Time
+	transit   map[string]time.Time
+}
+
+// activeLocation is the location being processed, nil for home.
+var activeLocation atomic.Pointer[Location]
+
+func newSiteState(loc Location) *siteState {
+	return &siteState{
+		loc:       &loc,
+		radius:    make(map[string]RadiusAircraftState),
+		rules:     make(map[string]RuleHitState),
+		aggregate: make(map[string]bool),
+		proximity: make(map[string]time.Time),
+		transit:   make(map[string]time.Time),
+	}
+}
+
+// swap exchanges the site's state with the globals. Calling it twice puts
+// everything back. Callers hold radiusMutex.
+func (s *siteState) swap() {
+	globalRadiusState, s.radius = s.radius, globalRadiusState
+	globalRuleState, s.rules = s.rules, globalRuleState
+	globalAggregateEpisodes, s.aggregate = s.aggregate, globalAggregateEpisodes
+	proximityAlertedMutex.Lock()
+	proximityAlertedAt, s.proximity = s.proximity, proximityAlertedAt
+	proximityAlertedMutex.Unlock()
+	transitMutex.Lock()
+	transitAlerted, s.transit = s.transit, transitAlerted
+	transitMutex.Unlock()
+}
+
+// process runs one snapshot as this site. Everything is undone in defers, so
+// a panic recovered by supervise doesn't leave the mutex held or the home
+// site swapped out.
+func (s *siteState) process(aircraft []Aircraft) {
+	radiusMutex.Lock()
+	defer radiusMutex.Unlock()
+	s.swap()
+	defer s.swap()
+	activeLocation.Store(s.loc)
+	defer activeLocation.Store(nil)
+	processRadiusSnapshot(s.loc.center(), aircraft)
+}
+
+// startLocations starts a poll loop per configured location. Locations are
+// read at startup; adding or removing one needs a restart.
+func startLocations() {
+	for _, loc := range cfg().Locations {
+		if loc.Name == "" {
+			fmt.Println("[LOC] Skipping a location without a name")
+			continue
+		}
+		site := newSiteState(loc)
+		supervise("location "+loc.Name, func() { locationLoop(site) })
+	}
+}
+
+func locationLoop(site *siteState) {
+	loc := site.loc
+	fmt.Printf("[LOC] Watching %s: %.4f, %.4f, %s\n", loc.Name, loc.Lat, loc.Lon, fmtRadius(loc.radius()))
+	ticker := time.NewTicker(radiusPollInterval)
+	defer ticker.Stop()
+	for {
+		if offlineMode() && loc.FeedURL == "" {
+			// Nothing to poll without adsb.lol
+			<-ticker.C
+			continue
+		}
+		aircraft, err := fetchLocationAircraft(loc)
+		if err != nil {
+			fmt.Printf("[LOC] %s: %v\n", loc.Name, err)
+		} else {
+			site.process(aircraft)
+		}
+		<-ticker.C
+	}
+}
+
+// fetchLocationAircraft polls the location's own receiver when it has one,
+// else the configured aggregator (adsb.lol unless source says otherwise)
+// around it.
+func fetchLocationAircraft(loc *Location) ([]Aircraft, error) {
+	sc := SourceConfig{Type: primaryAggregator(cfg())}
+	if loc.FeedURL != "" {
+		sc = SourceConfig{Type: "readsb", URL: loc.FeedURL}
+	}
+	src, err := newSource(sc, loc.center())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+	return src.Fetch(ctx)
+}
+
+// alertLocation is the location an alert was raised at. The nationwide
+// types come from their own loops, which may run while a location is being
+// processed, so they never belong to one.
+func alertLocation(alertType string) *Location {
+	switch alertType {
+	case "special_military", "global_watch":
+		return nil
+	}
+	return activeLocation.Load()
+}
+
+// alertCenter is the observer an alert is measured from: the location it was
+// raised at, else the home site.
+func alertCenter(alertType string) sourceCenter {
+	if loc := alertLocation(alertType); loc != nil {
+		return loc.center()
+	}
+	return homeCenter()
+}
+
+// siteName tags alerts: the location being processed, else observer.name.
+func siteName(alertType string) string {
+	if loc := alertLocation(alertType); loc != nil {
+		return loc.Name
+	}
+	return cfg().Observer.Name
+}
+
+// siteStateName keeps each location's radius state under its own Redis keys.
+func siteStateName(name string) string {
+	if loc := activeLocation.Load(); loc != nil {
+		return "location:" + loc.Name + ":" + name
+	}
+	return name
+}
+
+// siteWebhook routes an alert raised at a location to that location's
+// channels.
+func siteWebhook(alertType, webhook string) string {
+	loc := alertLocation(alertType)
+	if loc == nil {
+		return webhook
+	}
+	if ch, ok := loc.Channels[alertType]; ok {
+		return resolveChannel(ch)
+	}
+	if loc.Webhook != "" {
+		return resolveChannel(loc.Webhook)
+	}
+	return webhook
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Public social posting ---
+// Sent alerts of the opted-in types are re-posted to public accounts
+// (Mastodon, ...). Runs after the Discord post so a slow instance never
+// delays the alert itself.
+type socialImage struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	Alt         string
+}
+
+const maxSocialImageBytes = 8 << 20
+
+var socialHTTP = newHTTPClient(30 * time.Second)
+
+func publishSocial(rec AlertRecord, details AircraftDetail) {
+	// Images are fetched at most once, and only if some notifier wants this alert
+	images := sync.OnceValue(func() []socialImage { return alertImages(rec, details) })
+	if postsAlertType(cfg().Mastodon.Types, rec) {
+		postMastodon(rec, images)
+	}
+	if postsAlertType(cfg().Bluesky.Types, rec) {
+		postBluesky(rec, images)
+	}
+}
+
+// notifyLocal hands an alert to the notifiers on this machine. They don't
+// wait on Discord: a workstation may have no webhook at all, and an outage
+// shouldn't silence them. Muted and quiet-hours alerts never get here. Site
+// is the observer the alert was raised for.
+func notifyLocal(rec AlertRecord, site sourceCenter) {
+	if cfg().Announce.Enabled && postsAlertType(cfg().Announce.Types, rec) {
+		announceAlert(rec, site)
+	}
+	if cfg().Desktop.Enabled && postsAlertType(cfg().Desktop.Types, rec) {
+		go notifyDesktop(rec)
+	}
+}
+
+// postsAlertType reports whether a notifier opted in to this alert. Types
+// default to watchlist and special_military; "rule:<name>" opts in a single rule.
+func postsAlertType(types []string, rec AlertRecord) bool {
+	if len(types) == 0 {
+		types = []string{"watchlist", "special_military"}
+	}
+	return containsFold(types, rec.Type) || (rec.Rule != "" && containsFold(types, "rule:"+rec.Rule))
+}
+
+// socialText is the plain-text body shared by the social notifiers.
+func socialText(rec AlertRecord, hashtags []string) string {
+	lines := []string{alertTitle(rec)}
+	if rec.Owner != "" {
+		lines = append(lines, "Operator: "+rec.Owner)
+	}
+	if rec.AltBaro != "" && rec.AltBaro != "N/A" {
+		lines = append(lines, "Altitude: "+fmtRecordAltitude(rec.AltBaro))
+	}
+	if rec.Note != "" {
+		lines = append(lines, strings.NewReplacer("**", "", "`", "").Replace(rec.Note))
+	}
+	lines = append(lines, alertLink(rec))
+	var tags []string
+	for _, t := range hashtags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, "#"+strings.TrimPrefix(t, "#"))
+		}
+	}
+	if len(tags) > 0 {
+		lines = append(lines, strings.Join(tags, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// alertImages downloads the map and aircraft photo for an alert.
+func alertImages(rec AlertRecord, details AircraftDetail) []socialImage {
+	var images []socialImage
+	if rec.Lat != nil && rec.Lon != nil {
+		if data, err := mapImage(*rec.Lat, *rec.Lon); err == nil {
+			img := socialImage{ContentType: "image/png", Data: data}
+			img.Name, img.Alt = "map.png", fmt.Sprintf("Map of the aircraft position near %.3f, %.3f", *rec.Lat, *rec.Lon)
+			images = append(images, img)
+		} else {
+			fmt.Printf("[SOC] Error fetching map: %v\n", err)
+		}
+	}
+	if details.FullImageURL != "" {
+		if img, err := downloadImage(details.FullImageURL); err == nil {
+			img.Name, img.Alt = "photo.jpg", fmt.Sprintf("Photo of %s", alertTitle(rec))
+			images = append(images, img)
+		} else {
+			fmt.Printf("[SOC] Error fetching photo: %v\n", err)
+		}
+	}
+	return images
+}
+
+func downloadImage(url string) (socialImage, error) {
+	resp, err := socialHTTP.Get(url)
+	if err != nil {
+		return socialImage{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return socialImage{}, fmt.Errorf("non-200 status: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSocialImageBytes))
+	if err != nil {
+		return socialImage{}, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return socialImage{ContentType: contentType, Data: data}, nil
+}
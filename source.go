@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Aircraft sources ---
+// Where the radius loop gets its aircraft from. adsb.lol's point query is the
+// default, with adsb.fi and airplanes.live as drop-in alternatives; "readsb"
+// reads aircraft.json from your own dump1090, readsb or tar1090 (over HTTP,
+// or straight from disk when it runs on the same box) so aircraft over the
+// house don't depend on an upstream aggregator. A local receiver reports
+// everything it hears, so its aircraft are cut down to the radius around the
+// site here. Several sources are merged with failover; see multisource.go.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Aircraft, error)
+}
+
+type SourceConfig struct {
+	Type    string `json:"type"`    // "adsb.lol" (default), "adsb.fi", "airplanes.live", "readsb" ("dump1090" and "tar1090" work too), "sbs", "beast" or "opensky"
+	URL     string `json:"url"`     // readsb: aircraft.json URL or file path, e.g. "http://localhost/tar1090/data/aircraft.json" or "/run/readsb/aircraft.json"
+	Address string `json:"address"` // sbs, beast: receiver host:port, default "localhost:30003" (sbs) or "localhost:30005" (beast)
+	// opensky: OAuth client credentials (anonymous without) and the day's
+	// credit allowance polls are spaced to fit, default 400 or 4000
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	DailyCredits int    `json:"daily_credits"`
+	// With several sources, a source whose newest position is older than
+	// this counts as failed. Default 2m.
+	StaleAfter Duration `json:"stale_after"`
+}
+
+const sourceTimeout = 20 * time.Second
+
+func (sc SourceConfig) address(def string) string {
+	if sc.Address != "" {
+		return sc.Address
+	}
+	return def
+}
+
+var sourceHTTP = newHTTPClient(sourceTimeout)
+
+// sourceCenter is the circle a source is asked about.
+type sourceCenter struct {
+	Lat, Lon, RadiusNM float64
+}
+
+func homeCenter() sourceCenter {
+	return sourceCenter{apiLat, apiLng, apiRadiusNM}
+}
+
+func normalizeSourceType(t string) string {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "", "adsb.lol", "adsblol":
+		return "adsb.lol"
+	case "adsb.fi", "adsbfi":
+		return "adsb.fi"
+	case "airplanes.live", "airplaneslive":
+		return "airplanes.live"
+	case "readsb", "dump1090", "tar1090", "aircraft.json":
+		return "readsb"
+	case "sbs", "sbs1", "sbs-1", "basestation":
+		return "sbs"
+	case "beast":
+		return "beast"
+	case "opensky", "opensky-network":
+		return "opensky"
+	}
+	return strings.ToLower(t)
+}
+
+// newSource builds the source for one config entry around a center.
+func newSource(sc SourceConfig, center sourceCenter) (Source, error) {
+	switch normalizeSourceType(sc.Type) {
+	case "adsb.lol", "adsb.fi", "airplanes.live":
+		name := normalizeSourceType(sc.Type)
+		if offlineMode() {
+			return nil, fmt.Errorf("%s: %w", name, errOffline)
+		}
+		return &jsonSource{name: name, url: aggregatorPointURL(name, center.Lat, center.Lon, center.RadiusNM), center: &center}, nil
+	case "readsb":
+		if sc.URL == "" {
+			return nil, fmt.Errorf("readsb source needs a url")
+		}
+		return &jsonSource{name: "readsb", url: sc.URL, center: &center}, nil
+	case "sbs":
+		return openStream("sbs", sc.address(defaultSBSAddress), center, readSBS), nil
+	case "beast":
+		return openStream("beast", sc.address(defaultBeastAddress), center, readBeast), nil
+	case "opensky":
+		if offlineMode() {
+			return nil, fmt.Errorf("opensky: %w", errOffline)
+		}
+		return newOpenSkySource(sc, center), nil
+	}
+	return nil, fmt.Errorf("unknown source type %q", sc.Type)
+}
+
+// radiusSource is the home site's source. Offline mode skips the
+// aggregators, and without a source of its own polls the receiver from
+// offline.receiver_url or feeder.url, as it always has.
+func radiusSource() (Source, error) {
+	var configs []SourceConfig
+	for _, sc := range cfg().Sources {
+		if offlineMode() && (isAggregator(sc.Type) || normalizeSourceType(sc.Type) == "opensky") {
+			continue
+		}
+		configs = append(configs, sc)
+	}
+	if len(configs) == 0 {
+		sc := SourceConfig{Type: "adsb.lol"}
+		if offlineMode() {
+			sc = SourceConfig{Type: "readsb", URL: offlineReceiverURL()}
+		}
+		configs = append(configs, sc)
+	}
+
+	sources := make([]Source, 0, len(configs))
+	var streams []*streamSource
+	for i, sc := range configs {
+		src, err := newSource(sc, homeCenter())
+		if err != nil {
+			return nil, fmt.Errorf("sources[%d]: %w", i, err)
+		}
+		switch s := src.(type) {
+		case *jsonSource:
+			// The archive keeps the primary's raw poll
+			s.archive = i == 0
+		case *streamSource:
+			streams = append(streams, s)
+		}
+		sources = append(sources, src)
+	}
+	closeStreamsExcept(streams)
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+	return newMultiSource(sources, configs), nil
+}
+
+// primaryAggregator is the aggregator the config lists first, which locations
+// without a feed of their own also use.
+func primaryAggregator(c *Config) string {
+	for _, sc := range c.Sources {
+		if isAggregator(sc.Type) {
+			return normalizeSourceType(sc.Type)
+		}
+	}
+	return "adsb.lol"
+}
+
+// --- Aggregators ---
+// The three public APIs serve the same readsb-style aircraft for a circle,
+// with small differences: adsb.fi names the list "aircraft" as aircraft.json
+// does (decodeAircraftList takes either), and only adsb.lol sets "mil"; the
+// others carry it in the dbFlags bit, which filterAround maps across.
+var aggregatorPointURLs = map[string]string{
+	"adsb.lol":       "https://api.adsb.lol/v2/point/%.6f/%.6f/%.0f",
+	"adsb.fi":        "https://opendata.adsb.fi/api/v2/lat/%.6f/lon/%.6f/dist/%.0f",
+	"airplanes.live": "https://api.airplanes.live/v2/point/%.6f/%.6f/%.0f",
+}
+
+func isAggregator(t string) bool {
+	_, ok := aggregatorPointURLs[normalizeSourceType(t)]
+	return ok
+}
+
+func aggregatorPointURL(name string, lat, lon, radiusNM float64) string {
+	return fmt.Sprintf(aggregatorPointURLs[name], lat, lon, radiusNM)
+}
+
+// --- JSON polling (aggregators, readsb) ---
+type jsonSource struct {
+	name    string
+	url     string        // http(s) URL or a local file
+	center  *sourceCenter // cuts the feed to the radius and normalises it
+	archive bool          // keep the raw body for the S3 archive
+}
+
+func (s *jsonSource) Name() string { return s.name }
+
+func (s *jsonSource) Fetch(ctx context.Context) ([]Aircraft, error) {
+	body, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.archive {
+		archiveRawPoll(body, clock.Now())
+	}
+	aircraft, err := decodeAircraftList(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error decoding JSON: %v", s.name, err)
+	}
+	if s.center != nil {
+		aircraft = filterAround(aircraft, s.center.Lat, s.center.Lon, s.center.RadiusNM)
+	}
+	return aircraft, nil
+}
+
+func (s *jsonSource) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.url, "http://") && !strings.HasPrefix(s.url, "https://") {
+		return os.ReadFile(s.url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sourceHTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error fetching aircraft: %v", s.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned non-200 status: %s", s.name, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading response body: %v", s.name, err)
+	}
+	return body, nil
+}
+
+// filterAround drops aircraft placed outside radius of a point and
+// normalises readsb's conventions (non-ICAO "~" hexes, the dbFlags mil bit).
+// Aircraft without a position are kept, as mergeUAT keeps them: an
+// emergency squawk doesn't need coordinates to alert.
+func filterAround(aircraft []Aircraft, lat, lon, radius float64) []Aircraft {
+	inRange := aircraft[:0]
+	for _, ac := range aircraft {
+		acLat, acLon, hasPos := getActualCoords(ac)
+		if hasPos && geo.DistanceNM(lat, lon, acLat, acLon) > radius {
+			continue
+		}
+		ac.Hex = strings.ToLower(strings.TrimPrefix(ac.Hex, "~"))
+		if ac.DBFlags&1 != 0 {
+			ac.Mil = true
+		}
+		inRange = append(inRange, ac)
+	}
+	return inRange
+}
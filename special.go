@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- Special aircraft types (nationwide scan) ---
+type SpecialType struct {
+	Type              string       `json:"type"`                // ICAO type designator, e.g. "E6"
+	Name              string       `json:"name"`                // friendly display name, e.g. "E-6B Mercury"
+	Channel           string       `json:"channel"`             // defaults to the special_military channel
+	Cooldown          Duration     `json:"cooldown"`            // re-alert window per aircraft, defaults to nationwide.realert_window
+	RealertDistanceNM float64      `json:"realert_distance_nm"` // re-alert early after moving this far, defaults to nationwide.realert_distance_nm
+	BBox              *BoundingBox `json:"bbox"`                // only alert inside this box
+	Region            string       `json:"region"`              // only alert inside this named region
+	Polygon           Polygon      `json:"polygon"`             // only alert inside this inline polygon
+	Tags              []string     `json:"tags"`
+	Disabled          bool         `json:"disabled"` // keep the entry but stop scanning for it
+	Emoji             string       `json:"emoji"`    // prefixed to the alert title, e.g. "📡"
+	Title             string       `json:"title"`    // alert title, default "Military Flight: <callsign>"
+	Color             int          `json:"color"`    // embed color, default purple
+}
+
+type BoundingBox struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// NationwideConfig holds the defaults for every special type.
+type NationwideConfig struct {
+	RealertWindow     Duration `json:"realert_window"`
+	RealertDistanceNM float64  `json:"realert_distance_nm"` // 0 disables movement-based re-alerts
+}
+
+const defaultSpecialCooldown = 24 * time.Hour
+
+func (b BoundingBox) contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+func (s SpecialType) cooldown() time.Duration {
+	if s.Cooldown.Duration > 0 {
+		return s.Cooldown.Duration
+	}
+	if cfg().Nationwide.RealertWindow.Duration > 0 {
+		return cfg().Nationwide.RealertWindow.Duration
+	}
+	return defaultSpecialCooldown
+}
+
+func (s SpecialType) realertDistance() float64 {
+	if s.RealertDistanceNM > 0 {
+		return s.RealertDistanceNM
+	}
+	return cfg().Nationwide.RealertDistanceNM
+}
+
+func (s SpecialType) webhook() string {
+	if s.Channel != "" {
+		return resolveChannel(s.Channel)
+	}
+	return resolveChannel("special_military")
+}
+
+// alertContext carries the type's title and color overrides. A custom title
+// still gets the callsign appended so alerts stay distinguishable.
+func (s SpecialType) alertContext(ac Aircraft) *AlertContext {
+	actx := &AlertContext{Tags: s.Tags, Color: s.Color}
+	if s.Title == "" && s.Emoji == "" {
+		return actx
+	}
+	title := T("Military Flight: %s", ac.Flight)
+	if s.Title != "" {
+		title = s.Title
+		if flight := strings.TrimSpace(ac.Flight); flight != "" {
+			title += ": " + flight
+		}
+	}
+	if s.Emoji != "" {
+		title = s.Emoji + " " + title
+	}
+	actx.Title = title
+	return actx
+}
+
+// inRegion applies the optional geographic restrictions (all that are set
+// must match). Aircraft without a position can't be placed, so they don't
+// match a restricted type.
+func (s SpecialType) inRegion(ac Aircraft) bool {
+	if s.BBox == nil && s.Region == "" && len(s.Polygon) == 0 {
+		return true
+	}
+	lat, lon, hasCoords := getActualCoords(ac)
+	if !hasCoords {
+		return false
+	}
+	if s.BBox != nil && !s.BBox.contains(lat, lon) {
+		return false
+	}
+	if s.Region != "" {
+		region := lookupRegion(s.Region)
+		if region == nil {
+			fmt.Printf("[SM] Unknown region '%s' for type %s\n", s.Region, s.Type)
+			return false
+		}
+		if !region.Contains(lat, lon) {
+			return false
+		}
+	}
+	if len(s.Polygon) > 0 && !s.Polygon.Contains(lat, lon) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"main.go/geo"
+)
+
+// --- Squawk range rules ---
+// Watches blocks of transponder codes rather than single values, e.g.
+// "4400-4477" for special operations or a local approach's assignment block.
+// Codes are octal, so ranges compare by their octal value and "4477" is
+// followed by "4500".
+type SquawkRule struct {
+	Name      string        `json:"name"`
+	Ranges    []SquawkRange `json:"ranges"`
+	RadiusNM  float64       `json:"radius_nm"` // 0 = anywhere in the feed
+	Classes   []string      `json:"classes"`
+	Channel   string        `json:"channel"`
+	Schedule  []Schedule    `json:"schedule"`
+	Tags      []string      `json:"tags"`
+	PhotoMode bool          `json:"photo_mode"` // see photomode.go
+}
+
+type SquawkRange struct {
+	Codes   string `json:"codes"`   // "7777", or an inclusive range "4400-4477"
+	Label   string `json:"label"`   // shown on the alert, e.g. "Special operations"
+	Title   string `json:"title"`   // alert title, default "Squawk Alert: <rule>"
+	Color   int    `json:"color"`   // embed color, default yellow
+	Channel string `json:"channel"` // default the rule's channel
+}
+
+func parseSquawk(s string) (uint64, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) != 4 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 8, 16)
+	return v, err == nil
+}
+
+func (r SquawkRange) contains(squawk string) bool {
+	code, ok := parseSquawk(squawk)
+	if !ok {
+		return false
+	}
+	lo, hi, isRange := strings.Cut(r.Codes, "-")
+	if !isRange {
+		hi = lo
+	}
+	from, okFrom := parseSquawk(lo)
+	to, okTo := parseSquawk(hi)
+	if !okFrom || !okTo {
+		return false
+	}
+	return code >= from && code <= to
+}
+
+// match returns the first range the squawk falls in.
+func (r SquawkRule) match(squawk string) (SquawkRange, bool) {
+	for _, sr := range r.Ranges {
+		if sr.contains(squawk) {
+			return sr, true
+		}
+	}
+	return SquawkRange{}, false
+}
+
+func squawkRangeLabel(sr SquawkRange) string {
+	if sr.Label != "" {
+		return sr.Label
+	}
+	return sr.Codes
+}
+
+// processSquawkAlerts alerts once per visit to a rule's ranges. Leaving the
+// ranges (or the radius) resets the rule for that aircraft.
+func processSquawkAlerts(site sourceCenter, ac Aircraft) {
+	if len(cfg().SquawkRules) == 0 {
+		return
+	}
+	lat, lon, hasCoords := getActualCoords(ac)
+	now := clock.Now()
+
+	for _, rule := range cfg().SquawkRules {
+		key := ruleStateKey(rule.Name, ac.Hex)
+		sr, inRange := rule.match(ac.Squawk)
+		if inRange && rule.RadiusNM > 0 {
+			inRange = hasCoords && geo.DistanceNM(site.Lat, site.Lon, lat, lon) <= rule.RadiusNM
+		}
+		if !inRange || !matchesClass(ac, rule.Classes) {
+			delete(globalRuleState, key)
+			continue
+		}
+
+		state, seen := globalRuleState[key]
+		if !seen {
+			state.FirstSeen = now
+		}
+		state.LastSeen = now
+		if !state.Alerted && ruleActive(rule.Name, rule.Schedule, now) {
+			fmt.Printf("[Radius] !!! SQUAWK RANGE: %s squawking %s (%s) rule '%s'\n", ac.Hex, ac.Squawk, squawkRangeLabel(sr), rule.Name)
+			details, _ := getAircraftDetails(ac.Hex)
+			channel := rule.Channel
+			if sr.Channel != "" {
+				channel = sr.Channel
+			}
+			sendDiscordAlert(resolveChannel(channel), ac, details, "squawk", &AlertContext{
+				Rule:      rule.Name,
+				Tags:      rule.Tags,
+				PhotoMode: rule.PhotoMode,
+				Note:      fmt.Sprintf("**Squawk %s:** %s", ac.Squawk, squawkRangeLabel(sr)),
+				Title:     sr.Title,
+				Color:     sr.Color,
+			})
+			state.Alerted = true
+		}
+		globalRuleState[key] = state
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// --- Shared state (Redis) ---
+// By default all alert state lives in process memory. With state.redis_url
+// set, the radius, nationwide, rule and aggregate state plus runtime mutes
+// are loaded from Redis before each poll and written back after it, so a
+// restart (or another instance) picks up exactly where the last one left
+// off without re-sending alerts.
+type StateConfig struct {
+	RedisURL string   `json:"redis_url"` // redis://[:password@]host:6379/0, empty keeps state in memory
+	Prefix   string   `json:"prefix"`    // key prefix, default "flight-ingestor:"
+	TTL      Duration `json:"ttl"`       // keys expire after this long without a write, default 24h
+}
+
+const redisTimeout = 5 * time.Second
+
+var redisClient *redis.Client
+
+func initSharedState() {
+	sc := cfg().State
+	if sc.RedisURL == "" {
+		return
+	}
+	opts, err := redis.ParseURL(sc.RedisURL)
+	if err != nil {
+		fmt.Printf("[ST] Invalid redis_url, keeping state in memory: %v\n", err)
+		return
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Printf("[ST] Error connecting to Redis, keeping state in memory: %v\n", err)
+		client.Close()
+		return
+	}
+	redisClient = client
+	fmt.Printf("[ST] Using Redis at %s for shared state.\n", opts.Addr)
+
+	loadMutes()
+	loadRadiusState()
+	loadNationwideState()
+}
+
+func stateKey(name string) string {
+	prefix := cfg().State.Prefix
+	if prefix == "" {
+		prefix = "flight-ingestor:"
+	}
+	return prefix + name
+}
+
+func stateTTL() time.Duration {
+	if ttl := cfg().State.TTL.Duration; ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
+// loadStateHash replaces the contents of dst with the JSON-encoded fields of a Redis hash.
+func loadStateHash[T any](name string, dst map[string]T) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	fields, err := redisClient.HGetAll(ctx, stateKey(name)).Result()
+	if err != nil {
+		fmt.Printf("[ST] Error loading %s: %v\n", name, err)
+		return
+	}
+	clear(dst)
+	for k, v := range fields {
+		var item T
+		if err := json.Unmarshal([]byte(v), &item); err == nil {
+			dst[k] = item
+		}
+	}
+}
+
+// saveStateHash replaces a Redis hash with the contents of src in one transaction.
+func saveStateHash[T any](name string, src map[string]T) {
+	values := make(map[string]any, len(src))
+	for k, v := range src {
+		if data, err := json.Marshal(v); err == nil {
+			values[k] = data
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	key := stateKey(name)
+	_, err := redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(values) > 0 {
+			pipe.HSet(ctx, key, values)
+			pipe.Expire(ctx, key, stateTTL())
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[ST] Error saving %s: %v\n", name, err)
+	}
+}
+
+// loadRadiusState runs before each radius poll (on the radius goroutine).
+func loadRadiusState() {
+	if redisClient == nil {
+		return
+	}
+	loadStateHash(siteStateName("radius"), globalRadiusState)
+	loadStateHash(siteStateName("rules"), globalRuleState)
+	loadStateHash(siteStateName("aggregate"), globalAggregateEpisodes)
+	loadMutes()
+}
+
+// saveRadiusState runs after each radius poll and stream batch.
+func saveRadiusState() {
+	if redisClient == nil {
+		return
+	}
+	saveStateHash(siteStateName("radius"), globalRadiusState)
+	saveStateHash(siteStateName("rules"), globalRuleState)
+	saveStateHash(siteStateName("aggregate"), globalAggregateEpisodes)
+	saveMutes()
+}
+
+func loadNationwideState() {
+	if redisClient == nil {
+		return
+	}
+	nationwideStateMutex.Lock()
+	defer nationwideStateMutex.Unlock()
+	loadStateHash("nationwide", globalNationwideState)
+}
+
+func saveNationwideState(hex string, state NationwideAlertState) {
+	if redisClient == nil {
+		return
+	}
+	data, _ := json.Marshal(state)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	key := stateKey("nationwide")
+	if err := redisClient.HSet(ctx, key, hex, data).Err(); err != nil {
+		fmt.Printf("[ST] Error saving nationwide state for %s: %v\n", hex, err)
+		return
+	}
+	redisClient.Expire(ctx, key, stateTTL())
+}
+
+func loadMutes() {
+	if redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := redisClient.Get(ctx, stateKey("mutes")).Bytes()
+	if err == redis.Nil {
+		saveMutes() // first run: seed Redis with the config mutes
+		return
+	}
+	if err != nil {
+		fmt.Printf("[ST] Error loading mutes: %v\n", err)
+		return
+	}
+	var mutes []Mute
+	if err := json.Unmarshal(data, &mutes); err != nil {
+		fmt.Printf("[ST] Error decoding mutes: %v\n", err)
+		return
+	}
+	muteMutex.Lock()
+	globalMutes = mutes
+	muteMutex.Unlock()
+}
+
+func saveMutes() {
+	if redisClient == nil {
+		return
+	}
+	data, _ := json.Marshal(listMutes())
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := redisClient.Set(ctx, stateKey("mutes"), data, 0).Err(); err != nil {
+		fmt.Printf("[ST] Error saving mutes: %v\n", err)
+	}
+}
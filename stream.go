@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- Streaming sources ---
+// Some receiver outputs push every message as it's heard instead of being
+// polled. A reader keeps a live table of aircraft from the stream; Fetch
+// hands the radius loop a snapshot of it on the usual interval, and each
+// update is also run through processRadiusAlerts straight away, so a
+// proximity or emergency alert goes out within a second rather than at the
+// next poll. The decoders (sbs.go, beast.go) only fill in the table.
+const (
+	streamAircraftTTL   = 60 * time.Second // drop aircraft not heard for this long
+	streamWarmup        = 5 * time.Second  // the first snapshot after connecting waits this long
+	streamRetryMin      = 2 * time.Second
+	streamRetryMax      = time.Minute
+	streamDialTimeout   = 10 * time.Second
+	streamAlertCoalesce = 250 * time.Millisecond
+)
+
+// liveAlerts is set once the radius loop has processed its first snapshot,
+// which loads any saved alert state. One-shot commands (once, rules test)
+// never run the loop, so they read the table but never alert from it.
+var liveAlerts atomic.Bool
+
+type streamAircraft struct {
+	ac      Aircraft
+	seen    time.Time
+	posTime time.Time
+}
+
+type liveTable struct {
+	mu       sync.Mutex
+	aircraft map[string]*streamAircraft
+	dirty    map[string]bool
+	notify   chan struct{}
+}
+
+func newLiveTable() *liveTable {
+	return &liveTable{
+		aircraft: make(map[string]*streamAircraft),
+		dirty:    make(map[string]bool),
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// update applies one decoded message to an aircraft. fn returns whether the
+// message carried a position.
+func (t *liveTable) update(hex string, fn func(ac *Aircraft) bool) {
+	now := clock.Now()
+	t.mu.Lock()
+	la, ok := t.aircraft[hex]
+	if !ok {
+		la = &streamAircraft{ac: Aircraft{Hex: hex}}
+		t.aircraft[hex] = la
+	}
+	la.seen = now
+	if fn(&la.ac) {
+		la.posTime = now
+	}
+	t.dirty[hex] = true
+	t.mu.Unlock()
+
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (t *liveTable) has(hex string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.aircraft[hex]
+	return ok
+}
+
+func (t *liveTable) withAge(la *streamAircraft, now time.Time) Aircraft {
+	ac := la.ac
+	if !la.posTime.IsZero() {
+		ac.SeenPos = someFloat(now.Sub(la.posTime).Seconds())
+	}
+	return ac
+}
+
+// snapshot lists everything heard recently, forgetting the rest.
+func (t *liveTable) snapshot() []Aircraft {
+	now := clock.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]Aircraft, 0, len(t.aircraft))
+	for hex, la := range t.aircraft {
+		if now.Sub(la.seen) > streamAircraftTTL {
+			delete(t.aircraft, hex)
+			continue
+		}
+		list = append(list, t.withAge(la, now))
+	}
+	return list
+}
+
+// takeDirty returns the aircraft updated since the last call.
+func (t *liveTable) takeDirty() []Aircraft {
+	now := clock.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]Aircraft, 0, len(t.dirty))
+	for hex := range t.dirty {
+		if la, ok := t.aircraft[hex]; ok {
+			list = append(list, t.withAge(la, now))
+		}
+	}
+	clear(t.dirty)
+	return list
+}
+
+type streamDecoder func(r io.Reader, t *liveTable) error
+
+type streamSource struct {
+	name      string
+	addr      string
+	center    sourceCenter
+	decode    streamDecoder
+	table     *liveTable
+	cancel    context.CancelFunc
+	connected atomic.Bool
+	since     atomic.Int64 // unix nanos of the current connection
+}
+
+var (
+	activeStreams = make(map[string]*streamSource) // by name@addr
+	streamMutex   = &sync.Mutex{}
+)
+
+// openStream returns the running stream for this receiver, restarting it if
+// the site it's filtered around has changed.
+func openStream(name, addr string, center sourceCenter, decode streamDecoder) *streamSource {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	key := name + "@" + addr
+	if s := activeStreams[key]; s != nil && s.center == center {
+		return s
+	}
+	if s := activeStreams[key]; s != nil {
+		s.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &streamSource{name: name, addr: addr, center: center, decode: decode, table: newLiveTable(), cancel: cancel}
+	activeStreams[key] = s
+	go s.run(ctx)
+	go s.alertLoop(ctx)
+	return s
+}
+
+// closeStreamsExcept stops the streams a reloaded config no longer lists.
+func closeStreamsExcept(keep []*streamSource) {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	for key, s := range activeStreams {
+		if !slices.Contains(keep, s) {
+			s.cancel()
+			delete(activeStreams, key)
+		}
+	}
+}
+
+func (s *streamSource) Name() string { return s.name }
+
+func (s *streamSource) Fetch(ctx context.Context) ([]Aircraft, error) {
+	for !s.connected.Load() {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s: not connected to %s", s.name, s.addr)
+		}
+	}
+	// Give a fresh connection time to hear the sky before the first snapshot
+	if wait := streamWarmup - since(time.Unix(0, s.since.Load())); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return filterAround(s.table.snapshot(), s.center.Lat, s.center.Lon, s.center.RadiusNM), nil
+}
+
+func (s *streamSource) run(ctx context.Context) {
+	backoff := streamRetryMin
+	for ctx.Err() == nil {
+		dialer := net.Dialer{Timeout: streamDialTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+		if err == nil {
+			fmt.Printf("[ST] Connected to %s feed at %s\n", s.name, s.addr)
+			backoff = streamRetryMin
+			s.since.Store(clock.Now().UnixNano())
+			s.connected.Store(true)
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			err = s.decode(conn, s.table)
+			stop()
+			conn.Close()
+			s.connected.Store(false)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("[ST] Error on %s feed %s: %v; retrying in %v\n", s.name, s.addr, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, streamRetryMax)
+	}
+}
+
+// alertLoop runs the per-aircraft radius rules on every update, batching
+// whatever arrives within streamAlertCoalesce.
+func (s *streamSource) alertLoop(ctx context.Context) {
+	for {
+		select {
+		case <-s.table.notify:
+		case <-ctx.Done():
+			return
+		}
+		time.Sleep(streamAlertCoalesce)
+		updated := s.table.takeDirty()
+		if !liveAlerts.Load() {
+			continue
+		}
+		updated = filterAround(updated, s.center.Lat, s.center.Lon, s.center.RadiusNM)
+		processStreamBatch(s.center, updated)
+	}
+}
+
+// processStreamBatch saves the radius state afterwards: the next poll
+// reloads it from Redis, which would otherwise undo what the batch did.
+func processStreamBatch(site sourceCenter, updated []Aircraft) {
+	if len(updated) == 0 {
+		return
+	}
+	radiusMutex.Lock()
+	defer radiusMutex.Unlock()
+	for _, ac := range updated {
+		if !isBlocked(ac) {
+			processRadiusAlerts(site, ac)
+		}
+	}
+	saveRadiusState()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// --- Sun position ---
+// Low-precision solar ephemeris (Astronomical Almanac), good to ~0.01°,
+// which is plenty for golden hour.
+
+const deg = math.Pi / 180
+
+// daysSinceJ2000 is the (fractional) day number relative to 2000-01-01 12:00 UT.
+func daysSinceJ2000(t time.Time) float64 {
+	j2000 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	return t.Sub(j2000).Hours() / 24
+}
+
+// sunRADec returns the sun's right ascension and declination in degrees.
+func sunRADec(t time.Time) (ra, dec float64) {
+	n := daysSinceJ2000(t)
+	L := 280.460 + 0.9856474*n
+	g := (357.528 + 0.9856003*n) * deg
+	lambda := (L + 1.915*math.Sin(g) + 0.020*math.Sin(2*g)) * deg
+	eps := (23.439 - 0.0000004*n) * deg
+	ra = math.Atan2(math.Cos(eps)*math.Sin(lambda), math.Cos(lambda)) / deg
+	dec = math.Asin(math.Sin(eps)*math.Sin(lambda)) / deg
+	return ra, dec
+}
+
+// horizontal converts equatorial coordinates to elevation and azimuth (from
+// true north, clockwise) for an observer, in degrees.
+func horizontal(ra, dec float64, t time.Time, lat, lon float64) (elevation, azimuth float64) {
+	gmst := 280.46061837 + 360.98564736629*daysSinceJ2000(t)
+	ha := (gmst + lon - ra) * deg
+	latR, decR := lat*deg, dec*deg
+	elevation = math.Asin(math.Sin(latR)*math.Sin(decR)+math.Cos(latR)*math.Cos(decR)*math.Cos(ha)) / deg
+	azimuth = math.Atan2(-math.Sin(ha), math.Tan(decR)*math.Cos(latR)-math.Sin(latR)*math.Cos(ha)) / deg
+	return elevation, math.Mod(azimuth+360, 360)
+}
+
+func sunPosition(t time.Time, lat, lon float64) (elevation, azimuth float64) {
+	ra, dec := sunRADec(t)
+	return horizontal(ra, dec, t, lat, lon)
+}
+
+// --- Moon position ---
+// Paul Schlyter's method with the main perturbation terms, good to a couple
+// of arcminutes. Returns geocentric RA/Dec in degrees and the distance in
+// Earth radii (needed for parallax, which is about a degree for the Moon).
+func moonRADec(t time.Time) (ra, dec, distER float64) {
+	d := daysSinceJ2000(t) + 1.5
+	N := 125.1228 - 0.0529538083*d
+	const i, a, e = 5.1454, 60.2666, 0.054900
+	w := 318.0634 + 0.1643573223*d
+	M := math.Mod(115.3654+13.0649929509*d, 360)
+
+	E := M + e/deg*math.Sin(M*deg)*(1+e*math.Cos(M*deg))
+	for range 5 {
+		E -= (E - e/deg*math.Sin(E*deg) - M) / (1 - e*math.Cos(E*deg))
+	}
+	xv, yv := a*(math.Cos(E*deg)-e), a*math.Sqrt(1-e*e)*math.Sin(E*deg)
+	v := math.Atan2(yv, xv) / deg
+	r := math.Hypot(xv, yv)
+
+	sinN, cosN := math.Sincos(N * deg)
+	sinVW, cosVW := math.Sincos((v + w) * deg)
+	xh := r * (cosN*cosVW - sinN*sinVW*math.Cos(i*deg))
+	yh := r * (sinN*cosVW + cosN*sinVW*math.Cos(i*deg))
+	zh := r * sinVW * math.Sin(i*deg)
+	lon := math.Atan2(yh, xh) / deg
+	lat := math.Atan2(zh, math.Hypot(xh, yh)) / deg
+
+	// Perturbations
+	Ms := 356.0470 + 0.9856002585*d
+	Ls := 282.9404 + 4.70935e-5*d + Ms
+	Lm := N + w + M
+	D := Lm - Ls
+	F := Lm - N
+	sin := func(x float64) float64 { return math.Sin(x * deg) }
+	cos := func(x float64) float64 { return math.Cos(x * deg) }
+	lon += -1.274*sin(M-2*D) + 0.658*sin(2*D) - 0.186*sin(Ms) -
+		0.059*sin(2*M-2*D) - 0.057*sin(M-2*D+Ms) + 0.053*sin(M+2*D) +
+		0.046*sin(2*D-Ms) + 0.041*sin(M-Ms) - 0.035*sin(D) -
+		0.031*sin(M+Ms) - 0.015*sin(2*F-2*D) + 0.011*sin(M-4*D)
+	lat += -0.173*sin(F-2*D) - 0.055*sin(M-F-2*D) - 0.046*sin(M+F-2*D) +
+		0.033*sin(F+2*D) + 0.017*sin(2*M+F)
+	r += -0.58*cos(M-2*D) - 0.46*cos(2*D)
+
+	// Ecliptic to equatorial
+	ecl := (23.4393 - 3.563e-7*d) * deg
+	xg := r * cos(lon) * cos(lat)
+	yg := r * sin(lon) * cos(lat)
+	zg := r * sin(lat)
+	ye := yg*math.Cos(ecl) - zg*math.Sin(ecl)
+	ze := yg*math.Sin(ecl) + zg*math.Cos(ecl)
+	return math.Atan2(ye, xg) / deg, math.Atan2(ze, math.Hypot(xg, ye)) / deg, r
+}
+
+// moonPosition is topocentric: corrected for parallax from the observer.
+func moonPosition(t time.Time, lat, lon float64) (elevation, azimuth float64) {
+	ra, dec, r := moonRADec(t)
+	elevation, azimuth = horizontal(ra, dec, t, lat, lon)
+	elevation -= math.Asin(1/r) / deg * math.Cos(elevation*deg)
+	return elevation, azimuth
+}
+
+// moonRadius is the Moon's apparent angular radius in degrees.
+func moonRadius(t time.Time) float64 {
+	_, _, r := moonRADec(t)
+	return math.Asin(0.2725/r) / deg
+}
+
+const sunRadius = 0.267 // degrees, varies ±1.7% over the year
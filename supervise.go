@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// --- Goroutine supervision ---
+// A panic in one of the background loops (a payload nobody expected) used
+// to leave that loop dead while the rest of the process carried on. Each
+// loop now runs under supervise, which recovers the panic, logs the stack,
+// tells the ops channel and restarts the loop with exponential backoff. A
+// loop that returns normally (e.g. a disabled integration) stays stopped.
+const (
+	superviseMinBackoff = time.Second
+	superviseMaxBackoff = 5 * time.Minute
+	superviseHealthyRun = 10 * time.Minute // running this long resets the backoff
+)
+
+func supervise(name string, loop func()) {
+	go func() {
+		backoff := superviseMinBackoff
+		for {
+			started := time.Now()
+			panicked, value, stack := runRecovered(loop)
+			if !panicked {
+				return
+			}
+			if time.Since(started) > superviseHealthyRun {
+				backoff = superviseMinBackoff
+			}
+			fmt.Printf("[SUP] %s panicked: %v\n%s\n", name, value, stack)
+			fmt.Printf("[SUP] Restarting %s in %s\n", name, backoff)
+			go notifyOps("💥 Loop Crashed: "+name,
+				fmt.Sprintf("`%v`\nRestarting in %s.\n```\n%s\n```", value, backoff, truncateRunes(firstStackFrames(stack), 1500)),
+				15548997) // Red
+			time.Sleep(backoff)
+			backoff = min(backoff*2, superviseMaxBackoff)
+		}
+	}()
+}
+
+func runRecovered(loop func()) (panicked bool, value any, stack string) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked, value, stack = true, r, string(debug.Stack())
+		}
+	}()
+	loop()
+	return false, nil, ""
+}
+
+// firstStackFrames trims a stack to the lines nearest the panic.
+func firstStackFrames(stack string) string {
+	lines := strings.Split(stack, "\n")
+	return strings.Join(lines[:min(len(lines), 24)], "\n")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+)
+
+// --- Alert tags ---
+// Rules can attach free-form tags ("photo-op", "medical", "noise-complaint").
+// Built-in triggers get theirs from trigger_tags. Tags are shown in the embed
+// footer and carried along with every alert for downstream filtering.
+
+// alertTags merges the rule's tags with the configured tags for the alert type.
+func alertTags(alertType string, ruleTags []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, ruleTags...), cfg().TriggerTags[alertType]...) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func footerText(tags []string) string {
+	text := "ADSB.lol Alerter"
+	if len(tags) > 0 {
+		text += " · #" + strings.Join(tags, " #")
+	}
+	return text
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Telegram bot ---
+// Long-polls the Bot API for messages and answers lookup commands from the
+// enrichment chain and the live radius state:
+//
+//	/lookup N123AB      registration, ICAO hex or callsign
+//	/nearby [nm]        aircraft currently within nm of the observer
+//	/search <text>      alert and flight history
+type TelegramConfig struct {
+	BotToken     string  `json:"bot_token"`     // empty disables the bot
+	AllowedChats []int64 `json:"allowed_chats"` // empty answers anyone
+}
+
+const (
+	telegramAPIURL      = "https://api.telegram.org/bot"
+	telegramPollTimeout = 50 // seconds, Telegram's long-poll wait
+	defaultNearbyNM     = 10
+)
+
+var telegramHTTP = newHTTPClient((telegramPollTimeout + 10) * time.Second)
+
+// --- Latest radius snapshot, for lookups ---
+var (
+	liveAircraft []Aircraft
+	liveMutex    = &sync.RWMutex{}
+)
+
+func setLiveAircraft(aircraft []Aircraft) {
+	liveMutex.Lock()
+	liveAircraft = aircraft
+	liveMutex.Unlock()
+}
+
+func findLiveAircraft(query string) (Aircraft, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	liveMutex.RLock()
+	defer liveMutex.RUnlock()
+	for _, ac := range liveAircraft {
+		if ac.Hex == query || strings.ToLower(ac.NNumber) == query || strings.ToLower(strings.TrimSpace(ac.Flight)) == query {
+			return ac, true
+		}
+	}
+	return Aircraft{}, false
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+func startTelegram() {
+	if cfg().Telegram.BotToken == "" {
+		return
+	}
+	fmt.Println("[TG] Telegram bot listening for commands.")
+	var offset int64
+	for {
+		token := cfg().Telegram.BotToken
+		var resp struct {
+			OK     bool             `json:"ok"`
+			Result []telegramUpdate `json:"result"`
+		}
+		err := telegramCall(token, "getUpdates", map[string]any{"offset": offset, "timeout": telegramPollTimeout, "allowed_updates": []string{"message"}}, &resp)
+		if err != nil {
+			fmt.Printf("[TG] Error polling updates: %v\n", err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		for _, u := range resp.Result {
+			offset = u.UpdateID + 1
+			if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+				continue
+			}
+			chat := u.Message.Chat.ID
+			if allowed := cfg().Telegram.AllowedChats; len(allowed) > 0 && !slices.Contains(allowed, chat) {
+				continue
+			}
+			reply := runTelegramCommand(u.Message.Text)
+			err := telegramCall(token, "sendMessage", map[string]any{
+				"chat_id":                  chat,
+				"text":                     reply,
+				"parse_mode":               "HTML",
+				"disable_web_page_preview": true,
+			}, nil)
+			if err != nil {
+				fmt.Printf("[TG] Error replying to %d: %v\n", chat, err)
+			}
+		}
+	}
+}
+
+func telegramCall(token, method string, params any, out any) error {
+	payload, _ := json.Marshal(params)
+	resp, err := telegramHTTP.Post(telegramAPIURL+token+"/"+method, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Telegram %s returned non-200 status: %s", method, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func runTelegramCommand(text string) string {
+	fields := strings.Fields(text)
+	// "/lookup@MyBot N123" in groups
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	arg := strings.Join(fields[1:], " ")
+
+	switch command {
+	case "/lookup":
+		if arg == "" {
+			return "Usage: /lookup &lt;registration, hex or callsign&gt;"
+		}
+		return telegramLookup(arg)
+	case "/nearby":
+		radius := float64(defaultNearbyNM)
+		if arg != "" {
+			r, err := strconv.ParseFloat(arg, 64)
+			if err != nil || r <= 0 {
+				return "Usage: /nearby [radius in nm]"
+			}
+			radius = r
+		}
+		return telegramNearby(radius)
+	case "/search":
+		results, err := searchHistory(arg, 10)
+		if err != nil {
+			return html.EscapeString(fmt.Sprintf("Search failed: %v", err))
+		}
+		return telegramSearchResults(arg, results)
+	case "/start", "/help":
+		return "/lookup N123AB — registration, hex or callsign\n/nearby [nm] — aircraft near the station\n/search text — alert and flight history"
+	}
+	return html.EscapeString(fmt.Sprintf("Unknown command %s, try /help", command))
+}
+
+func telegramLookup(query string) string {
+	ac, live := findLiveAircraft(query)
+	key := query
+	if live {
+		key = ac.Hex
+	}
+	// adsbdb accepts registrations as well as hex codes
+	details, err := getAircraftDetails(strings.ToLower(key))
+	if err != nil && !live {
+		return html.EscapeString(fmt.Sprintf("Nothing found for %s: %v", query, err))
+	}
+	if details.Registration == "" && !live {
+		return html.EscapeString(fmt.Sprintf("Nothing found for %s.", query))
+	}
+
+	var b strings.Builder
+	label := details.Registration
+	if label == "" {
+		label = ac.NNumber
+	}
+	fmt.Fprintf(&b, "<b>%s</b>", html.EscapeString(strings.TrimSpace(label+" "+details.AircraftType)))
+	if details.Owner != "" {
+		fmt.Fprintf(&b, "\nOwner: %s", html.EscapeString(details.Owner))
+	}
+	if details.CountryName != "" {
+		fmt.Fprintf(&b, "\nCountry: %s", html.EscapeString(details.CountryName))
+	}
+	hex := details.Hex
+	if live {
+		hex = ac.Hex
+	}
+	if watch, ok := lookupWatchlist(hex); ok {
+		fmt.Fprintf(&b, "\nWatchlist: %s", html.EscapeString(strings.TrimSpace(watch.Category+" "+watch.Note)))
+	}
+	if live {
+		fmt.Fprintf(&b, "\n\n<b>Live</b> %s", html.EscapeString(strings.TrimSpace(ac.Flight)))
+		if lat, lon, ok := getActualCoords(ac); ok {
+			fmt.Fprintf(&b, "\n%s %s, %s, %s",
+				fmtDistance(geo.DistanceNM(apiLat, apiLng, lat, lon)), compassPoint(geo.InitialBearing(apiLat, apiLng, lat, lon)),
+				fmtAltBaro(ac.AltBaro), fmtOptSpeed(ac.GS))
+		}
+		if ac.Squawk != "" {
+			fmt.Fprintf(&b, "\nSquawk %s", ac.Squawk)
+		}
+		if route, _ := lookupRoute(ac.Flight); route != nil {
+			fmt.Fprintf(&b, "\nRoute: %s", html.EscapeString(route.String()))
+		}
+	}
+	if hex != "" {
+		fmt.Fprintf(&b, "\n<a href=\"https://globe.adsb.lol/?icao=%s\">Track on globe.adsb.lol</a>", html.EscapeString(hex))
+	}
+	return b.String()
+}
+
+func lookupWatchlist(hex string) (WatchlistEntry, bool) {
+	watchlistMutex.RLock()
+	defer watchlistMutex.RUnlock()
+	entry, ok := globalWatchlist[strings.ToLower(hex)]
+	return entry, ok
+}
+
+func telegramNearby(radius float64) string {
+	type nearby struct {
+		ac   Aircraft
+		dist float64
+	}
+	var list []nearby
+	liveMutex.RLock()
+	for _, ac := range liveAircraft {
+		if lat, lon, ok := getActualCoords(ac); ok {
+			if d := geo.DistanceNM(apiLat, apiLng, lat, lon); d <= radius {
+				list = append(list, nearby{ac, d})
+			}
+		}
+	}
+	liveMutex.RUnlock()
+	if len(list) == 0 {
+		return fmt.Sprintf("No aircraft within %s.", fmtRadius(radius))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].dist < list[j].dist })
+
+	lines := []string{fmt.Sprintf("<b>%d aircraft within %s</b>", len(list), fmtRadius(radius))}
+	for i, n := range list {
+		if i == 20 {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(list)-20))
+			break
+		}
+		label := strings.TrimSpace(n.ac.Flight)
+		if label == "" {
+			label = n.ac.NNumber
+		}
+		if label == "" {
+			label = n.ac.Hex
+		}
+		line := fmt.Sprintf("<code>%s</code> %s %s, %s", html.EscapeString(label), html.EscapeString(n.ac.Type), fmtDistance(n.dist), fmtAltBaro(n.ac.AltBaro))
+		if isMilitary(n.ac) {
+			line += " 🎖"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func telegramSearchResults(query string, results []SearchResult) string {
+	if len(results) == 0 {
+		return html.EscapeString(fmt.Sprintf("No matches for %s.", query))
+	}
+	lines := []string{fmt.Sprintf("<b>%d matches for %s</b>", len(results), html.EscapeString(query))}
+	for _, r := range results {
+		label := r.Registration
+		if label == "" {
+			label = r.Hex
+		}
+		line := fmt.Sprintf("[%s] <code>%s</code> %s %s", r.Source, html.EscapeString(label), html.EscapeString(r.Flight), html.EscapeString(r.Owner))
+		if r.Time != nil {
+			line += " · " + r.Time.Format("2006-01-02")
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func compassPoint(bearing float64) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return points[int((bearing+22.5)/45)%8]
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// --- CLI: test-alert ---
+// Sends one alert through the normal pipeline, so a new webhook, a channel
+// mapping or the embed formatting can be checked without waiting for
+// traffic. The alert is recorded like any other and says it's a test.
+//
+//	flight-ingestor test-alert
+//	flight-ingestor test-alert --type special_military --channel ops
+//	flight-ingestor test-alert --hex a1b2c3
+//
+// Without --hex a made-up aircraft over the observer is used.
+// defaultChannelFor is where an alert type goes when nothing more specific
+// applies.
+func defaultChannelFor(alertType string) string {
+	switch alertType {
+	case "emergency":
+		return emergencyWebhook("7700")
+	case "watchlist", "military":
+		return discordHookWatchlist
+	case "proximity", "overhead":
+		return discordHookProximity
+	case "special_military":
+		return resolveChannel("special_military")
+	}
+	return resolveChannel(alertType)
+}
+
+func runTestAlert(args []string) int {
+	fs := flag.NewFlagSet("test-alert", flag.ContinueOnError)
+	alertType := fs.String("type", "proximity", "alert type to format the message as")
+	channel := fs.String("channel", "", "channel name or webhook URL, default the alert type's channel")
+	hex := fs.String("hex", "", "use this aircraft, live from adsb.lol, instead of a sample")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: test-alert [--type TYPE] [--channel NAME] [--hex HEX]")
+		return 2
+	}
+	if !slices.Contains(alertTypes, *alertType) {
+		fmt.Fprintf(os.Stderr, "unknown alert type %q (one of %v)\n", *alertType, alertTypes)
+		return 2
+	}
+
+	webhook := defaultChannelFor(*alertType)
+	if *channel != "" {
+		webhook = resolveChannel(*channel)
+		if webhook == "" {
+			fmt.Fprintf(os.Stderr, "error: no channel named %q in %s\n", *channel, configFile)
+			return 1
+		}
+	}
+	if webhook == "" {
+		fmt.Fprintf(os.Stderr, "error: %s alerts have no default channel; pass --channel\n", *alertType)
+		return 1
+	}
+
+	ac := Aircraft{Hex: "000000", Flight: "TEST123", NNumber: "N0TEST", Type: "C172", Squawk: "7700", AltBaro: 1500.0,
+		GS: someFloat(110), Lat: someFloat(apiLat), Lon: someFloat(apiLng)}
+	details := AircraftDetail{Hex: ac.Hex, Registration: ac.NNumber, AircraftType: ac.Type, Owner: "flight-ingestor test alert"}
+	if *hex != "" {
+		aircraft, err := fetchLiveAircraft(*hex)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		if len(aircraft) == 0 {
+			fmt.Fprintf(os.Stderr, "error: %s isn't being tracked by adsb.lol right now\n", *hex)
+			return 1
+		}
+		ac = aircraft[0]
+		details, _ = getAircraftDetails(ac.Hex)
+	}
+
+	note := "This is a test alert from flight-ingestor."
+	actx := &AlertContext{
+		Rule:  "test",
+		Note:  note,
+		Entry: &WatchlistEntry{ICAO: ac.Hex, Note: note},
+	}
+	startAlertCapture()
+	sendDiscordAlert(webhook, ac, details, *alertType, actx)
+	records := stopAlertCapture()
+	if len(records) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no alert was produced")
+		return 1
+	}
+	rec := records[0]
+	fmt.Printf("%s alert for %s: %s\n", *alertType, ac.Hex, rec.Outcome)
+	if rec.Outcome != "sent" {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests for the time-driven alert state. Each step moves the fake clock to
+// an offset from the start and checks how many webhook posts there have
+// been so far.
+
+var testStart = time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC) // a Wednesday
+
+func at(offset time.Duration) {
+	clock = fakeClock{testStart.Add(offset)}
+}
+
+// withAlertState is withTestEnv with the per-aircraft alert state emptied
+// before and after, and emergency incidents kept in a temp dir.
+func withAlertState(t *testing.T) *stubTransport {
+	t.Helper()
+	stub := withTestEnv(t)
+	cfg().Emergency.DedupPath = filepath.Join(t.TempDir(), "emergency-dedup.json")
+	reset := func() {
+		clear(globalRadiusState)
+		clear(globalRuleState)
+		clear(proximityAlertedAt)
+		clear(quietQueue)
+		emergencyIncidents = nil
+		initMutes(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+	return stub
+}
+
+// Positions around the test site
+var testSite = sourceCenter{Lat: 35.79, Lon: -78.64, RadiusNM: 40}
+
+func aircraftAt(dLat float64) Aircraft {
+	ac := testAircraft()
+	ac.Lat, ac.Lon = someFloat(testSite.Lat+dLat), someFloat(testSite.Lon)
+	ac.AltBaro = 2000.0
+	return ac
+}
+
+const (
+	overheadNM = 0.0
+	nearbyNM   = 0.05 // ~3 nm
+	outsideNM  = 0.5  // ~30 nm
+)
+
+type timedStep struct {
+	at    time.Duration
+	ac    Aircraft
+	posts int // total so far
+}
+
+func TestDwellTimer(t *testing.T) {
+	in, out := aircraftAt(nearbyNM), aircraftAt(outsideNM)
+	stale := aircraftAt(outsideNM)
+	stale.SeenPos = someFloat(120)
+
+	cases := []struct {
+		name  string
+		steps []timedStep
+	}{
+		{"alerts once after min duration", []timedStep{
+			{0, in, 0}, {5 * time.Minute, in, 0}, {10 * time.Minute, in, 1}, {15 * time.Minute, in, 1},
+		}},
+		{"drop-out longer than the gap tolerance restarts", []timedStep{
+			{0, in, 0}, {6 * time.Minute, in, 0}, {10 * time.Minute, in, 0}, {14 * time.Minute, in, 0}, {16 * time.Minute, in, 1},
+		}},
+		{"leaving the zone restarts", []timedStep{
+			{0, in, 0}, {4 * time.Minute, out, 0}, {8 * time.Minute, in, 0}, {13 * time.Minute, in, 0}, {18 * time.Minute, in, 1},
+		}},
+		{"stale position holds the timer", []timedStep{
+			{0, in, 0}, {3 * time.Minute, stale, 0}, {5 * time.Minute, in, 0}, {10 * time.Minute, in, 1},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withAlertState(t)
+			cfg().DwellRules = []DwellRule{{Name: "orbit", RadiusNM: 5, MinDuration: Duration{10 * time.Minute}, Channel: testWebhook}}
+			for i, step := range tc.steps {
+				at(step.at)
+				processDwellAlerts(testSite, step.ac)
+				if got := len(stub.posts); got != step.posts {
+					t.Fatalf("step %d (+%v): got %d posts, want %d", i, step.at, got, step.posts)
+				}
+			}
+		})
+	}
+}
+
+func TestEmergencyEscalation(t *testing.T) {
+	squawking := aircraftAt(outsideNM)
+	squawking.Squawk = "7700"
+	normal := aircraftAt(outsideNM)
+
+	cases := []struct {
+		name        string
+		repeatEvery time.Duration
+		steps       []timedStep
+	}{
+		{"escalates once", 0, []timedStep{
+			{0, squawking, 1}, {5 * time.Minute, squawking, 1}, {10 * time.Minute, squawking, 2},
+			{20 * time.Minute, squawking, 2}, {40 * time.Minute, squawking, 2},
+		}},
+		{"repeats", 15 * time.Minute, []timedStep{
+			{0, squawking, 1}, {10 * time.Minute, squawking, 2}, {20 * time.Minute, squawking, 2},
+			{25 * time.Minute, squawking, 3}, {40 * time.Minute, squawking, 4},
+		}},
+		{"squawking again after the end pages", 0, []timedStep{
+			{0, squawking, 1}, {2 * time.Minute, normal, 1}, {3 * time.Minute, squawking, 2},
+			{8 * time.Minute, squawking, 2}, {13 * time.Minute, squawking, 3},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withAlertState(t)
+			ec := &cfg().Emergency
+			ec.EscalateAfter = Duration{10 * time.Minute}
+			ec.RepeatEvery = Duration{tc.repeatEvery}
+			ec.Squawks = []EmergencySquawk{{Code: "7700", Channel: testWebhook}}
+			for i, step := range tc.steps {
+				at(step.at)
+				processRadiusAlerts(testSite, step.ac)
+				if got := len(stub.posts); got != step.posts {
+					t.Fatalf("step %d (+%v): got %d posts, want %d", i, step.at, got, step.posts)
+				}
+			}
+		})
+	}
+
+	// A restart mid-incident: the radius state is gone but the dedup file
+	// still has the incident, so the aircraft isn't paged again.
+	t.Run("restart keeps the incident", func(t *testing.T) {
+		stub := withAlertState(t)
+		cfg().Emergency.Squawks = []EmergencySquawk{{Code: "7700", Channel: testWebhook}}
+		at(0)
+		processRadiusAlerts(testSite, squawking)
+		clear(globalRadiusState)
+		emergencyIncidents = nil
+		at(time.Minute)
+		processRadiusAlerts(testSite, squawking)
+		if got := len(stub.posts); got != 1 {
+			t.Fatalf("got %d posts, want 1", got)
+		}
+	})
+}
+
+func TestProximityTiers(t *testing.T) {
+	nearby, overhead, outside := aircraftAt(nearbyNM), aircraftAt(overheadNM), aircraftAt(outsideNM)
+
+	cases := []struct {
+		name  string
+		steps []timedStep
+	}{
+		{"tighter tier alerts again", []timedStep{
+			{0, outside, 0}, {time.Minute, nearby, 1}, {2 * time.Minute, nearby, 1},
+			{3 * time.Minute, overhead, 2}, {4 * time.Minute, nearby, 2}, {5 * time.Minute, overhead, 2},
+		}},
+		{"cooldown spans visits", []timedStep{
+			{0, nearby, 1}, {time.Minute, outside, 1}, {10 * time.Minute, nearby, 1},
+			{20 * time.Minute, outside, 1}, {31 * time.Minute, nearby, 2},
+		}},
+		{"no cooldown alerts every visit", []timedStep{
+			{0, overhead, 1}, {time.Minute, outside, 1}, {2 * time.Minute, overhead, 2},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withAlertState(t)
+			cfg().Proximity.Tiers = []ProximityTier{
+				{Name: "nearby", RadiusNM: 5, MaxAltFT: 5000, Cooldown: Duration{30 * time.Minute}, Channel: testWebhook},
+				{Name: "overhead", RadiusNM: 1, MaxAltFT: 2500, Channel: testWebhook},
+			}
+			for i, step := range tc.steps {
+				at(step.at)
+				processRadiusAlerts(testSite, step.ac)
+				if got := len(stub.posts); got != step.posts {
+					t.Fatalf("step %d (+%v): got %d posts, want %d", i, step.at, got, step.posts)
+				}
+			}
+		})
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	night := QuietHours{Windows: []Schedule{{Start: "22:00", End: "06:00"}}, Except: []string{"emergency"}}
+	evening := 8 * time.Hour  // 22:30
+	morning := 16 * time.Hour // 06:30
+
+	cases := []struct {
+		name      string
+		mode      string
+		alertType string
+		at        time.Duration
+		posts     int
+		queued    int
+	}{
+		{"outside the window", "queue", "military", 0, 1, 0},
+		{"suppressed", "", "military", evening, 0, 0},
+		{"queued", "queue", "military", evening, 0, 1},
+		{"excepted type", "queue", "emergency", evening, 1, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := withAlertState(t)
+			q := night
+			q.Mode = tc.mode
+			c := cfg()
+			c.Channels["alerts"] = testWebhook
+			c.QuietHours = map[string]QuietHours{"alerts": q}
+			at(tc.at)
+			sendDiscordAlert(testWebhook, testAircraft(), AircraftDetail{}, tc.alertType, nil)
+			if got := len(stub.posts); got != tc.posts {
+				t.Errorf("got %d posts, want %d", got, tc.posts)
+			}
+			if got := len(quietQueue[testWebhook]); got != tc.queued {
+				t.Errorf("got %d queued, want %d", got, tc.queued)
+			}
+		})
+	}
+
+	t.Run("flush waits for the window and requeues on failure", func(t *testing.T) {
+		stub := withAlertState(t)
+		q := night
+		q.Mode = "queue"
+		c := cfg()
+		c.Channels["alerts"] = testWebhook
+		c.QuietHours = map[string]QuietHours{"alerts": q}
+
+		at(evening)
+		sendDiscordAlert(testWebhook, testAircraft(), AircraftDetail{}, "military", nil)
+		at(evening + time.Hour)
+		sendDiscordAlert(testWebhook, testAircraft(), AircraftDetail{}, "military", nil)
+		flushQuietQueues(clock.Now())
+		if len(stub.posts) != 0 || len(quietQueue[testWebhook]) != 2 {
+			t.Fatalf("inside the window: %d posts, %d queued; want 0, 2", len(stub.posts), len(quietQueue[testWebhook]))
+		}
+
+		at(morning)
+		stub.fail = true
+		flushQuietQueues(clock.Now())
+		if got := len(quietQueue[testWebhook]); got != 2 {
+			t.Fatalf("after a failed post: %d queued, want 2", got)
+		}
+
+		stub.fail = false
+		at(morning + time.Minute)
+		flushQuietQueues(clock.Now())
+		if len(stub.posts) != 1 || len(quietQueue[testWebhook]) != 0 {
+			t.Fatalf("after the window: %d posts, %d queued; want 1, 0", len(stub.posts), len(quietQueue[testWebhook]))
+		}
+	})
+}
+
+func TestScheduleMidnightWrap(t *testing.T) {
+	withTestEnv(t)
+	friNight := Schedule{Days: []string{"fri"}, Start: "22:00", End: "06:00"}
+	weekdays := Schedule{Days: []string{"weekdays"}, Start: "09:00", End: "17:00"}
+	day := func(d, hh, mm int) time.Time { return time.Date(2024, 5, d, hh, mm, 0, 0, time.UTC) } // May 3 is a Friday
+
+	cases := []struct {
+		name string
+		s    Schedule
+		t    time.Time
+		want bool
+	}{
+		{"friday evening", friNight, day(3, 23, 0), true},
+		{"saturday early morning", friNight, day(4, 3, 0), true},
+		{"friday early morning belongs to thursday", friNight, day(3, 3, 0), false},
+		{"saturday evening", friNight, day(4, 23, 0), false},
+		{"end is exclusive", friNight, day(4, 6, 0), false},
+		{"start is inclusive", friNight, day(3, 22, 0), true},
+		{"before start", friNight, day(3, 21, 59), false},
+		{"weekday hours", weekdays, day(3, 12, 0), true},
+		{"weekend", weekdays, day(4, 12, 0), false},
+		{"after hours", weekdays, day(3, 17, 0), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scheduleActive([]Schedule{tc.s}, tc.t); got != tc.want {
+				t.Errorf("scheduleActive(%s) = %v, want %v", tc.t.Format("Mon 15:04"), got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMuteExpiry(t *testing.T) {
+	cases := []struct {
+		name  string
+		at    time.Duration
+		muted bool
+		kept  int // mutes left afterwards
+	}{
+		{"before expiry", 5 * time.Minute, true, 1},
+		{"at expiry", 10 * time.Minute, true, 1},
+		{"after expiry", 11 * time.Minute, false, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			withAlertState(t)
+			until := testStart.Add(10 * time.Minute)
+			if err := addMute(Mute{Match: "hex", Value: "a1b2c3", Until: &until}); err != nil {
+				t.Fatal(err)
+			}
+			at(tc.at)
+			if _, muted := isMuted(testAircraft()); muted != tc.muted {
+				t.Errorf("muted = %v, want %v", muted, tc.muted)
+			}
+			muteMutex.RLock()
+			kept := len(globalMutes)
+			muteMutex.RUnlock()
+			if kept != tc.kept {
+				t.Errorf("%d mutes kept, want %d", kept, tc.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- GPX/KML track export ---
+// Positions are accumulated per aircraft per "flight session". A session ends
+// when the aircraft hasn't been seen for SessionGap; the finished track is then
+// written to Dir and/or uploaded to a Discord channel. The current session of
+// any aircraft can also be fetched from the HTTP API (GET /tracks/{hex}).
+type TrackConfig struct {
+	Enabled     bool             `json:"enabled"`
+	Dir         string           `json:"dir"`          // where finished tracks are written, empty to skip
+	Formats     []string         `json:"formats"`      // "gpx", "kml"; default both
+	Channel     string           `json:"channel"`      // optional: upload finished tracks here
+	SessionGap  Duration         `json:"session_gap"`  // default 10m
+	AlertedOnly bool             `json:"alerted_only"` // only export sessions that produced an alert
+	MinPoints   int              `json:"min_points"`   // default 2
+	Recap       TrackRecapConfig `json:"recap"`        // see recap.go
+}
+
+type TrackPoint struct {
+	Time   time.Time
+	Lat    float64
+	Lon    float64
+	AltFT  float64
+	HasAlt bool
+}
+
+type TrackSession struct {
+	Hex     string
+	Flight  string
+	Reg     string
+	Type    string
+	Start   time.Time
+	Last    time.Time
+	Alerted bool
+	Points  []TrackPoint
+
+	AlertWebhook string // channel of the session's last alert, for the recap
+	Recapped     bool
+}
+
+var (
+	globalTracks = make(map[string]*TrackSession)
+	tracksMutex  = &sync.Mutex{}
+)
+
+func trackSessionGap() time.Duration {
+	if gap := cfg().Tracks.SessionGap.Duration; gap > 0 {
+		return gap
+	}
+	return 10 * time.Minute
+}
+
+func recordTrackPoint(ac Aircraft) {
+	if !cfg().Tracks.Enabled {
+		return
+	}
+	lat, lon, ok := getActualCoords(ac)
+	if !ok {
+		return
+	}
+	now := clock.Now()
+	altFT, hasAlt := altitudeFeet(ac)
+
+	tracksMutex.Lock()
+	defer tracksMutex.Unlock()
+
+	session := globalTracks[ac.Hex]
+	if session != nil && now.Sub(session.Last) > trackSessionGap() {
+		go finishTrack(session)
+		session = nil
+	}
+	if session == nil {
+		session = &TrackSession{Hex: ac.Hex, Start: now}
+		globalTracks[ac.Hex] = session
+	}
+	if flight := strings.TrimSpace(ac.Flight); flight != "" {
+		session.Flight = flight
+	}
+	if ac.NNumber != "" {
+		session.Reg = ac.NNumber
+	}
+	if ac.Type != "" {
+		session.Type = ac.Type
+	}
+	landed := !hasAlt && strings.EqualFold(formatAltitudeString(ac.AltBaro), "ground") &&
+		len(session.Points) > 0 && session.Points[len(session.Points)-1].HasAlt
+	session.Last = now
+	session.Points = append(session.Points, TrackPoint{Time: now, Lat: lat, Lon: lon, AltFT: altFT, HasAlt: hasAlt})
+	if landed {
+		// Landing ends the flight as far as the recap goes
+		maybeRecapTrack(session)
+	}
+}
+
+func markTrackAlerted(rec AlertRecord) {
+	if rec.Outcome != "sent" {
+		return
+	}
+	tracksMutex.Lock()
+	if session := globalTracks[rec.Hex]; session != nil {
+		session.Alerted = true
+		session.AlertWebhook = rec.Webhook
+	}
+	tracksMutex.Unlock()
+}
+
+// closeStaleTracks finishes every session whose aircraft has left.
+func closeStaleTracks() {
+	cutoff := clock.Now().Add(-trackSessionGap())
+	tracksMutex.Lock()
+	var finished []*TrackSession
+	for hex, session := range globalTracks {
+		if session.Last.Before(cutoff) {
+			finished = append(finished, session)
+			delete(globalTracks, hex)
+		}
+	}
+	tracksMutex.Unlock()
+
+	for _, session := range finished {
+		go finishTrack(session)
+	}
+}
+
+// currentTrack returns a copy of the active session for a hex.
+func currentTrack(hex string) (TrackSession, bool) {
+	tracksMutex.Lock()
+	defer tracksMutex.Unlock()
+	session, ok := globalTracks[strings.ToLower(hex)]
+	if !ok {
+		return TrackSession{}, false
+	}
+	copied := *session
+	copied.Points = append([]TrackPoint(nil), session.Points...)
+	return copied, true
+}
+
+func finishTrack(session *TrackSession) {
+	tracksMutex.Lock()
+	maybeRecapTrack(session)
+	tracksMutex.Unlock()
+
+	tc := cfg().Tracks
+	minPoints := tc.MinPoints
+	if minPoints <= 0 {
+		minPoints = 2
+	}
+	if len(session.Points) < minPoints || (tc.AlertedOnly && !session.Alerted) {
+		return
+	}
+
+	formats := tc.Formats
+	if len(formats) == 0 {
+		formats = []string{"gpx", "kml"}
+	}
+	webhook := resolveChannel(tc.Channel)
+	if webhook != "" && quietHold(webhook, "track", heldAlert{Title: "Track", Hex: session.Hex, Label: session.label()}) != "" {
+		webhook = "" // files are still written to tracks.dir
+	}
+
+	for _, format := range formats {
+		data, err := encodeTrack(*session, format)
+		if err != nil {
+			fmt.Printf("[TRK] %v\n", err)
+			continue
+		}
+		name := session.fileName(format)
+
+		if tc.Dir != "" {
+			if err := os.MkdirAll(tc.Dir, 0o755); err != nil {
+				fmt.Printf("[TRK] Error creating %s: %v\n", tc.Dir, err)
+			} else if err := os.WriteFile(filepath.Join(tc.Dir, name), data, 0o644); err != nil {
+				fmt.Printf("[TRK] Error writing %s: %v\n", name, err)
+			}
+		}
+		if webhook != "" {
+			content := fmt.Sprintf("Track for %s (%d points, %v)", session.label(), len(session.Points), session.Last.Sub(session.Start).Round(time.Minute))
+			if err := postDiscordFile(webhook, content, name, data); err != nil {
+				fmt.Printf("[TRK] Error uploading %s: %v\n", name, err)
+			}
+		}
+	}
+	fmt.Printf("[TRK] Finished track for %s (%d points).\n", session.label(), len(session.Points))
+}
+
+func (s TrackSession) label() string {
+	for _, v := range []string{s.Flight, s.Reg} {
+		if v != "" {
+			return fmt.Sprintf("%s (%s)", v, s.Hex)
+		}
+	}
+	return s.Hex
+}
+
+func (s TrackSession) fileName(format string) string {
+	return fmt.Sprintf("%s_%s.%s", s.Start.UTC().Format("20060102T150405Z"), s.Hex, format)
+}
+
+func encodeTrack(s TrackSession, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "gpx":
+		return encodeGPX(s), nil
+	case "kml":
+		return encodeKML(s), nil
+	}
+	return nil, fmt.Errorf("unknown track format %q", format)
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func encodeGPX(s TrackSession) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<gpx version="1.1" creator="flight-ingestor" xmlns="http://www.topografix.com/GPX/1/1">` + "\n")
+	fmt.Fprintf(&b, "  <trk>\n    <name>%s</name>\n", xmlEscape(s.label()))
+	if s.Type != "" {
+		fmt.Fprintf(&b, "    <type>%s</type>\n", xmlEscape(s.Type))
+	}
+	b.WriteString("    <trkseg>\n")
+	for _, p := range s.Points {
+		fmt.Fprintf(&b, `      <trkpt lat="%.6f" lon="%.6f">`, p.Lat, p.Lon)
+		if p.HasAlt {
+			fmt.Fprintf(&b, "<ele>%.1f</ele>", p.AltFT*0.3048)
+		}
+		fmt.Fprintf(&b, "<time>%s</time></trkpt>\n", p.Time.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("    </trkseg>\n  </trk>\n</gpx>\n")
+	return []byte(b.String())
+}
+
+func encodeKML(s TrackSession) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2">` + "\n  <Document>\n")
+	fmt.Fprintf(&b, "    <name>%s</name>\n    <Placemark>\n      <name>%s</name>\n", xmlEscape(s.label()), xmlEscape(s.label()))
+	fmt.Fprintf(&b, "      <TimeSpan><begin>%s</begin><end>%s</end></TimeSpan>\n",
+		s.Start.UTC().Format(time.RFC3339), s.Last.UTC().Format(time.RFC3339))
+	b.WriteString("      <LineString>\n        <altitudeMode>absolute</altitudeMode>\n        <coordinates>\n")
+	for _, p := range s.Points {
+		fmt.Fprintf(&b, "          %.6f,%.6f,%.1f\n", p.Lon, p.Lat, p.AltFT*0.3048)
+	}
+	b.WriteString("        </coordinates>\n      </LineString>\n    </Placemark>\n  </Document>\n</kml>\n")
+	return []byte(b.String())
+}
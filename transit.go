@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- Sun/Moon transit prediction ---
+// Projects each aircraft along its current track, speed and climb rate and
+// checks whether, seen from the observer, it will cross the solar or lunar
+// disk in the next few minutes. Photographers need the warning early and the
+// look angle exactly, so the alert carries both.
+type TransitConfig struct {
+	Enabled          bool     `json:"enabled"`
+	Bodies           []string `json:"bodies"`             // "sun", "moon"; default both
+	Horizon          Duration `json:"horizon"`            // how far ahead to project, default 5m
+	MaxDistanceNM    float64  `json:"max_distance_nm"`    // default 30
+	MinBodyElevation float64  `json:"min_body_elevation"` // degrees, default 5
+	MarginDeg        float64  `json:"margin_deg"`         // added to the disk radius, default 0.1
+	Channel          string   `json:"channel"`
+	Mention          string   `json:"mention"`
+}
+
+const (
+	transitStep     = 500 * time.Millisecond
+	transitCooldown = 15 * time.Minute
+	earthRadiusFT   = 20902231.0
+	feetPerNM       = 6076.12
+)
+
+var (
+	transitAlerted = make(map[string]time.Time) // hex|body -> last alert
+	transitMutex   = &sync.Mutex{}
+)
+
+// TransitPrediction is the closest predicted approach to a body's centre.
+type TransitPrediction struct {
+	Body       string
+	At         time.Time
+	Separation float64 // degrees between aircraft and body centre
+	Radius     float64 // body's angular radius
+	Elevation  float64 // look angle at At
+	Azimuth    float64
+	RangeNM    float64 // observer to aircraft at At
+}
+
+type bodyPosition struct{ elevation, azimuth float64 }
+
+// bodyPositions samples a body every 10s across the projection window; it
+// moves ~0.04° in that time, well under the disk radius.
+func bodyPositions(site sourceCenter, body string, start time.Time, horizon time.Duration) []bodyPosition {
+	var out []bodyPosition
+	for dt := time.Duration(0); dt <= horizon+10*time.Second; dt += 10 * time.Second {
+		var e, a float64
+		if body == "moon" {
+			e, a = moonPosition(start.Add(dt), site.Lat, site.Lon)
+		} else {
+			e, a = sunPosition(start.Add(dt), site.Lat, site.Lon)
+		}
+		out = append(out, bodyPosition{e, a})
+	}
+	return out
+}
+
+// lookAngle is the elevation and azimuth of a point in the sky from the
+// site, allowing for the curvature of the Earth.
+func lookAngle(site sourceCenter, lat, lon, altFT, observerFT float64) (elevation, azimuth, rangeNM float64) {
+	rangeNM = geo.DistanceNM(site.Lat, site.Lon, lat, lon)
+	ground := rangeNM * feetPerNM
+	height := altFT - observerFT - ground*ground/(2*earthRadiusFT)
+	return math.Atan2(height, ground) / deg, geo.InitialBearing(site.Lat, site.Lon, lat, lon), rangeNM
+}
+
+func angularSeparation(e1, a1, e2, a2 float64) float64 {
+	c := math.Sin(e1*deg)*math.Sin(e2*deg) + math.Cos(e1*deg)*math.Cos(e2*deg)*math.Cos((a1-a2)*deg)
+	return math.Acos(math.Max(-1, math.Min(1, c))) / deg
+}
+
+func predictTransit(site sourceCenter, ac Aircraft, body string, now time.Time, tc TransitConfig, sky []bodyPosition) *TransitPrediction {
+	lat, lon, ok := freshCoords(ac)
+	alt, hasAlt := altitudeFeet(ac)
+	if !ok || !hasAlt || ac.Track == nil || ac.GS.Or(0) < 30 {
+		return nil
+	}
+	observerFT := observerHeightFT()
+	var rate float64
+	if ac.BaroRate != nil {
+		rate = *ac.BaroRate
+	}
+	radius := sunRadius
+	if body == "moon" {
+		radius = moonRadius(now)
+	}
+
+	var best *TransitPrediction
+	for dt := time.Duration(0); dt <= tc.Horizon.Duration; dt += transitStep {
+		b := sky[int(dt/(10*time.Second))]
+		if b.elevation < tc.MinBodyElevation {
+			continue
+		}
+		plat, plon := geo.Destination(lat, lon, *ac.Track, ac.GS.V*dt.Hours())
+		e, a, rng := lookAngle(site, plat, plon, alt+rate*dt.Minutes(), observerFT)
+		sep := angularSeparation(e, a, b.elevation, b.azimuth)
+		if best == nil || sep < best.Separation {
+			best = &TransitPrediction{Body: body, At: now.Add(dt), Separation: sep, Radius: radius, Elevation: e, Azimuth: a, RangeNM: rng}
+		}
+	}
+	if best == nil || best.Separation > radius+tc.MarginDeg {
+		return nil
+	}
+	return best
+}
+
+func processTransitAlerts(site sourceCenter, aircraft []Aircraft) {
+	tc := cfg().Transit
+	if !tc.Enabled {
+		return
+	}
+	if tc.Horizon.Duration <= 0 {
+		tc.Horizon.Duration = 5 * time.Minute
+	}
+	if tc.MaxDistanceNM <= 0 {
+		tc.MaxDistanceNM = 30
+	}
+	if tc.MinBodyElevation == 0 {
+		tc.MinBodyElevation = 5
+	}
+	if tc.MarginDeg == 0 {
+		tc.MarginDeg = 0.1
+	}
+	bodies := tc.Bodies
+	if len(bodies) == 0 {
+		bodies = []string{"sun", "moon"}
+	}
+	if !ruleActive("transit", cfg().Schedules["transit"], clock.Now()) {
+		return
+	}
+
+	now := clock.Now()
+	for _, body := range bodies {
+		body = strings.ToLower(body)
+		sky := bodyPositions(site, body, now, tc.Horizon.Duration)
+		if !slices.ContainsFunc(sky, func(b bodyPosition) bool { return b.elevation >= tc.MinBodyElevation }) {
+			continue
+		}
+		for _, ac := range aircraft {
+			lat, lon, ok := getActualCoords(ac)
+			if !ok || geo.DistanceNM(site.Lat, site.Lon, lat, lon) > tc.MaxDistanceNM+ac.GS.Or(0)*tc.Horizon.Hours() {
+				continue
+			}
+			p := predictTransit(site, ac, body, now, tc, sky)
+			if p == nil || p.RangeNM > tc.MaxDistanceNM {
+				continue
+			}
+
+			key := ac.Hex + "|" + body
+			transitMutex.Lock()
+			last, seen := transitAlerted[key]
+			if seen && now.Sub(last) < transitCooldown {
+				transitMutex.Unlock()
+				continue
+			}
+			transitAlerted[key] = now
+			for k, t := range transitAlerted {
+				if now.Sub(t) > transitCooldown {
+					delete(transitAlerted, k)
+				}
+			}
+			transitMutex.Unlock()
+
+			fmt.Printf("[Radius] !!! %s TRANSIT PREDICTED: %s at %s (%.2f° from centre)\n", strings.ToUpper(body), ac.Hex, p.At.Format("15:04:05"), p.Separation)
+			details, _ := getAircraftDetails(ac.Hex)
+			sendDiscordAlert(resolveChannel(tc.Channel), ac, details, "transit", &AlertContext{
+				Rule:    body,
+				Mention: tc.Mention,
+				Note:    p.describe(now),
+			})
+		}
+	}
+}
+
+func (p TransitPrediction) describe(now time.Time) string {
+	kind := "central"
+	if p.Separation > p.Radius*0.5 {
+		kind = "grazing"
+	}
+	if p.Separation > p.Radius {
+		kind = "near miss"
+	}
+	return fmt.Sprintf("**%s transit at <t:%d:T>** (in %v)\nLook %.1f° up, bearing %03.0f° · aircraft %s away\n%.2f° from centre, disk radius %.2f° (%s)",
+		strings.ToUpper(p.Body[:1])+p.Body[1:], p.At.Unix(), p.At.Sub(now).Round(time.Second),
+		p.Elevation, p.Azimuth, fmtDistance(p.RangeNM), p.Separation, p.Radius, kind)
+}
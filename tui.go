@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"main.go/geo"
+)
+
+// --- CLI: tui ---
+// Runs the ingestor as usual but draws a live view instead of scrolling
+// logs: the tracked aircraft as a sortable table, the recent alerts and the
+// tail of the log. Built on bubbletea, which puts the terminal in raw mode,
+// redraws on resize and restores the terminal on exit, a signal or a panic,
+// so it works over SSH in any terminal.
+//
+//	flight-ingestor tui
+//
+// Keys: s cycles the sort column, r reverses it, l swaps the alert list for
+// the log, q (or Ctrl-C) quits.
+const (
+	tuiRefresh  = time.Second
+	tuiLogLines = 500
+)
+
+var tuiSortColumns = []string{"dist", "alt", "flight", "type", "hex"}
+
+var (
+	tuiTitleStyle     = lipgloss.NewStyle().Bold(true)
+	tuiHeaderStyle    = lipgloss.NewStyle().Reverse(true)
+	tuiEmergencyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	tuiFlaggedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+type tuiRow struct {
+	Hex, Flight, Type, Squawk, Flags string
+	DistNM, AltFT                    float64
+	HasDist, HasAlt                  bool
+	Alt, Speed                       string
+}
+
+// tuiView is the bubbletea model. Width and height follow the terminal;
+// until the first size message it assumes 100x30.
+type tuiView struct {
+	sortBy  int
+	reverse bool
+	showLog bool
+	width   int
+	height  int
+}
+
+type tuiTickMsg time.Time
+
+// --- Log capture ---
+// Everything the loops print is kept in a ring instead of hitting the screen.
+var (
+	tuiLog      []string
+	tuiLogMutex = &sync.Mutex{}
+)
+
+func captureLogs(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		tuiLogMutex.Lock()
+		tuiLog = append(tuiLog, clock.Now().Format("15:04:05 ")+sc.Text())
+		if len(tuiLog) > tuiLogLines {
+			tuiLog = tuiLog[len(tuiLog)-tuiLogLines:]
+		}
+		tuiLogMutex.Unlock()
+	}
+}
+
+func tailLog(n int) []string {
+	tuiLogMutex.Lock()
+	defer tuiLogMutex.Unlock()
+	return append([]string(nil), tuiLog[max(0, len(tuiLog)-n):]...)
+}
+
+func runTUI(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: tui")
+		return 2
+	}
+	term, stderr := os.Stdout, os.Stderr
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	os.Stdout, os.Stderr = w, w
+	go captureLogs(r)
+
+	startDaemon()
+
+	p := tea.NewProgram(tuiView{width: 100, height: 30}, tea.WithAltScreen(), tea.WithOutput(term))
+	if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrInterrupted) {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func tuiTick() tea.Cmd {
+	return tea.Tick(tuiRefresh, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+func (v tuiView) Init() tea.Cmd {
+	return tuiTick()
+}
+
+func (v tuiView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width, v.height = msg.Width, msg.Height
+	case tuiTickMsg:
+		return v, tuiTick()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "Q", "ctrl+c":
+			return v, tea.Quit
+		case "s":
+			v.sortBy = (v.sortBy + 1) % len(tuiSortColumns)
+		case "r":
+			v.reverse = !v.reverse
+		case "l":
+			v.showLog = !v.showLog
+		}
+	}
+	return v, nil
+}
+
+// tuiRows builds the table from the latest radius snapshot.
+func tuiRows() []tuiRow {
+	liveMutex.RLock()
+	aircraft := append([]Aircraft(nil), liveAircraft...)
+	liveMutex.RUnlock()
+
+	radiusMutex.Lock()
+	states := make(map[string]RadiusAircraftState, len(aircraft))
+	for _, ac := range aircraft {
+		states[ac.Hex] = globalRadiusState[ac.Hex]
+	}
+	radiusMutex.Unlock()
+
+	rows := make([]tuiRow, 0, len(aircraft))
+	for _, ac := range aircraft {
+		row := tuiRow{
+			Hex:    ac.Hex,
+			Flight: strings.TrimSpace(ac.Flight),
+			Type:   ac.Type,
+			Squawk: ac.Squawk,
+			Alt:    fmtAltBaro(ac.AltBaro),
+			Speed:  fmtOptSpeed(ac.GS),
+			Flags:  tuiFlags(ac, states[ac.Hex]),
+		}
+		if lat, lon, ok := getActualCoords(ac); ok {
+			row.DistNM, row.HasDist = geo.DistanceNM(apiLat, apiLng, lat, lon), true
+		}
+		row.AltFT, row.HasAlt = altitudeFeet(ac)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// tuiFlags is a compact summary: E emergency, M military, W watchlist,
+// P proximity (with its tier).
+func tuiFlags(ac Aircraft, st RadiusAircraftState) string {
+	var f []string
+	if isEmergencySquawk(ac.Squawk) || !st.EmergencySince.IsZero() {
+		f = append(f, "E")
+	}
+	if ac.Mil || st.MilAlerted {
+		f = append(f, "M")
+	}
+	if st.WatchlistAlerted {
+		f = append(f, "W")
+	}
+	if st.ProximityAlerted {
+		if st.ProximityTier != "" {
+			f = append(f, "P:"+st.ProximityTier)
+		} else {
+			f = append(f, "P")
+		}
+	}
+	return strings.Join(f, " ")
+}
+
+func (v tuiView) sortRows(rows []tuiRow) {
+	// Rows without the sort value go last either way
+	less := func(a, b tuiRow) bool {
+		switch tuiSortColumns[v.sortBy] {
+		case "dist":
+			if a.HasDist != b.HasDist {
+				return a.HasDist
+			}
+			return a.DistNM < b.DistNM
+		case "alt":
+			if a.HasAlt != b.HasAlt {
+				return a.HasAlt
+			}
+			return a.AltFT < b.AltFT
+		case "flight":
+			return a.Flight < b.Flight
+		case "type":
+			return a.Type < b.Type
+		}
+		return a.Hex < b.Hex
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if v.reverse {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+}
+
+func (v tuiView) View() string {
+	width, height := max(v.width, 2), v.height
+	rows := tuiRows()
+	v.sortRows(rows)
+
+	var lines []string
+	line := func(style *lipgloss.Style, format string, a ...any) {
+		text := truncateRunes(fmt.Sprintf(format, a...), width)
+		if style != nil {
+			text = style.Render(text)
+		}
+		lines = append(lines, text)
+	}
+	bar := func(format string, a ...any) {
+		text := truncateRunes(fmt.Sprintf(format, a...), width)
+		lines = append(lines, tuiHeaderStyle.Width(width).Render(text))
+	}
+
+	dir := "↑"
+	if v.reverse {
+		dir = "↓"
+	}
+	line(&tuiTitleStyle, "flight-ingestor  %d aircraft  sort: %s%s  %s   [s]ort [r]everse [l]og [q]uit",
+		len(rows), tuiSortColumns[v.sortBy], dir, formatTime(clock.Now()))
+	bar("%-7s %-9s %-5s %9s %9s %10s %-5s %s", "HEX", "FLIGHT", "TYPE", "DIST", "ALT", "SPEED", "SQWK", "FLAGS")
+
+	// The bottom third goes to alerts or the log
+	panel := max(height/3, 4)
+	tableRows := max(height-panel-3, 1)
+	for i, r := range rows {
+		if i == tableRows {
+			break
+		}
+		dist := "-"
+		if r.HasDist {
+			dist = fmtDistance(r.DistNM)
+		}
+		var style *lipgloss.Style
+		switch {
+		case strings.Contains(r.Flags, "E"):
+			style = &tuiEmergencyStyle
+		case r.Flags != "":
+			style = &tuiFlaggedStyle
+		}
+		line(style, "%-7s %-9s %-5s %9s %9s %10s %-5s %s", r.Hex, truncateRunes(r.Flight, 9), truncateRunes(r.Type, 5), dist, r.Alt, r.Speed, r.Squawk, r.Flags)
+	}
+	for i := len(rows); i < tableRows; i++ {
+		line(nil, "")
+	}
+
+	if v.showLog {
+		bar("LOG")
+		logs := tailLog(panel - 1)
+		for _, l := range logs {
+			line(nil, "%s", l)
+		}
+		for i := len(logs); i < panel-1; i++ {
+			line(nil, "")
+		}
+	} else {
+		bar("RECENT ALERTS")
+		alerts := recentAlertsOfType(nil)
+		for i := range panel - 1 {
+			if i >= len(alerts) {
+				line(nil, "")
+				continue
+			}
+			a := alerts[i]
+			line(nil, "%s  %-16s %-7s %-9s %s", formatTime(a.Time), a.Type, a.Hex, a.Flight, a.Rule)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
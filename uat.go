@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"main.go/geo"
+)
+
+// --- UAT (978 MHz) ---
+// In the US a lot of low-flying GA traffic only has a UAT transponder, which
+// never shows up on 1090. With a local dump978-fa/skyaware978 receiver, its
+// aircraft.json is fetched alongside every radius poll (or push) and merged
+// in, so those aircraft reach the proximity and dwell rules too.
+type UATConfig struct {
+	URL string `json:"url"` // e.g. "http://piaware/skyaware978/data/aircraft.json"
+}
+
+var uatHTTP = newHTTPClient(5 * time.Second)
+
+func fetchUAT(url string) ([]Aircraft, error) {
+	resp, err := uatHTTP.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dump978 returned non-200 status: %s", resp.Status)
+	}
+	var data struct {
+		Aircraft []Aircraft `json:"aircraft"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data.Aircraft, nil
+}
+
+// mergeUAT adds the UAT receiver's aircraft to a 1090 snapshot. An aircraft
+// on both keeps its 1090 data unless only UAT has a position for it.
+func mergeUAT(aircraft []Aircraft) []Aircraft {
+	url := cfg().UAT.URL
+	if url == "" {
+		return aircraft
+	}
+	uat, err := fetchUAT(url)
+	if err != nil {
+		fmt.Printf("[UAT] Error fetching UAT aircraft: %v\n", err)
+		return aircraft
+	}
+
+	index := make(map[string]int, len(aircraft))
+	for i, ac := range aircraft {
+		index[ac.Hex] = i
+	}
+	added := 0
+	for _, ac := range uat {
+		ac.Hex = strings.ToLower(strings.TrimPrefix(ac.Hex, "~"))
+		if ac.MsgType == "" {
+			ac.MsgType = "uat"
+		}
+		lat, lon, hasPos := getActualCoords(ac)
+		if hasPos && geo.DistanceNM(apiLat, apiLng, lat, lon) > apiRadiusNM {
+			continue
+		}
+		if i, ok := index[ac.Hex]; ok {
+			if _, _, has1090Pos := getActualCoords(aircraft[i]); !has1090Pos && hasPos {
+				aircraft[i].Lat, aircraft[i].Lon = ac.Lat, ac.Lon
+			}
+			continue
+		}
+		if !hasPos {
+			continue
+		}
+		index[ac.Hex] = len(aircraft)
+		aircraft = append(aircraft, ac)
+		added++
+	}
+	if added > 0 {
+		fmt.Printf("[UAT] Merged %d UAT-only aircraft.\n", added)
+	}
+	return aircraft
+}
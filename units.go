@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --- Display units ---
+// Everything is tracked internally in feet, knots and nautical miles; these
+// settings only change how values are written in alerts, notes, bot replies
+// and the dashboard. The JSON API and stored records keep the internal units
+// (the field names say which) so consumers don't depend on config.
+type UnitsConfig struct {
+	Preset   string `json:"preset"`   // "aviation" (default), "imperial", "metric" or "mixed"; the fields below override it
+	Altitude string `json:"altitude"` // "ft" (default) or "m"
+	Speed    string `json:"speed"`    // "kt" (default), "kmh" or "mph"
+	Distance string `json:"distance"` // "nm" (default), "km" or "mi"
+}
+
+const (
+	metersPerFoot = 0.3048
+	kmPerNM       = 1.852
+	milesPerNM    = 1.15078
+)
+
+// unitPresets: aviation is ft/kt/nm throughout, imperial uses statute miles
+// and mph, metric is m/km/h/km, and mixed keeps feet and knots for the
+// aircraft but gives distances on the ground in km.
+var unitPresets = map[string]UnitsConfig{
+	"aviation": {Altitude: "ft", Speed: "kt", Distance: "nm"},
+	"imperial": {Altitude: "ft", Speed: "mph", Distance: "mi"},
+	"metric":   {Altitude: "m", Speed: "kmh", Distance: "km"},
+	"mixed":    {Altitude: "ft", Speed: "kt", Distance: "km"},
+}
+
+func units() UnitsConfig {
+	u := cfg().Display.Units
+	p := unitPresets[strings.ToLower(u.Preset)]
+	if u.Altitude == "" {
+		u.Altitude = p.Altitude
+	}
+	if u.Speed == "" {
+		u.Speed = p.Speed
+	}
+	if u.Distance == "" {
+		u.Distance = p.Distance
+	}
+	return u
+}
+
+// toNM picks whichever of a nm/km/mi threshold was configured, in nm.
+func toNM(nm, km, mi float64) float64 {
+	switch {
+	case km > 0:
+		return km / kmPerNM
+	case mi > 0:
+		return mi / milesPerNM
+	}
+	return nm
+}
+
+func toFeet(ft, m float64) float64 {
+	if m > 0 {
+		return m / metersPerFoot
+	}
+	return ft
+}
+
+// fmtAltitude formats an altitude in feet, e.g. "3500 ft" or "1067 m".
+func fmtAltitude(ft float64) string {
+	if strings.EqualFold(units().Altitude, "m") {
+		return fmt.Sprintf("%.0f m", ft*metersPerFoot)
+	}
+	return fmt.Sprintf("%.0f ft", ft)
+}
+
+// fmtAltBaro formats a raw alt_baro value, which may be "ground".
+func fmtAltBaro(alt any) string {
+	if ft, ok := alt.(float64); ok {
+		return fmtAltitude(ft)
+	}
+	return fmtRecordAltitude(formatAltitudeString(alt))
+}
+
+// fmtRecordAltitude formats the AltBaro string kept on records.
+func fmtRecordAltitude(alt string) string {
+	if ft, err := strconv.ParseFloat(alt, 64); err == nil {
+		return fmtAltitude(ft)
+	}
+	if strings.EqualFold(alt, "ground") {
+		return T("On ground")
+	}
+	return alt
+}
+
+func fmtSpeed(kt float64) string {
+	switch strings.ToLower(units().Speed) {
+	case "kmh", "km/h":
+		return fmt.Sprintf("%.0f km/h", kt*kmPerNM)
+	case "mph":
+		return fmt.Sprintf("%.0f mph", kt*milesPerNM)
+	}
+	return fmt.Sprintf("%.1f kts", kt)
+}
+
+// fmtOptSpeed is fmtSpeed with "N/A" for a missing ground speed.
+func fmtOptSpeed(kt OptFloat) string {
+	if !kt.Valid {
+		return "N/A"
+	}
+	return fmtSpeed(kt.V)
+}
+
+func fmtDistance(nm float64) string {
+	value, unit := convertDistance(nm)
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
+
+// fmtRadius is fmtDistance for configured radii: "50 nm" rather than "50.0 nm".
+func fmtRadius(nm float64) string {
+	value, unit := convertDistance(nm)
+	return fmt.Sprintf("%g %s", float64(int(value*10+0.5))/10, unit)
+}
+
+func convertDistance(nm float64) (float64, string) {
+	switch strings.ToLower(units().Distance) {
+	case "km":
+		return nm * kmPerNM, "km"
+	case "mi":
+		return nm * milesPerNM, "mi"
+	}
+	return nm, "nm"
+}
+
+// Spoken unit names for announcements.
+func spokenDistanceUnit() string {
+	switch strings.ToLower(units().Distance) {
+	case "km":
+		return "kilometres"
+	case "nm":
+		return "nautical miles"
+	}
+	return "miles"
+}
+
+func spokenAltitudeUnit() string {
+	if strings.EqualFold(units().Altitude, "m") {
+		return "metres"
+	}
+	return "feet"
+}
@@ -0,0 +1,786 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- CLI: validate ---
+// Checks config.json without starting anything: JSON syntax and types,
+// unknown fields (usually typos), webhook URLs, channel references, radii,
+// duplicate rule names and credentials for features that are switched on.
+// Problems are reported as file:line so they can be jumped to. --online
+// also checks the webhooks and feeds answer; see connectivity.go.
+//
+//	flight-ingestor validate [--online [--post]] [FILE]
+//
+// Exits 1 when there are errors. Warnings don't fail.
+
+// adsb.lol's /v2/point serves at most this radius (adsb.fi and airplanes.live
+// the same)
+const adsbLolMaxRadiusNM = 250
+
+var (
+	discordWebhookPattern = regexp.MustCompile(`^https://((ptb|canary)\.)?discord(app)?\.com/api/webhooks/\d+/[\w-]+$`)
+	telegramTokenPattern  = regexp.MustCompile(`^\d+:[\w-]+$`)
+	unmarshalerType       = reflect.TypeFor[json.Unmarshaler]()
+)
+
+type configProblem struct {
+	Line    int // 0 when unknown
+	Path    string
+	Message string
+	Warning bool
+}
+
+type configValidator struct {
+	data         []byte
+	lines        map[string]int // JSON path, e.g. "dwell_rules[0].channel" -> line
+	channels     map[string]string
+	webhooks     map[string]string // URL -> first path it appears at, for --online
+	pushRadiusNM float64
+	pollRadiusNM float64
+	problems     []configProblem
+}
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	online := fs.Bool("online", false, "also check that webhooks, feeds and adsbdb answer")
+	post := fs.Bool("post", false, "with --online, send a test message to every webhook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	path := configFile
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		path = fs.Arg(0)
+	default:
+		fmt.Fprintln(os.Stderr, "usage: validate [--online [--post]] [FILE]")
+		return 2
+	}
+	var conn *connectivityCheck
+	if *online || *post {
+		conn = &connectivityCheck{Post: *post}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	problems := validateConfig(data, conn)
+
+	errCount, warnCount := 0, 0
+	for _, p := range problems {
+		loc := path
+		if p.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", path, p.Line)
+		}
+		level := "error"
+		if p.Warning {
+			level = "warning"
+			warnCount++
+		} else {
+			errCount++
+		}
+		if p.Path != "" {
+			fmt.Printf("%s: %s: %s: %s\n", loc, level, p.Path, p.Message)
+		} else {
+			fmt.Printf("%s: %s: %s\n", loc, level, p.Message)
+		}
+	}
+	if errCount == 0 && warnCount == 0 {
+		fmt.Printf("%s: OK\n", path)
+	} else {
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", path, errCount, warnCount)
+	}
+	if errCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateConfig runs the static checks, and the online ones when conn is
+// set.
+func validateConfig(data []byte, conn *connectivityCheck) []configProblem {
+	v := &configValidator{data: data, lines: make(map[string]int), webhooks: make(map[string]string)}
+
+	// Syntax first; nothing else is meaningful if this fails
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal(data, new(any)); errors.As(err, &syntaxErr) {
+		v.errorAt(v.lineAt(syntaxErr.Offset), "", err.Error())
+		return v.problems
+	} else if err != nil {
+		v.errorAt(0, "", err.Error())
+		return v.problems
+	}
+
+	if err := v.walk(json.NewDecoder(bytes.NewReader(data)), reflect.TypeFor[Config](), ""); err != nil {
+		v.errorAt(0, "", err.Error())
+	}
+
+	// Older schemas are checked as they'll be loaded, i.e. after migrating
+	migrated, notes, _ := migrateConfig(data)
+	for _, note := range notes {
+		v.warnf("version", "%s; run '%s migrate' to upgrade the file", note, os.Args[0])
+	}
+	data = migrated
+
+	c := defaultConfig()
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(data, c); errors.As(err, &typeErr) {
+		v.errorAt(v.lineAt(typeErr.Offset), typeErr.Field, fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value))
+		return v.sorted()
+	} else if err != nil {
+		v.errorAt(0, "", err.Error())
+		return v.sorted()
+	}
+
+	v.checkChannels(c)
+	v.checkUnits(c)
+	v.checkRadii(c)
+	v.checkRuleNames(c)
+	v.checkCredentials(c)
+	v.checkTriggerNames(c)
+	if conn != nil {
+		v.checkConnectivity(c, *conn)
+	}
+	return v.sorted()
+}
+
+func (v *configValidator) lineAt(offset int64) int {
+	offset = min(max(offset, 0), int64(len(v.data)))
+	return bytes.Count(v.data[:offset], []byte("\n")) + 1
+}
+
+func (v *configValidator) errorAt(line int, path, msg string) {
+	v.problems = append(v.problems, configProblem{Line: line, Path: path, Message: msg})
+}
+
+// lineOf finds the line of a path, or of its closest parent for keys that
+// were left out and took their default.
+func (v *configValidator) lineOf(path string) int {
+	for path != "" {
+		if line, ok := v.lines[path]; ok {
+			return line
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0
+}
+
+func (v *configValidator) errorf(path, format string, args ...any) {
+	v.errorAt(v.lineOf(path), path, fmt.Sprintf(format, args...))
+}
+
+func (v *configValidator) warnf(path, format string, args ...any) {
+	v.problems = append(v.problems, configProblem{Line: v.lineOf(path), Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *configValidator) sorted() []configProblem {
+	sort.SliceStable(v.problems, func(i, j int) bool { return v.problems[i].Line < v.problems[j].Line })
+	return v.problems
+}
+
+// walk follows the JSON token stream alongside the Go type it decodes into,
+// recording where every path starts and flagging keys the type doesn't have.
+// Types with their own UnmarshalJSON (Duration, OptFloat) and "any" fields
+// are skipped over.
+func (v *configValidator) walk(dec *json.Decoder, t reflect.Type, path string) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	opaque := t == nil || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(unmarshalerType)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			v.lines[child] = v.lineAt(dec.InputOffset())
+
+			var next reflect.Type
+			switch {
+			case opaque:
+			case t.Kind() == reflect.Map:
+				next = t.Elem()
+			case t.Kind() == reflect.Struct:
+				field, known := jsonField(t, key)
+				if !known && !deprecatedConfigPath(child) {
+					msg := "unknown field"
+					if guess := closestField(t, key); guess != "" {
+						msg += fmt.Sprintf(" (did you mean %q?)", guess)
+					}
+					v.errorf(child, "%s", msg)
+				}
+				next = field
+			}
+			if err := v.walk(dec, next, child); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			v.lines[child] = v.lineAt(dec.InputOffset())
+			var next reflect.Type
+			if !opaque && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				next = t.Elem()
+			}
+			if err := v.walk(dec, next, child); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = dec.Token() // closing delimiter
+	return err
+}
+
+// jsonField finds the field encoding/json would decode key into.
+func jsonField(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.IsExported() && f.Tag.Get("json") != "-" && strings.EqualFold(jsonName(f), key) {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.IsExported() && f.Tag.Get("json") != "-" {
+			names = append(names, jsonName(f))
+		}
+	}
+	return names
+}
+
+func closestField(t reflect.Type, key string) string {
+	key = strings.ToLower(key)
+	best, bestDist := "", 3 // anything further off isn't a typo
+	for _, name := range jsonFieldNames(t) {
+		if strings.HasPrefix(name, key+"_") {
+			return name // "radius" for "radius_nm"
+		}
+		if d := editDistance(key, strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// --- Semantic checks ---
+
+func (v *configValidator) checkWebhook(path, url string) {
+	if !discordWebhookPattern.MatchString(url) {
+		v.errorf(path, "%q is not a Discord webhook URL (https://discord.com/api/webhooks/<id>/<token>)", url)
+		return
+	}
+	if _, ok := v.webhooks[url]; !ok && v.webhooks != nil {
+		v.webhooks[url] = path
+	}
+}
+
+func (v *configValidator) checkChannelRef(path, name string) {
+	switch {
+	case name == "":
+	case strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://"):
+		v.checkWebhook(path, name)
+	case v.channels[name] == "":
+		v.errorf(path, "unknown channel %q; define it under \"channels\" or use a webhook URL", name)
+	}
+}
+
+func (v *configValidator) checkChannels(c *Config) {
+	v.channels = c.Channels
+	for name, url := range c.Channels {
+		v.checkWebhook("channels."+name, url)
+	}
+	for i, r := range c.DwellRules {
+		v.checkChannelRef(fmt.Sprintf("dwell_rules[%d].channel", i), r.Channel)
+	}
+	for i, r := range c.OperatorRules {
+		v.checkChannelRef(fmt.Sprintf("operator_rules[%d].channel", i), r.Channel)
+	}
+	for i, r := range c.AggregateRules {
+		v.checkChannelRef(fmt.Sprintf("aggregate_rules[%d].channel", i), r.Channel)
+	}
+	for i, r := range c.DescentRules {
+		v.checkChannelRef(fmt.Sprintf("descent_rules[%d].channel", i), r.Channel)
+	}
+	for i, r := range c.SquawkRules {
+		v.checkChannelRef(fmt.Sprintf("squawk_rules[%d].channel", i), r.Channel)
+		for j, sr := range r.Ranges {
+			v.checkChannelRef(fmt.Sprintf("squawk_rules[%d].ranges[%d].channel", i, j), sr.Channel)
+		}
+	}
+	for i, sq := range c.Emergency.Squawks {
+		v.checkChannelRef(fmt.Sprintf("emergency.squawks[%d].channel", i), sq.Channel)
+	}
+	for i, s := range c.SpecialTypes {
+		v.checkChannelRef(fmt.Sprintf("special_types[%d].channel", i), s.Channel)
+	}
+	for category, ch := range c.Watchlist.CategoryChannels {
+		v.checkChannelRef("watchlist.category_channels."+category, ch)
+	}
+	for i, t := range c.Proximity.Tiers {
+		v.checkChannelRef(fmt.Sprintf("proximity.tiers[%d].channel", i), t.Channel)
+	}
+	v.checkChannelRef("emergency.escalation_channel", c.Emergency.EscalationChannel)
+	v.checkChannelRef("global_watch.channel", c.GlobalWatch.Channel)
+	v.checkChannelRef("transit.channel", c.Transit.Channel)
+	v.checkChannelRef("overhead.channel", c.Overhead.Channel)
+	v.checkChannelRef("ops.channel", c.Ops.Channel)
+	v.checkChannelRef("watchlist.diff_channel", c.Watchlist.DiffChannel)
+	v.checkChannelRef("digest.channel", c.Digest.Channel)
+	v.checkChannelRef("tracks.recap.channel", c.Tracks.Recap.Channel)
+	for i, l := range c.Locations {
+		v.checkChannelRef(fmt.Sprintf("locations[%d].webhook", i), l.Webhook)
+		types := make([]string, 0, len(l.Channels))
+		for alertType := range l.Channels {
+			types = append(types, alertType)
+		}
+		sort.Strings(types)
+		for _, alertType := range types {
+			v.checkChannelRef(fmt.Sprintf("locations[%d].channels.%s", i, alertType), l.Channels[alertType])
+		}
+	}
+}
+
+// checkRadius: negative never makes sense, beyond adsb.lol's limit can't be
+// served, and beyond the poll radius nothing will ever be seen.
+func (v *configValidator) checkRadius(path string, nm float64, required bool) {
+	pollRadius := v.pollRadiusNM
+	if v.pushRadiusNM > pollRadius {
+		pollRadius = v.pushRadiusNM
+	}
+	switch {
+	case nm < 0 || (required && nm == 0):
+		v.errorf(path, "radius must be greater than 0")
+	case nm > adsbLolMaxRadiusNM:
+		v.errorf(path, "%g nm is more than adsb.lol serves (%d nm)", nm, adsbLolMaxRadiusNM)
+	case nm > pollRadius:
+		v.warnf(path, "%g nm is beyond the %g nm poll radius; aircraft out there are never seen", nm, pollRadius)
+	}
+}
+
+// checkSources checks each entry of the source list. Offline mode skips the
+// aggregators, so it needs a local source or a receiver URL to fall back on.
+func (v *configValidator) checkSources(c *Config) {
+	local := false
+	seen := make(map[SourceConfig]bool)
+	for i, sc := range c.Sources {
+		path := fmt.Sprintf("sources[%d]", i)
+		typ := normalizeSourceType(sc.Type)
+		switch typ {
+		case "adsb.lol", "adsb.fi", "airplanes.live":
+			if sc.URL != "" {
+				v.warnf(path+".url", "the %s source builds its own URL; url is ignored", typ)
+			}
+		case "readsb":
+			local = true
+			if sc.URL == "" {
+				v.errorf(path+".url", "a readsb source needs the URL or path of aircraft.json")
+			}
+		case "sbs", "beast":
+			local = true
+			if sc.URL != "" {
+				v.warnf(path+".url", "the %s source connects to address, not a URL; url is ignored", typ)
+			}
+			if a := sc.Address; a != "" {
+				if _, _, err := net.SplitHostPort(a); err != nil {
+					v.errorf(path+".address", "%q is not host:port (e.g. \"localhost:30005\")", a)
+				}
+			}
+		case "opensky":
+			switch {
+			case (sc.ClientID == "") != (sc.ClientSecret == ""):
+				v.errorf(path+".client_id", "opensky needs both client_id and client_secret, or neither for anonymous access")
+			case sc.ClientID == "" && sc.DailyCredits == 0:
+				v.warnf(path, "anonymous OpenSky access allows %d credits a day, so polls are spaced several minutes apart", openskyAnonymousCredits)
+			}
+			if sc.DailyCredits < 0 {
+				v.errorf(path+".daily_credits", "must not be negative")
+			}
+		default:
+			v.errorf(path+".type", "unknown source %q", sc.Type)
+		}
+		if sc.StaleAfter.Duration < 0 {
+			v.errorf(path+".stale_after", "must not be negative")
+		}
+		key := sc
+		key.Type, key.StaleAfter, key.DailyCredits = typ, Duration{}, 0
+		if seen[key] {
+			v.warnf(path, "the same source is listed twice")
+		}
+		seen[key] = true
+	}
+	if c.Offline.Enabled && !local && c.Offline.ReceiverURL == "" && c.Feeder.URL == "" && !c.Push.DisablePolling {
+		v.errorf("offline.receiver_url", "offline mode polls the local receiver; set offline.receiver_url or feeder.url")
+	}
+}
+
+// checkUnits also converts km/mi/m proximity thresholds, as loading does,
+// so checkRadii sees nm.
+func (v *configValidator) checkUnits(c *Config) {
+	u := c.Display.Units
+	if _, ok := unitPresets[strings.ToLower(u.Preset)]; u.Preset != "" && !ok {
+		v.errorf("display.units.preset", "%q is not aviation, imperial, metric or mixed", u.Preset)
+	}
+	for _, f := range []struct{ path, value, allowed string }{
+		{"display.units.altitude", u.Altitude, "ft m"},
+		{"display.units.speed", u.Speed, "kt kmh km/h mph"},
+		{"display.units.distance", u.Distance, "nm km mi"},
+	} {
+		if f.value != "" && !slices.Contains(strings.Fields(f.allowed), strings.ToLower(f.value)) {
+			v.errorf(f.path, "%q is not one of %s", f.value, strings.ReplaceAll(f.allowed, " ", ", "))
+		}
+	}
+
+	_, hasNM := v.lines["proximity.radius_nm"]
+	_, hasFT := v.lines["proximity.max_alt_ft"]
+	v.checkOneUnit("proximity", hasNM, c.Proximity.RadiusKM, c.Proximity.RadiusMI, hasFT, c.Proximity.MaxAltM)
+	for i, t := range c.Proximity.Tiers {
+		v.checkOneUnit(fmt.Sprintf("proximity.tiers[%d]", i), t.RadiusNM > 0, t.RadiusKM, t.RadiusMI, t.MaxAltFT > 0, t.MaxAltM)
+	}
+	c.Proximity.applyUnits()
+}
+
+func (v *configValidator) checkOneUnit(path string, hasNM bool, km, mi float64, hasFT bool, m float64) {
+	set := 0
+	for _, has := range []bool{hasNM, km > 0, mi > 0} {
+		if has {
+			set++
+		}
+	}
+	if set > 1 {
+		v.errorf(path+".radius_nm", "set only one of radius_nm, radius_km and radius_mi")
+	}
+	if hasFT && m > 0 {
+		v.errorf(path+".max_alt_ft", "set only one of max_alt_ft and max_alt_m")
+	}
+}
+
+func (v *configValidator) checkRadii(c *Config) {
+	v.pushRadiusNM = c.Push.RadiusNM
+	v.pollRadiusNM = apiRadiusNM
+	if c.Observer.RadiusNM != 0 {
+		v.pollRadiusNM = c.Observer.RadiusNM
+		if c.Observer.RadiusNM < 0 || c.Observer.RadiusNM > adsbLolMaxRadiusNM {
+			v.errorf("observer.radius_nm", "must be between 0 and %d nm", adsbLolMaxRadiusNM)
+		}
+	}
+	names := make(map[string]bool)
+	for i, l := range c.Locations {
+		path := fmt.Sprintf("locations[%d]", i)
+		switch {
+		case l.Name == "":
+			v.errorf(path+".name", "every location needs a name")
+		case names[l.Name] || l.Name == c.Observer.Name:
+			v.errorf(path+".name", "%q is used twice; location state is kept by name", l.Name)
+		}
+		names[l.Name] = true
+		if math.Abs(l.Lat) > 90 || math.Abs(l.Lon) > 180 || (l.Lat == 0 && l.Lon == 0) {
+			v.errorf(path+".lat", "%g, %g is not a position", l.Lat, l.Lon)
+		}
+		if l.RadiusNM < 0 || l.RadiusNM > adsbLolMaxRadiusNM {
+			v.errorf(path+".radius_nm", "must be between 0 and %d nm", adsbLolMaxRadiusNM)
+		}
+		if c.Offline.Enabled && l.FeedURL == "" {
+			v.warnf(path+".feed_url", "offline mode doesn't poll adsb.lol; this location needs its own feed_url")
+		}
+	}
+	if len(c.Locations) > 0 && c.Observer.Name == "" {
+		v.warnf("observer.name", "with several locations, name the home site so its alerts are tagged too")
+	}
+	if (c.Observer.Lat == nil) != (c.Observer.Lon == nil) {
+		v.errorf("observer.lat", "set both observer.lat and observer.lon, or neither")
+	}
+	if c.Observer.Lat != nil && c.Observer.Lon != nil && (math.Abs(*c.Observer.Lat) > 90 || math.Abs(*c.Observer.Lon) > 180) {
+		v.errorf("observer.lat", "%g, %g is not a position", *c.Observer.Lat, *c.Observer.Lon)
+	}
+	if len(c.Proximity.Tiers) == 0 {
+		v.checkRadius("proximity.radius_nm", c.Proximity.RadiusNM, true)
+	}
+	for i, t := range c.Proximity.Tiers {
+		v.checkRadius(fmt.Sprintf("proximity.tiers[%d].radius_nm", i), t.RadiusNM, true)
+	}
+	for i, r := range c.DwellRules {
+		v.checkRadius(fmt.Sprintf("dwell_rules[%d].radius_nm", i), r.RadiusNM, true)
+	}
+	for i, r := range c.OperatorRules {
+		v.checkRadius(fmt.Sprintf("operator_rules[%d].radius_nm", i), r.RadiusNM, true)
+	}
+	for i, r := range c.AggregateRules {
+		v.checkRadius(fmt.Sprintf("aggregate_rules[%d].radius_nm", i), r.RadiusNM, false)
+		if r.MinCount < 1 {
+			v.errorf(fmt.Sprintf("aggregate_rules[%d].min_count", i), "must be at least 1; an empty poll would match")
+		}
+	}
+	for i, r := range c.DescentRules {
+		v.checkRadius(fmt.Sprintf("descent_rules[%d].radius_nm", i), r.RadiusNM, false)
+	}
+	for i, r := range c.SquawkRules {
+		v.checkRadius(fmt.Sprintf("squawk_rules[%d].radius_nm", i), r.RadiusNM, false)
+	}
+	if c.Push.RadiusNM > adsbLolMaxRadiusNM*2 {
+		v.warnf("push.radius_nm", "%g nm is further than any receiver hears", c.Push.RadiusNM)
+	}
+}
+
+// checkRuleNames: rule state, cooldowns and silencing are all keyed by rule
+// name, so two rules sharing a name step on each other.
+func (v *configValidator) checkRuleNames(c *Config) {
+	seen := make(map[string]string)
+	check := func(path, name string) {
+		if strings.TrimSpace(name) == "" {
+			v.errorf(path, "rule has no name")
+			return
+		}
+		key := strings.ToLower(name)
+		if first, dup := seen[key]; dup {
+			v.errorf(path+".name", "rule name %q is already used by %s; rule state and silencing are keyed by name", name, first)
+			return
+		}
+		seen[key] = path
+	}
+	for i, r := range c.DwellRules {
+		check(fmt.Sprintf("dwell_rules[%d]", i), r.Name)
+	}
+	for i, r := range c.OperatorRules {
+		check(fmt.Sprintf("operator_rules[%d]", i), r.Name)
+	}
+	for i, r := range c.AggregateRules {
+		check(fmt.Sprintf("aggregate_rules[%d]", i), r.Name)
+	}
+	for i, r := range c.DescentRules {
+		check(fmt.Sprintf("descent_rules[%d]", i), r.Name)
+	}
+	for i, r := range c.SquawkRules {
+		check(fmt.Sprintf("squawk_rules[%d]", i), r.Name)
+	}
+	tiers := make(map[string]bool)
+	for i, t := range c.Proximity.Tiers {
+		path := fmt.Sprintf("proximity.tiers[%d]", i)
+		switch key := strings.ToLower(t.Name); {
+		case key == "" && len(c.Proximity.Tiers) > 1:
+			v.errorf(path, "tier has no name; tiers are told apart by name")
+		case tiers[key]:
+			v.errorf(path+".name", "tier name %q is used twice", t.Name)
+		}
+		tiers[strings.ToLower(t.Name)] = true
+		if _, err := renderProximityTemplate(t.Title, ProximityData{}); err != nil {
+			v.errorf(path+".title", "%v", err)
+		}
+		if _, err := renderProximityTemplate(t.Message, ProximityData{}); err != nil {
+			v.errorf(path+".message", "%v", err)
+		}
+	}
+	for name := range c.Regions {
+		if len(c.Regions[name]) < 3 {
+			v.errorf("regions."+name, "region needs at least 3 vertices")
+		}
+	}
+}
+
+func (v *configValidator) checkCredentials(c *Config) {
+	for i, p := range c.Routes.Providers {
+		if strings.EqualFold(p, "aeroapi") && c.AeroAPI.APIKey == "" {
+			v.errorf(fmt.Sprintf("routes.providers[%d]", i), "aeroapi is listed but aeroapi.api_key is empty")
+		}
+	}
+	if c.Mastodon.Server != "" && c.Mastodon.AccessToken == "" {
+		v.errorf("mastodon.server", "mastodon is enabled but access_token is empty")
+	}
+	if c.Bluesky.Handle != "" && c.Bluesky.AppPassword == "" {
+		v.errorf("bluesky.handle", "bluesky is enabled but app_password is empty")
+	}
+	if c.S3.Bucket != "" && (c.S3.AccessKey == "" || c.S3.SecretKey == "") {
+		v.errorf("s3.bucket", "s3 archival is enabled but access_key or secret_key is empty")
+	}
+	if c.Telegram.BotToken != "" && !telegramTokenPattern.MatchString(c.Telegram.BotToken) {
+		v.errorf("telegram.bot_token", "doesn't look like a bot token (<id>:<secret> from @BotFather)")
+	}
+	if k := c.DiscordBot.PublicKey; k != "" && (len(k) != 64 || strings.Trim(strings.ToLower(k), "0123456789abcdef") != "") {
+		v.errorf("discord_bot.public_key", "expected the 64 hex character application public key")
+	}
+	if (c.Push.Token != "" || c.DiscordBot.PublicKey != "") && c.HTTPListen == "" {
+		v.errorf("http_listen", "push and discord_bot need the HTTP API; set http_listen")
+	}
+	if c.HTTPListen != "" && c.HTTPToken == "" {
+		v.warnf("http_token", "empty, so the API refuses changes to /mutes and POST /reload")
+	}
+	if c.Map.local() && c.Feed.BaseURL == "" && !c.Attachments.Map {
+		v.warnf("map.renderer", "local maps are served from the API; without feed.base_url or attachments.map Discord embeds get no map")
+	}
+	for i, k := range c.Map.APIKeys {
+		if strings.TrimSpace(k) == "" {
+			v.errorf(fmt.Sprintf("map.api_keys[%d]", i), "empty key")
+		}
+	}
+	switch strings.ToLower(c.Proximity.AltitudeReference) {
+	case "", "baro", "agl", "observer":
+	default:
+		v.errorf("proximity.altitude_reference", "%q is not one of baro, agl, observer", c.Proximity.AltitudeReference)
+	}
+	if e := c.Observer.ElevationFT; e != nil && (*e < -1500 || *e > 30000) {
+		v.errorf("observer.elevation_ft", "%g ft is not a plausible site elevation (feet, not metres)", *e)
+	}
+	for i, a := range c.Airlines.Extra {
+		if _, _, ok := splitCallsign(a.ICAO + "1"); !ok || len(a.ICAO) != 3 {
+			v.errorf(fmt.Sprintf("airlines.extra[%d].icao", i), "%q is not a three letter ICAO airline designator", a.ICAO)
+		}
+	}
+	if c.Map.CellDeg > 0.1 {
+		v.warnf("map.cell_deg", "%g° cells can put the marker miles from the aircraft", c.Map.CellDeg)
+	}
+	if u := c.Map.StaticURL; u != "" && (!strings.Contains(u, "{lat}") || !strings.Contains(u, "{lon}")) {
+		v.errorf("map.static_url", "template needs {lat} and {lon} placeholders")
+	}
+	v.checkSources(c)
+	if c.Offline.Enabled && c.Offline.AircraftDB == "" {
+		v.warnf("offline.aircraft_db", "without a local aircraft database alerts won't have registration or owner")
+	}
+	seenCodes := make(map[string]bool)
+	for i, sq := range c.Emergency.Squawks {
+		if _, ok := parseSquawk(sq.Code); !ok {
+			v.errorf(fmt.Sprintf("emergency.squawks[%d].code", i), "%q is not a four digit octal squawk", sq.Code)
+		} else if seenCodes[sq.Code] {
+			v.errorf(fmt.Sprintf("emergency.squawks[%d].code", i), "%s is listed twice", sq.Code)
+		}
+		seenCodes[sq.Code] = true
+	}
+	seenTypes := make(map[string]bool)
+	for i, s := range c.SpecialTypes {
+		t := strings.ToUpper(s.Type)
+		if t == "" {
+			v.errorf(fmt.Sprintf("special_types[%d].type", i), "type designator is empty")
+		} else if seenTypes[t] {
+			v.errorf(fmt.Sprintf("special_types[%d].type", i), "%s is listed twice", s.Type)
+		}
+		seenTypes[t] = true
+		if s.Color < 0 || s.Color > 0xFFFFFF {
+			v.errorf(fmt.Sprintf("special_types[%d].color", i), "%d is not an RGB color", s.Color)
+		}
+	}
+	quietChannels := make([]string, 0, len(c.QuietHours))
+	for name := range c.QuietHours {
+		quietChannels = append(quietChannels, name)
+	}
+	sort.Strings(quietChannels)
+	for _, name := range quietChannels {
+		q := c.QuietHours[name]
+		path := "quiet_hours." + name
+		v.checkChannelRef(path, name)
+		if len(q.Windows) == 0 {
+			v.warnf(path+".windows", "no windows, so quiet hours never apply")
+		}
+		for i, w := range q.Windows {
+			for _, t := range []string{w.Start, w.End} {
+				if _, err := time.Parse("15:04", t); t != "" && err != nil {
+					v.errorf(fmt.Sprintf("%s.windows[%d]", path, i), "%q is not an HH:MM time", t)
+				}
+			}
+		}
+		if q.Timezone != "" {
+			if _, err := time.LoadLocation(q.Timezone); err != nil {
+				v.errorf(path+".timezone", "unknown timezone %q", q.Timezone)
+			}
+		}
+		if m := strings.ToLower(q.Mode); m != "" && m != "suppress" && m != "queue" {
+			v.errorf(path+".mode", "%q is not \"suppress\" or \"queue\"", q.Mode)
+		}
+		for i, t := range q.Except {
+			if !slices.Contains(alertTypes, t) {
+				v.warnf(fmt.Sprintf("%s.except[%d]", path, i), "unknown alert type %q", t)
+			}
+		}
+	}
+	if c.Tracks.Recap.Enabled && !c.Tracks.Enabled {
+		v.warnf("tracks.recap.enabled", "recaps are drawn from stored tracks; set tracks.enabled")
+	}
+	if c.Tracks.Recap.Digest && c.Digest.Channel == "" {
+		v.errorf("tracks.recap.digest", "recaps are held for the digest but digest.channel is empty")
+	}
+	if c.Push.DisablePolling && c.Push.Token == "" {
+		v.errorf("push.disable_polling", "polling is off but push.token is empty, so no data will arrive")
+	}
+}
+
+// Built-in trigger names accepted by "schedules"
+var builtinTriggers = []string{"watchlist", "emergency", "military", "proximity", "special_military"}
+
+func (v *configValidator) checkTriggerNames(c *Config) {
+	for name := range c.Schedules {
+		known := false
+		for _, t := range builtinTriggers {
+			known = known || strings.EqualFold(t, name)
+		}
+		if !known {
+			v.warnf("schedules."+name, "unknown trigger %q; schedules apply to %s (rules take their own \"schedule\")", name, strings.Join(builtinTriggers, ", "))
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Watchlist (plane-alert-db) helpers ---
+type WatchlistConfig struct {
+	// Route entries by plane-alert-db category, e.g. "Dictator Alert" -> "dictators".
+	// Categories without an entry go to the watchlist channel.
+	CategoryChannels map[string]string `json:"category_channels"`
+
+	// Post what changed upstream after each refresh. Default the ops channel.
+	DiffChannel  string `json:"diff_channel"`
+	DiffExamples int    `json:"diff_examples"` // entries named per side, default 5
+}
+
+// watchlistColumns maps normalised CSV header names ("$#Tag 2" -> "tag 2") to indices.
+type watchlistColumns map[string]int
+
+func parseWatchlistHeader(header []string) watchlistColumns {
+	cols := make(watchlistColumns)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimLeft(name, "$#")))
+		if _, dup := cols[name]; !dup {
+			cols[name] = i
+		}
+	}
+	return cols
+}
+
+func (c watchlistColumns) get(row []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// watchlistEntryFromRow reads a plane-alert-db row by header name, falling
+// back to the historical column positions for headerless files.
+func watchlistEntryFromRow(cols watchlistColumns, row []string) (WatchlistEntry, bool) {
+	col := func(name string, index int) string {
+		if _, ok := cols[name]; ok {
+			return cols.get(row, name)
+		}
+		if index < len(row) {
+			return strings.TrimSpace(row[index])
+		}
+		return ""
+	}
+	entry := WatchlistEntry{
+		ICAO:         strings.ToLower(col("icao", 0)), // feeds report lowercase hexes
+		Registration: col("registration", 1),
+		Operator:     col("operator", 2),
+		TypeName:     col("type", 3),
+		PlaneType:    col("icao type", 4),
+		CMPG:         col("cmpg", 5),
+		Note:         col("tag 1", 6),
+		Category:     col("category", 9),
+		Link:         col("link", 10),
+	}
+	if entry.ICAO == "" {
+		return entry, false
+	}
+	for _, tag := range []string{col("tag 2", 7), col("tag 3", 8)} {
+		if tag != "" {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+	for i, name := range []string{"imagelink", "imagelink2", "imagelink3", "imagelink4"} {
+		if u := col(name, 11+i); strings.HasPrefix(u, "http") {
+			entry.ImageURLs = append(entry.ImageURLs, u)
+		}
+	}
+	return entry, true
+}
+
+// watchlistEntryFields are the curated bits of a watchlist alert.
+func watchlistEntryFields(entry WatchlistEntry) []Field {
+	var fields []Field
+	if entry.Category != "" {
+		badge := strings.Join(append([]string{entry.Category}, entry.Tags...), " · ")
+		if entry.CMPG != "" {
+			badge += " (" + entry.CMPG + ")"
+		}
+		fields = append(fields, Field{Name: "Category", Value: fmt.Sprintf("🏷️ %s", badge), Inline: false})
+	}
+	if entry.Operator != "" {
+		fields = append(fields, Field{Name: "Listed Operator", Value: entry.Operator, Inline: true})
+	}
+	if entry.TypeName != "" {
+		fields = append(fields, Field{Name: "Listed Type", Value: entry.TypeName, Inline: true})
+	}
+	var links []string
+	if entry.Link != "" {
+		links = append(links, fmt.Sprintf("[%s](%s)", T("Info"), entry.Link))
+	}
+	for i, u := range entry.ImageURLs {
+		links = append(links, fmt.Sprintf("[%s %d](%s)", T("Photo"), i+1, u))
+	}
+	if len(links) > 0 {
+		fields = append(fields, Field{Name: "Links", Value: strings.Join(links, " · "), Inline: false})
+	}
+	return fields
+}
+
+func watchlistWebhook(entry WatchlistEntry) string {
+	for category, channel := range cfg().Watchlist.CategoryChannels {
+		if strings.EqualFold(category, entry.Category) {
+			if hook := resolveChannel(channel); hook != "" {
+				return hook
+			}
+		}
+	}
+	return discordHookWatchlist
+}
+
+// --- Upstream diffs ---
+// The previous refresh's CSV entries, before manual additions are merged
+// in. Nil until the first load, which is the baseline and isn't reported.
+var upstreamWatchlist map[string]WatchlistEntry
+
+func diffWatchlist(old, new map[string]WatchlistEntry) (added, removed []WatchlistEntry) {
+	for hex, e := range new {
+		if _, ok := old[hex]; !ok {
+			added = append(added, e)
+		}
+	}
+	for hex, e := range old {
+		if _, ok := new[hex]; !ok {
+			removed = append(removed, e)
+		}
+	}
+	byReg := func(list []WatchlistEntry) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Registration < list[j].Registration })
+	}
+	byReg(added)
+	byReg(removed)
+	return added, removed
+}
+
+func watchlistEntryLabel(e WatchlistEntry) string {
+	name := e.Registration
+	if name == "" {
+		name = e.ICAO
+	}
+	if e.Note != "" {
+		name += " – " + e.Note
+	}
+	return name
+}
+
+// describeWatchlistDiff reads "+12 new aircraft, including N757AF – Trump 757, ...".
+func describeWatchlistDiff(added, removed []WatchlistEntry, examples int) string {
+	side := func(sign, what string, list []WatchlistEntry) string {
+		line := fmt.Sprintf("**%s%d %s**", sign, len(list), what)
+		var names []string
+		for _, e := range list[:min(examples, len(list))] {
+			names = append(names, watchlistEntryLabel(e))
+		}
+		if len(names) > 0 {
+			line += ", including " + strings.Join(names, ", ")
+		}
+		if len(list) > examples {
+			line += fmt.Sprintf(" and %d more", len(list)-examples)
+		}
+		return line
+	}
+	var lines []string
+	if len(added) > 0 {
+		lines = append(lines, side("+", "new aircraft", added))
+	}
+	if len(removed) > 0 {
+		lines = append(lines, side("−", "removed", removed))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func notifyWatchlistDiff(added, removed []WatchlistEntry) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	wc := cfg().Watchlist
+	examples := wc.DiffExamples
+	if examples <= 0 {
+		examples = 5
+	}
+	fmt.Printf("[WL] Upstream watchlist changed: +%d -%d\n", len(added), len(removed))
+	webhook := resolveChannel(wc.DiffChannel)
+	if webhook == "" {
+		webhook = resolveChannel(cfg().Ops.Channel)
+	}
+	if webhook == "" {
+		return
+	}
+	embed := Embed{
+		Title:       "📋 Watchlist Updated",
+		Description: truncateRunes(describeWatchlistDiff(added, removed, examples), 4000),
+		Color:       16776960, // Yellow
+	}
+	if err := postDiscordWebhook(webhook, DiscordWebhook{Embeds: []Embed{embed}}); err != nil {
+		fmt.Printf("[WL] Error posting watchlist diff: %v\n", err)
+	}
+}
+
+// --- Runtime watchlist additions ---
+// Entries added through the command channel survive the daily CSV refresh.
+var manualWatchlist = make(map[string]WatchlistEntry)
+
+func addWatch(entry WatchlistEntry) {
+	entry.ICAO = strings.ToLower(strings.TrimSpace(entry.ICAO))
+	watchlistMutex.Lock()
+	defer watchlistMutex.Unlock()
+	manualWatchlist[entry.ICAO] = entry
+	globalWatchlist[entry.ICAO] = entry
+}
+
+// removeWatch drops a runtime entry. CSV entries come back on the next refresh.
+func removeWatch(hex string) bool {
+	hex = strings.ToLower(strings.TrimSpace(hex))
+	watchlistMutex.Lock()
+	defer watchlistMutex.Unlock()
+	_, manual := manualWatchlist[hex]
+	_, listed := globalWatchlist[hex]
+	delete(manualWatchlist, hex)
+	delete(globalWatchlist, hex)
+	return manual || listed
+}